@@ -51,7 +51,14 @@ var (
 	printAvgDetectorTime = cli.Flag("print-avg-detector-time", "Print the average time spent on each detector.").Bool()
 	noUpdate             = cli.Flag("no-update", "Don't check for updates.").Bool()
 	fail                 = cli.Flag("fail", "Exit with code 183 if results are found.").Bool()
+	remediate            = cli.Flag("remediate", "Revoke verified secrets for the detectors that support it. This is destructive and can't be undone.").Bool()
 	verifiers            = cli.Flag("verifier", "Set custom verification endpoints.").StringMap()
+	verificationRate     = cli.Flag("verification-rate-limit", "Maximum number of verification requests per second across all detectors. 0 means unlimited.").Float64()
+	verificationAttempts = cli.Flag("verification-attempts", "Maximum number of attempts of verification requests that are rate limited or fail transiently, for the detectors that retry them.").Default(strconv.Itoa(detectors.DefaultRetryPolicy.MaxAttempts)).Int()
+	maxResultsPerChunk   = cli.Flag("max-results-per-chunk", "Maximum number of results a detector returns for a single chunk. 0 means unlimited.").Default(strconv.Itoa(detectors.DefaultMaxResultsPerChunk)).Int()
+	detectorTimeout      = cli.Flag("detector-timeout", "Maximum time a detector may spend on a single chunk, including verification. Detectors taking longer are abandoned and logged.").Default(engine.DefaultDetectorTimeout.String()).Duration()
+	traceDetectors       = cli.Flag("trace-detectors", "Record the decisions of the detectors that support it, such as matched patterns and verification outcomes, in each result's Trace for tuning detectors. Only shown in JSON output.").Bool()
+	chunkFingerprints    = cli.Flag("chunk-fingerprints", "Path to a file recording fingerprints of scanned chunks. Chunks unchanged since the previous run are skipped.").String()
 	archiveMaxSize       = cli.Flag("archive-max-size", "Maximum size of archive to scan. (Byte units eg. 512B, 2KB, 4MB)").Bytes()
 	archiveMaxDepth      = cli.Flag("archive-max-depth", "Maximum depth of archive to scan.").Int()
 	archiveTimeout       = cli.Flag("archive-timeout", "Maximum time to spend extracting an archive.").Duration()
@@ -91,13 +98,45 @@ var (
 	gitlabScanExcludePaths = gitlabScan.Flag("exclude-paths", "Path to file with newline separated regexes for files to exclude in scan.").Short('x').String()
 
 	filesystemScan  = cli.Command("filesystem", "Find credentials in a filesystem.")
-	filesystemPaths = filesystemScan.Arg("path", "Path to file or directory to scan.").Strings()
+	filesystemPaths = filesystemScan.Arg("path", "Path to file or directory to scan. Braces ({a,b}) and environment variables ($VAR, ${VAR}) are expanded.").Strings()
 	// DEPRECATED: --directory is deprecated in favor of arguments.
 	filesystemDirectories = filesystemScan.Flag("directory", "Path to directory to scan. You can repeat this flag.").Strings()
 	// TODO: Add more filesystem scan options. Currently only supports scanning a list of directories.
 	// filesystemScanRecursive = filesystemScan.Flag("recursive", "Scan recursively.").Short('r').Bool()
 	filesystemScanIncludePaths = filesystemScan.Flag("include-paths", "Path to file with newline separated regexes for files to include in scan.").Short('i').String()
 	filesystemScanExcludePaths = filesystemScan.Flag("exclude-paths", "Path to file with newline separated regexes for files to exclude in scan.").Short('x').String()
+	filesystemScanExcludeGlobs = filesystemScan.Flag("exclude-globs", "Comma separated list of globs to exclude in scan.").String()
+	filesystemArchiveInclude   = filesystemScan.Flag("archive-include-globs", "Comma separated list of globs for the entries to scan inside archives. All entries are scanned by default.").String()
+	filesystemArchiveExclude   = filesystemScan.Flag("archive-exclude-globs", "Comma separated list of globs for entries to skip inside archives.").String()
+	filesystemArchiveMaxRatio  = filesystemScan.Flag("archive-max-ratio", "Abort extracting an archive once it expands to more than this multiple of its size. 0 means unlimited.").Int()
+	filesystemArchiveMaxOutput = filesystemScan.Flag("archive-max-extracted-size", "Abort extracting an archive once this much data was extracted from it. 0 means unlimited. (Byte units eg. 512B, 2KB, 4MB)").Bytes()
+	filesystemReferencedCreds  = filesystemScan.Flag("scan-referenced-credentials", "Also scan the credential files used by the package manager of each lockfile found, such as .npmrc and .netrc next to it or in the home directory.").Bool()
+	filesystemMaxOpenFiles     = filesystemScan.Flag("max-open-files", "Maximum number of files open at once while scanning. 0 means the default of 256.").Int()
+	filesystemHiddenFiles      = filesystemScan.Flag("hidden-files", "Whether to scan hidden files (named or in directories named with a leading dot) when walking directories: include, exclude, or only.").Default("include").Enum("include", "exclude", "only")
+	filesystemLabels           = filesystemScan.Flag("label", `Label attached to findings in files under a path prefix. You can repeat this flag. Example: "/etc/app/prod=production"`).StringMap()
+	filesystemScanDiff         = filesystemScan.Flag("diff", "Path to a unified diff. Only added lines are scanned, with file paths in the diff relative to the scan path.").ExistingFile()
+	filesystemManifest         = filesystemScan.Flag("manifest", `Path to a JSON manifest of paths to scan, each with its own label, max size and filter. Example: {"paths": [{"path": "/srv/app", "label": "prod", "max_size": 1048576, "exclude_paths": ["/vendor/"]}]}`).ExistingFile()
+	filesystemChunksNDJSON     = filesystemScan.Flag("chunks-ndjson", "Write chunks to stdout as newline delimited JSON instead of scanning them.").Bool()
+	filesystemEmptyFiles       = filesystemScan.Flag("emit-empty-files", "Emit a chunk without data for each empty file, so that every scanned file appears in --chunks-ndjson output.").Bool()
+	filesystemFileSymlinks     = filesystemScan.Flag("follow-file-symlinks", "Resolve symlinks to files found in directories and scan each target once, skipping targets within the scanned paths, which are scanned anyway. Symlinks to files are scanned once per symlink otherwise, and symlinks to directories are never followed.").Bool()
+	filesystemSymlinkEscape    = filesystemScan.Flag("allow-symlink-escape", "With --follow-file-symlinks, also scan targets outside of the scanned paths, which are skipped and logged otherwise.").Bool()
+	filesystemDetectEncoding   = filesystemScan.Flag("detect-encoding", "Transcode files detected as UTF-16 or Latin-1 to UTF-8 before scanning them.").Bool()
+	filesystemContentTypes     = filesystemScan.Flag("content-type", `Only scan files whose content type, detected from their content, matches. Wildcards such as "text/*" are supported. You can repeat this flag.`).Strings()
+	filesystemResolveGitLFS    = filesystemScan.Flag("resolve-git-lfs", "Scan the objects Git LFS pointer files point to, read from the local LFS cache. Pointers whose object isn't cached are skipped.").Bool()
+	filesystemSizePercentile   = filesystemScan.Flag("size-percentile-cutoff", "Skip files larger than this percentile of the sizes of the files to scan, e.g. 90 skips the largest 10%. Requires surveying file sizes before scanning. 0 means no cutoff.").Int()
+	filesystemImageTarballs    = filesystemScan.Flag("image-tarballs", "Scan the files of container images saved with docker save layer by layer, skipping files deleted or replaced by upper layers.").Bool()
+	filesystemAttributes       = filesystemScan.Flag("file-attributes", "Also scan the extended attributes of files on Linux and macOS, or their alternate data streams on Windows.").Bool()
+	filesystemUnitsNDJSON      = filesystemScan.Flag("units-ndjson", "Write the units the paths enumerate to stdout as newline delimited JSON instead of scanning them, for scanning with --units-file elsewhere.").Bool()
+	filesystemUnitsFile        = filesystemScan.Flag("units-file", "Scan the units listed in a file written with --units-ndjson instead of the paths.").ExistingFile()
+	filesystemChunkBatchSize   = filesystemScan.Flag("chunk-batch-size", "Number of chunks to group into each batch when scanning a --units-file, which reduces contention on trees of many tiny files. 0 disables batching.").Int()
+	filesystemExcludeFPPaths   = filesystemScan.Flag("exclude-false-positive-paths", "Skip files known to produce false positives, such as minified assets, lockfiles and test fixtures.").Bool()
+	filesystemFPPaths          = filesystemScan.Flag("false-positive-path", "Glob of further files to skip with --exclude-false-positive-paths. You can repeat this flag.").Strings()
+	filesystemHighSignalOnly   = filesystemScan.Flag("high-signal-only", "Only scan files in locations strongly associated with secrets, such as .env files, credential files, private keys and config directories.").Bool()
+	filesystemHighSignalPaths  = filesystemScan.Flag("high-signal-path", "Glob of further files to scan with --high-signal-only. You can repeat this flag.").Strings()
+	filesystemAnnotateGit      = filesystemScan.Flag("annotate-git", "Attach the email of the author of the last commit to each file in a git working tree to its findings, normalized with .mailmap, e.g. to route them to the author.").Bool()
+	filesystemSampleRate       = filesystemScan.Flag("sample-rate", "Only scan this fraction of the files in directories, e.g. 0.1, selected by a hash of their path. For a quick triage of large trees, not a complete scan. 0 scans all files.").Float64()
+	filesystemProgressInterval = filesystemScan.Flag("progress-interval", "Update the scan progress with the file being scanned and the counts so far at this interval, e.g. 5s. 0 only updates it when each path is started.").Duration()
+	filesystemNoSkipDirs       = filesystemScan.Flag("no-default-skip-dirs", "Descend into the directories skipped by default: .git, node_modules, vendor and .terraform.").Bool()
 
 	s3Scan              = cli.Command("s3", "Find credentials in S3 buckets.")
 	s3ScanKey           = s3Scan.Flag("key", "S3 key used to authenticate. Can be provided with environment variable AWS_ACCESS_KEY_ID.").Envar("AWS_ACCESS_KEY_ID").String()
@@ -225,6 +264,15 @@ func run(state overseer.State) {
 		}
 	}
 
+	if *verificationRate > 0 {
+		detectors.SetVerificationRateLimit(*verificationRate)
+	}
+	retryPolicy := detectors.DefaultRetryPolicy
+	retryPolicy.MaxAttempts = *verificationAttempts
+	detectors.SetRetryPolicy(retryPolicy)
+	detectors.SetMaxResultsPerChunk(*maxResultsPerChunk)
+	detectors.SetTracing(*traceDetectors)
+
 	if *archiveMaxSize != 0 {
 		handlers.SetArchiveMaxSize(int(*archiveMaxSize))
 	}
@@ -316,7 +364,11 @@ func run(state overseer.State) {
 		return true
 	}
 
-	e := engine.Start(ctx,
+	if *remediate {
+		logger.Info("WARNING: remediation is enabled, verified secrets will be revoked where supported")
+	}
+
+	engineOpts := []engine.EngineOption{
 		engine.WithConcurrency(*concurrency),
 		engine.WithDecoders(decoders.DefaultDecoders()...),
 		engine.WithDetectors(!*noVerification, engine.DefaultDetectors()...),
@@ -325,7 +377,18 @@ func run(state overseer.State) {
 		engine.WithFilterDetectors(excludeFilter),
 		engine.WithFilterDetectors(endpointCustomizer),
 		engine.WithFilterUnverified(*filterUnverified),
-	)
+		engine.WithRemediation(*remediate),
+		engine.WithDetectorTimeout(*detectorTimeout),
+		engine.WithOnlyVerified(*onlyVerified),
+	}
+	if *chunkFingerprints != "" {
+		fingerprints, err := sources.LoadChunkFingerprints(*chunkFingerprints)
+		if err != nil {
+			logFatal(err, "could not load chunk fingerprints")
+		}
+		engineOpts = append(engineOpts, engine.WithChunkFingerprints(fingerprints))
+	}
+	e := engine.Start(ctx, engineOpts...)
 
 	var repoPath string
 	var remote bool
@@ -409,10 +472,68 @@ func run(state overseer.State) {
 		paths = append(paths, *filesystemPaths...)
 		paths = append(paths, *filesystemDirectories...)
 		cfg := sources.FilesystemConfig{
-			Paths:  paths,
-			Filter: filter,
+			Paths:        paths,
+			Filter:       filter,
+			DiffPath:     *filesystemScanDiff,
+			ManifestPath: *filesystemManifest,
+			Labels:       *filesystemLabels,
+
+			HiddenFiles:               *filesystemHiddenFiles,
+			MaxOpenFiles:              *filesystemMaxOpenFiles,
+			ChunkBatchSize:            *filesystemChunkBatchSize,
+			ProgressInterval:          *filesystemProgressInterval,
+			SampleRate:                *filesystemSampleRate,
+			AnnotateGit:               *filesystemAnnotateGit,
+			NoDefaultSkipDirs:         *filesystemNoSkipDirs,
+			ExcludeFalsePositivePaths: *filesystemExcludeFPPaths,
+			FalsePositivePaths:        *filesystemFPPaths,
+			HighSignalOnly:            *filesystemHighSignalOnly,
+			HighSignalPaths:           *filesystemHighSignalPaths,
+			EmitEmptyFiles:            *filesystemEmptyFiles,
+			FollowFileSymlinks:        *filesystemFileSymlinks,
+			AllowSymlinkEscape:        *filesystemSymlinkEscape,
+			DetectEncoding:            *filesystemDetectEncoding,
+			ResolveGitLFS:             *filesystemResolveGitLFS,
+			ContentTypeAllow:          *filesystemContentTypes,
+			ScanImageTarballs:         *filesystemImageTarballs,
+			ScanFileAttributes:        *filesystemAttributes,
+			SizePercentileCutoff:      *filesystemSizePercentile,
+			ArchiveMaxRatio:           *filesystemArchiveMaxRatio,
+			ArchiveMaxExtractedSize:   int64(*filesystemArchiveMaxOutput),
+			ScanReferencedCredentials: *filesystemReferencedCreds,
+		}
+		if *filesystemScanExcludeGlobs != "" {
+			cfg.ExcludeGlobs = strings.Split(*filesystemScanExcludeGlobs, ",")
+		}
+		if *filesystemArchiveInclude != "" {
+			cfg.ArchiveIncludeGlobs = strings.Split(*filesystemArchiveInclude, ",")
+		}
+		if *filesystemArchiveExclude != "" {
+			cfg.ArchiveExcludeGlobs = strings.Split(*filesystemArchiveExclude, ",")
+		}
+		if *filesystemChunksNDJSON {
+			if err := engine.ExportFileSystemChunks(ctx, cfg, os.Stdout); err != nil {
+				logFatal(err, "Failed to export filesystem chunks")
+			}
+			return
+		}
+		if *filesystemUnitsNDJSON {
+			if err := engine.ExportFileSystemUnits(ctx, cfg, os.Stdout); err != nil {
+				logFatal(err, "Failed to export filesystem units")
+			}
+			return
 		}
-		if err = e.ScanFileSystem(ctx, cfg); err != nil {
+		if *filesystemUnitsFile != "" {
+			unitsFile, err := os.Open(*filesystemUnitsFile)
+			if err != nil {
+				logFatal(err, "Failed to open filesystem units file")
+			}
+			err = e.ScanFileSystemUnits(ctx, cfg, unitsFile)
+			unitsFile.Close()
+			if err != nil {
+				logFatal(err, "Failed to scan filesystem units")
+			}
+		} else if err = e.ScanFileSystem(ctx, cfg); err != nil {
 			logFatal(err, "Failed to scan filesystem")
 		}
 	case s3Scan.FullCommand():
@@ -511,6 +632,10 @@ func run(state overseer.State) {
 		"bytes", e.BytesScanned(),
 	)
 
+	if detectors.VerificationUnavailable() {
+		logger.Info("WARNING: verification was unavailable at the end of the scan, unverified results may be valid secrets")
+	}
+
 	if *printAvgDetectorTime {
 		printAverageDetectorTime(e)
 	}
@@ -12,6 +12,29 @@ import (
 type Filter struct {
 	include *FilterRuleSet
 	exclude *FilterRuleSet
+	// composed are the filters of a filter created by ComposeFilters, which
+	// are combined according to mode instead of applying include and exclude.
+	composed []*Filter
+	mode     FilterMode
+}
+
+// FilterMode determines how ComposeFilters combines filters.
+type FilterMode int
+
+const (
+	// FilterAll passes an object if every filter passes it.
+	FilterAll FilterMode = iota
+	// FilterAny passes an object if at least one filter passes it.
+	FilterAny
+)
+
+// ComposeFilters returns a Filter that combines filters according to mode,
+// such as an organization-wide filter and a project-specific one. Nil filters
+// pass every object, like a nil *Filter does, so they make a FilterAny
+// composition pass everything. A composition of no filters passes every
+// object too.
+func ComposeFilters(mode FilterMode, filters ...*Filter) *Filter {
+	return &Filter{composed: append([]*Filter{}, filters...), mode: mode}
 }
 
 type FilterRuleSet []regexp.Regexp
@@ -50,6 +73,35 @@ func FilterFromFiles(includeFilterPath, excludeFilterPath string) (*Filter, erro
 	return filter, nil
 }
 
+// FilterFromRules creates a Filter from lists of include and exclude regular
+// expressions, like the lines of the files of FilterFromFiles. Every object
+// passes the include rules if there are none.
+func FilterFromRules(includeRules, excludeRules []string) (*Filter, error) {
+	compile := func(rules []string) (*FilterRuleSet, error) {
+		ruleSet := FilterRuleSet{}
+		for _, rule := range rules {
+			pattern, err := regexp.Compile(rule)
+			if err != nil {
+				return nil, fmt.Errorf("can not compile regular expression: %s", rule)
+			}
+			ruleSet = append(ruleSet, *pattern)
+		}
+		return &ruleSet, nil
+	}
+	include, err := compile(includeRules)
+	if err != nil {
+		return nil, fmt.Errorf("could not create include rules: %s", err)
+	}
+	exclude, err := compile(excludeRules)
+	if err != nil {
+		return nil, fmt.Errorf("could not create exclude rules: %s", err)
+	}
+	if len(includeRules) == 0 {
+		include = &FilterRuleSet{*regexp.MustCompile("")}
+	}
+	return &Filter{include: include, exclude: exclude}, nil
+}
+
 // FilterRulesFromFile loads the list of regular expression filter rules in `source` and creates a FilterRuleSet.
 func FilterRulesFromFile(source string) (*FilterRuleSet, error) {
 	rules := FilterRuleSet{}
@@ -97,11 +149,29 @@ func (filter *Filter) Pass(object string) bool {
 	if filter == nil {
 		return true
 	}
+	if filter.composed != nil {
+		return filter.passComposed(object)
+	}
 	excluded := filter.exclude.Matches(object)
 	included := filter.include.Matches(object)
 	return !excluded && included
 }
 
+// passComposed returns whether the filters of a composed filter pass object.
+func (filter *Filter) passComposed(object string) bool {
+	if len(filter.composed) == 0 {
+		return true
+	}
+	// The first filter that decides the outcome, by failing for FilterAll
+	// or passing for FilterAny, stops the evaluation.
+	for _, f := range filter.composed {
+		if pass := f.Pass(object); pass == (filter.mode == FilterAny) {
+			return pass
+		}
+	}
+	return filter.mode == FilterAll
+}
+
 // Matches will return true if any of the regular expressions in the FilterRuleSet match the pattern.
 func (rules *FilterRuleSet) Matches(object string) bool {
 	if rules == nil {
@@ -176,3 +176,87 @@ func testFilterWriteFile(filename string, content []byte) error {
 	}
 	return f.Close()
 }
+
+func TestComposeFilters(t *testing.T) {
+	// org only scans source directories, and project excludes its fixtures.
+	org := &Filter{
+		include: &FilterRuleSet{*regexp.MustCompile(`^(src|lib)/`)},
+	}
+	project := &Filter{
+		include: &FilterRuleSet{*regexp.MustCompile("")},
+		exclude: &FilterRuleSet{*regexp.MustCompile(`/fixtures/`)},
+	}
+
+	tests := map[string]struct {
+		filter *Filter
+		passes map[string]bool
+	}{
+		"All": {
+			filter: ComposeFilters(FilterAll, org, project),
+			passes: map[string]bool{
+				"src/main.go":          true,
+				"src/fixtures/key.pem": false,
+				"docs/readme.md":       false,
+				"docs/fixtures/a.txt":  false,
+			},
+		},
+		"Any": {
+			filter: ComposeFilters(FilterAny, org, project),
+			passes: map[string]bool{
+				"src/main.go":          true,
+				"src/fixtures/key.pem": true,
+				"docs/readme.md":       true,
+				"docs/fixtures/a.txt":  false,
+			},
+		},
+		"Nested": {
+			filter: ComposeFilters(FilterAll, project, ComposeFilters(FilterAny, org, nil)),
+			passes: map[string]bool{
+				"docs/readme.md":      true,
+				"docs/fixtures/a.txt": false,
+			},
+		},
+		"Empty": {
+			filter: ComposeFilters(FilterAny),
+			passes: map[string]bool{"docs/readme.md": true},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for object, want := range tt.passes {
+				if got := tt.filter.Pass(object); got != want {
+					t.Errorf("Pass(%q) = %v, want %v", object, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterFromRules(t *testing.T) {
+	filter, err := FilterFromRules([]string{`^src/`}, []string{`_test\.go$`})
+	if err != nil {
+		t.Fatalf("FilterFromRules() error = %v", err)
+	}
+	passes := map[string]bool{
+		"src/main.go":      true,
+		"src/main_test.go": false,
+		"docs/readme.md":   false,
+	}
+	for object, want := range passes {
+		if got := filter.Pass(object); got != want {
+			t.Errorf("Pass(%q) = %v, want %v", object, got, want)
+		}
+	}
+
+	filter, err = FilterFromRules(nil, nil)
+	if err != nil {
+		t.Fatalf("FilterFromRules() error = %v", err)
+	}
+	if !filter.Pass("docs/readme.md") {
+		t.Errorf("empty rules should pass every object")
+	}
+
+	if _, err := FilterFromRules([]string{"("}, nil); err == nil {
+		t.Errorf("FilterFromRules() with an invalid rule should fail")
+	}
+}
@@ -0,0 +1,134 @@
+package common
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// GlobFilter matches slash separated paths, relative to the root of a scan,
+// against a list of glob patterns. The same patterns are used by every
+// source that supports excluding paths by glob, so they behave identically
+// whether a source applies them itself or hands them to git.
+//
+// Pattern syntax:
+//   - `*` matches any sequence of characters except `/`, `?` matches a
+//     single character except `/`, and `[...]` matches a character class.
+//   - `**` matches zero or more directories.
+//   - A pattern with a leading `/`, or with a `/` anywhere except at the end,
+//     is anchored to the root. Otherwise it matches at any depth.
+//   - A pattern that matches a directory also matches everything within it.
+type GlobFilter struct {
+	patterns []string
+	regexes  []*regexp.Regexp
+}
+
+// NewGlobFilter compiles the provided patterns into a GlobFilter.
+func NewGlobFilter(patterns []string) (*GlobFilter, error) {
+	filter := &GlobFilter{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		normalized := NormalizeGlob(pattern)
+		re, err := globToRegexp(normalized)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, normalized)
+		filter.regexes = append(filter.regexes, re)
+	}
+	return filter, nil
+}
+
+// Patterns returns the normalized patterns of the filter. See NormalizeGlob.
+func (g *GlobFilter) Patterns() []string {
+	if g == nil {
+		return nil
+	}
+	return g.patterns
+}
+
+// Match returns true if the slash separated path, or any of its parent
+// directories, matches one of the filter's patterns. A nil GlobFilter matches
+// nothing.
+func (g *GlobFilter) Match(p string) bool {
+	if g == nil || len(g.regexes) == 0 {
+		return false
+	}
+	p = strings.Trim(path.Clean("/"+p), "/")
+	for p != "" && p != "." {
+		for _, re := range g.regexes {
+			if re.MatchString(p) {
+				return true
+			}
+		}
+		p = path.Dir(p)
+	}
+	return false
+}
+
+// NormalizeGlob rewrites a pattern so that it is anchored to the root:
+// unanchored patterns are prefixed with `**/`, and leading and trailing
+// slashes are removed. The result has the same meaning as a git pathspec with
+// the `glob` magic.
+func NormalizeGlob(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if strings.HasPrefix(pattern, "/") {
+		return strings.TrimLeft(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") || strings.HasPrefix(pattern, "**") {
+		return pattern
+	}
+	return "**/" + pattern
+}
+
+// globToRegexp converts a normalized glob into an anchored regular expression.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				switch {
+				case atStart && i+2 < len(glob) && glob[i+2] == '/':
+					// "**/" matches zero or more directories.
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				case atStart && i+2 == len(glob):
+					// A trailing "**" matches everything within.
+					sb.WriteString(".*")
+					i++
+				default:
+					// "**" elsewhere behaves like "*".
+					sb.WriteString("[^/]*")
+					i++
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var globTests = []struct {
+	pattern  string
+	match    []string
+	nonMatch []string
+}{
+	{
+		pattern:  "*.txt",
+		match:    []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"},
+		nonMatch: []string{"a.go", "txt", "dir/a.txt.go"},
+	},
+	{
+		pattern:  "/*.txt",
+		match:    []string{"a.txt"},
+		nonMatch: []string{"dir/b.txt"},
+	},
+	{
+		pattern:  "dir/*.go",
+		match:    []string{"dir/a.go"},
+		nonMatch: []string{"dir/sub/a.go", "other/dir/a.go", "a.go"},
+	},
+	{
+		pattern:  "dir/**/*.go",
+		match:    []string{"dir/a.go", "dir/sub/a.go", "dir/sub/deeper/a.go"},
+		nonMatch: []string{"other/a.go", "a.go"},
+	},
+	{
+		pattern:  "**/testdata",
+		match:    []string{"testdata/x", "pkg/testdata/y/z"},
+		nonMatch: []string{"pkg/testdatax/y"},
+	},
+	{
+		pattern:  "vendor/",
+		match:    []string{"vendor/a.go", "pkg/vendor/b.go"},
+		nonMatch: []string{"vendors/a.go"},
+	},
+	{
+		pattern:  "docs/**",
+		match:    []string{"docs/a.md", "docs/sub/b.md"},
+		nonMatch: []string{"pkg/docs/c.md"},
+	},
+	{
+		pattern:  "file?.[ch]",
+		match:    []string{"file1.c", "src/fileA.h"},
+		nonMatch: []string{"file10.c", "file1.go"},
+	},
+	{
+		pattern:  "[!a]*.env",
+		match:    []string{"b.env", "dir/prod.env"},
+		nonMatch: []string{"a.env"},
+	},
+}
+
+func TestGlobFilter_Match(t *testing.T) {
+	for _, tt := range globTests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			filter, err := NewGlobFilter([]string{tt.pattern})
+			assert.NoError(t, err)
+			for _, p := range tt.match {
+				assert.True(t, filter.Match(p), p)
+			}
+			for _, p := range tt.nonMatch {
+				assert.False(t, filter.Match(p), p)
+			}
+		})
+	}
+}
+
+func TestGlobFilter_Empty(t *testing.T) {
+	var nilFilter *GlobFilter
+	assert.False(t, nilFilter.Match("a.txt"))
+
+	filter, err := NewGlobFilter([]string{"", "  "})
+	assert.NoError(t, err)
+	assert.False(t, filter.Match("a.txt"))
+	assert.Empty(t, filter.Patterns())
+}
+
+func TestNewGlobFilter_Invalid(t *testing.T) {
+	_, err := NewGlobFilter([]string{"[abc"})
+	assert.Error(t, err)
+}
+
+func TestNormalizeGlob(t *testing.T) {
+	tests := map[string]string{
+		"*.txt":       "**/*.txt",
+		"/*.txt":      "*.txt",
+		"dir/*.go":    "dir/*.go",
+		"vendor/":     "**/vendor",
+		"**/testdata": "**/testdata",
+	}
+	for pattern, want := range tests {
+		assert.Equal(t, want, NormalizeGlob(pattern), pattern)
+	}
+}
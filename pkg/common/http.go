@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"net"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
+	"golang.org/x/time/rate"
 )
 
 var caCerts = []string{
@@ -84,6 +86,14 @@ func (t *CustomTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return t.T.RoundTrip(req)
 }
 
+// CloseIdleConnections closes the idle connections of the wrapped transport,
+// if it keeps any.
+func (t *CustomTransport) CloseIdleConnections() {
+	if closer, ok := t.T.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
 func NewCustomTransport(T http.RoundTripper) *CustomTransport {
 	if T == nil {
 		T = http.DefaultTransport
@@ -91,10 +101,61 @@ func NewCustomTransport(T http.RoundTripper) *CustomTransport {
 	return &CustomTransport{T}
 }
 
+// newVerificationTransport returns a CustomTransport of T, or of the default
+// transport if T is nil, that waits for the verification rate limit. See
+// SetVerificationRateLimit.
+func newVerificationTransport(T http.RoundTripper) http.RoundTripper {
+	return &rateLimitedTransport{NewCustomTransport(T)}
+}
+
+// verificationLimiter caps the rate of the requests detectors make to verify
+// secrets, across all of them. It is unlimited by default.
+var verificationLimiter = rate.NewLimiter(rate.Inf, 1)
+
+// SetVerificationRateLimit sets the maximum number of requests per second
+// made by the clients detectors verify secrets with: SaneHttpClient,
+// SaneHttpClientTimeOut, RetryableHttpClient and PinnedRetryableHttpClient.
+// A value <= 0 removes the limit.
+func SetVerificationRateLimit(requestsPerSecond float64) {
+	if requestsPerSecond <= 0 {
+		verificationLimiter.SetLimit(rate.Inf)
+		return
+	}
+	verificationLimiter.SetLimit(rate.Limit(requestsPerSecond))
+	verificationLimiter.SetBurst(1)
+}
+
+// WaitForVerification blocks until the verification rate limit allows
+// another request. An error is only returned if ctx is done first.
+func WaitForVerification(ctx context.Context) error {
+	return verificationLimiter.Wait(ctx)
+}
+
+// rateLimitedTransport waits for the verification rate limit before each
+// request, including each retry of a retryable client.
+type rateLimitedTransport struct {
+	T http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := WaitForVerification(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.T.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport,
+// if it keeps any.
+func (t *rateLimitedTransport) CloseIdleConnections() {
+	if closer, ok := t.T.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
 func PinnedRetryableHttpClient() *http.Client {
 	httpClient := retryablehttp.NewClient()
 	httpClient.Logger = nil
-	httpClient.HTTPClient.Transport = NewCustomTransport(&http.Transport{
+	httpClient.HTTPClient.Transport = newVerificationTransport(&http.Transport{
 		TLSClientConfig: &tls.Config{
 			RootCAs: PinnedCertPool(),
 		},
@@ -117,10 +178,12 @@ func RetryableHttpClient() *http.Client {
 	httpClient.RetryMax = 3
 	httpClient.Logger = nil
 	httpClient.HTTPClient.Timeout = 3 * time.Second
-	httpClient.HTTPClient.Transport = NewCustomTransport(nil)
+	httpClient.HTTPClient.Transport = newVerificationTransport(nil)
 	return httpClient.StandardClient()
 }
 
+// RetryableHttpClientTimeout isn't subject to the verification rate limit,
+// since sources use it to fetch the data they scan.
 func RetryableHttpClientTimeout(timeOutSeconds int64) *http.Client {
 	httpClient := retryablehttp.NewClient()
 	httpClient.RetryMax = 3
@@ -147,7 +210,7 @@ var saneTransport = &http.Transport{
 func SaneHttpClient() *http.Client {
 	httpClient := &http.Client{}
 	httpClient.Timeout = DefaultResponseTimeout
-	httpClient.Transport = NewCustomTransport(saneTransport)
+	httpClient.Transport = newVerificationTransport(saneTransport)
 	return httpClient
 }
 
@@ -155,6 +218,6 @@ func SaneHttpClient() *http.Client {
 func SaneHttpClientTimeOut(timeOutSeconds int64) *http.Client {
 	httpClient := &http.Client{}
 	httpClient.Timeout = time.Second * time.Duration(timeOutSeconds)
-	httpClient.Transport = NewCustomTransport(nil)
+	httpClient.Transport = newVerificationTransport(nil)
 	return httpClient
 }
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaneHttpClient_VerificationRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	SetVerificationRateLimit(20)
+	defer SetVerificationRateLimit(0)
+
+	client := SaneHttpClient()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		res, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			res.Body.Close()
+		}
+	}
+	// The first request is allowed immediately, the others 50ms apart.
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+}
+
+func TestSaneHttpClient_VerificationRateLimitCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	SetVerificationRateLimit(0.1)
+	defer SetVerificationRateLimit(0)
+
+	client := SaneHttpClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		assert.NoError(t, err)
+		res, err := client.Do(req)
+		if i == 0 {
+			if assert.NoError(t, err) {
+				res.Body.Close()
+			}
+			continue
+		}
+		assert.Error(t, err)
+	}
+}
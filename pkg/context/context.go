@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -40,7 +41,27 @@ type logCtx struct {
 	// Embed context.Context to get all methods for free.
 	context.Context
 	log logr.Logger
-	err *error
+	err *cancelErr
+}
+
+// cancelErr holds the error recorded by a cancel function. It is shared by
+// every copy of a logCtx and guarded so that Err can be called concurrently
+// with cancellation.
+type cancelErr struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (c *cancelErr) get() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *cancelErr) set(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
 }
 
 // Logger returns a structured logger.
@@ -49,8 +70,10 @@ func (l logCtx) Logger() logr.Logger {
 }
 
 func (l logCtx) Err() error {
-	if l.err != nil && *l.err != nil {
-		return *l.err
+	if l.err != nil {
+		if err := l.err.get(); err != nil {
+			return err
+		}
 	}
 	return l.Context.Err()
 }
@@ -154,8 +177,7 @@ func SetDefaultLogger(l logr.Logger) {
 // the cancel function was first called.
 func captureCancelCallstack(ctx logCtx, f context.CancelFunc) (Context, context.CancelFunc) {
 	if ctx.err == nil {
-		var err error
-		ctx.err = &err
+		ctx.err = &cancelErr{}
 	}
 	return ctx, func() {
 		// We must check Err() before calling f() since f() sets the error.
@@ -165,10 +187,10 @@ func captureCancelCallstack(ctx logCtx, f context.CancelFunc) (Context, context.
 			return
 		}
 		f()
-		// Set the error with the stacktrace if the err pointer is non-nil.
-		*ctx.err = fmt.Errorf(
+		// Record the error with the stacktrace.
+		ctx.err.set(fmt.Errorf(
 			"%w (canceled at %v\n%s)",
 			ctx.Err(), time.Now(), string(debug.Stack()),
-		)
+		))
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
@@ -49,6 +50,9 @@ func NewWebhookCustomRegex(pb *custom_detectorspb.CustomRegex) (*customRegexWebh
 		if err := ValidateVerifyHeaders(verify.Headers); err != nil {
 			return nil, err
 		}
+		if err := ValidateVerifyRanges(verify.SuccessRanges); err != nil {
+			return nil, err
+		}
 	}
 
 	// TODO: Copy only necessary data out of pb.
@@ -166,7 +170,7 @@ func (c *customRegexWebhook) createResults(ctx context.Context, match map[string
 		}
 		// TODO: Read response body.
 		res.Body.Close()
-		if res.StatusCode == http.StatusOK {
+		if isSuccessStatus(res.StatusCode, verifyConfig.GetSuccessRanges()) {
 			result.Verified = true
 			break
 		}
@@ -180,6 +184,27 @@ func (c *customRegexWebhook) createResults(ctx context.Context, match map[string
 	}
 }
 
+// isSuccessStatus reports whether status is within one of ranges, such as
+// "200-250" or "288", or is 200 OK if there are none. The ranges must have
+// been validated with ValidateVerifyRanges.
+func isSuccessStatus(status int, ranges []string) bool {
+	if len(ranges) == 0 {
+		return status == http.StatusOK
+	}
+	for _, successRange := range ranges {
+		lower, upper, found := strings.Cut(successRange, "-")
+		if !found {
+			upper = lower
+		}
+		lowerBound, _ := strconv.Atoi(lower)
+		upperBound, _ := strconv.Atoi(upper)
+		if lowerBound <= status && status <= upperBound {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *customRegexWebhook) Keywords() []string {
 	return c.GetKeywords()
 }
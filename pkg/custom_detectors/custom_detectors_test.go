@@ -2,6 +2,9 @@ package custom_detectors
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -208,6 +211,75 @@ func TestDetector(t *testing.T) {
 	assert.Equal(t, results[0].Raw, []byte(`password="123456"`))
 }
 
+func TestDetector_Verification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]map[string][]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch body["internal token"]["token"][1] {
+		case "itk_0123456789abcdef":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	spec := func(successRanges string) string {
+		return `name: internal token
+keywords:
+- itk_
+regex:
+  token: \b(itk_[0-9a-f]{16})\b
+verify:
+- endpoint: ` + server.URL + `
+  unsafe: true` + successRanges
+	}
+	load := func(yaml string) *customRegexWebhook {
+		t.Helper()
+		var pb custom_detectorspb.CustomRegex
+		assert.NoError(t, protoyaml.UnmarshalStrict([]byte(yaml), &pb))
+		detector, err := NewWebhookCustomRegex(&pb)
+		assert.NoError(t, err)
+		return detector
+	}
+
+	tests := []struct {
+		name          string
+		successRanges string
+		data          string
+		wantVerified  bool
+	}{
+		{name: "status in success range", successRanges: "\n  successRanges:\n  - 200-299", data: "key: itk_0123456789abcdef", wantVerified: true},
+		{name: "status out of success range", successRanges: "\n  successRanges:\n  - 200-299", data: "key: itk_fedcba9876543210"},
+		{name: "only 200 without success ranges", data: "key: itk_0123456789abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := load(spec(tt.successRanges))
+			results, err := detector.FromData(context.Background(), true, []byte(tt.data))
+			assert.NoError(t, err)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tt.wantVerified, results[0].Verified)
+				assert.Equal(t, "internal token", results[0].DetectorName)
+			}
+		})
+	}
+
+	// Specs are validated when they're loaded.
+	for _, yaml := range []string{
+		"name: bad regex\nkeywords:\n- itk_\nregex:\n  token: itk_(\n",
+		spec("\n  successRanges:\n  - 299-200"),
+	} {
+		var pb custom_detectorspb.CustomRegex
+		assert.NoError(t, protoyaml.UnmarshalStrict([]byte(yaml), &pb))
+		_, err := NewWebhookCustomRegex(&pb)
+		assert.Error(t, err, yaml)
+	}
+}
+
 func BenchmarkProductIndices(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = productIndices(3, 2, 6)
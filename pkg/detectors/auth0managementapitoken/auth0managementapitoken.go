@@ -60,6 +60,9 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 				Raw:          []byte(managementApiTokenRes),
 				RawV2:        []byte(managementApiTokenRes + domainRes),
 			}
+			if expiresAt, ok := detectors.JWTExpiry(managementApiTokenRes); ok {
+				s1.SetExpiry(expiresAt)
+			}
 
 			if verify {
 				/*
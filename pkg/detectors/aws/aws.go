@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 )
 
 type scanner struct {
+	detectors.EndpointSetter
 	skipIDs map[string]struct{}
 }
 
@@ -44,8 +46,11 @@ func WithSkipIDs(skipIDs []string) func(*scanner) {
 	}
 }
 
-// Ensure the scanner satisfies the interface at compile time.
+// Ensure the scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*scanner)(nil)
+var _ detectors.EndpointCustomizer = (*scanner)(nil)
+
+func (scanner) DefaultEndpoint() string { return "https://sts.amazonaws.com" }
 
 var (
 	client = common.SaneHttpClient()
@@ -60,6 +65,10 @@ var (
 	falsePositiveSecretCheck = regexp.MustCompile(`[a-f0-9]{40}`)
 )
 
+// pairWindow is the maximum distance in bytes between the start of a key ID
+// and the start of a secret for the two to be tried as a pair.
+const pairWindow = 1024
+
 // Keywords are used for efficiently pre-filtering chunks.
 // Use identifiers in the secret preferably, or the provider name.
 func (s scanner) Keywords() []string {
@@ -71,6 +80,12 @@ func (s scanner) Keywords() []string {
 	}
 }
 
+// CaseSensitiveKeywords are the keywords that only match in uppercase, since
+// key IDs are never written any other way.
+func (s scanner) CaseSensitiveKeywords() []string {
+	return s.Keywords()
+}
+
 func GetHash(input string) string {
 	data := []byte(input)
 	hasher := sha256.New()
@@ -88,14 +103,14 @@ func GetHMAC(key []byte, data []byte) []byte {
 func (s scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
 	dataStr := string(data)
 
-	idMatches := idPat.FindAllStringSubmatch(dataStr, -1)
-	secretMatches := secretPat.FindAllStringSubmatch(dataStr, -1)
+	idMatches := idPat.FindAllStringSubmatchIndex(dataStr, -1)
+	secretMatches := secretPat.FindAllStringSubmatchIndex(dataStr, -1)
 
 	for _, idMatch := range idMatches {
-		if len(idMatch) != 2 {
+		if len(idMatch) != 4 {
 			continue
 		}
-		resIDMatch := strings.TrimSpace(idMatch[1])
+		resIDMatch := strings.TrimSpace(dataStr[idMatch[2]:idMatch[3]])
 
 		if s.skipIDs != nil {
 			if _, ok := s.skipIDs[resIDMatch]; ok {
@@ -103,11 +118,22 @@ func (s scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 			}
 		}
 
-		for _, secretMatch := range secretMatches {
-			if len(secretMatch) != 2 {
+		nearbySecrets := colocated(secretMatches, idMatch[2])
+		if len(nearbySecrets) == 0 {
+			// A key ID on its own can't be verified, but it is still worth reporting.
+			results = append(results, detectors.Result{
+				DetectorType: detectorspb.DetectorType_AWS,
+				Raw:          []byte(resIDMatch),
+				Redacted:     resIDMatch,
+			})
+			continue
+		}
+
+		for _, secretMatch := range nearbySecrets {
+			if len(secretMatch) != 4 {
 				continue
 			}
-			resSecretMatch := strings.TrimSpace(secretMatch[1])
+			resSecretMatch := strings.TrimSpace(dataStr[secretMatch[2]:secretMatch[3]])
 
 			s1 := detectors.Result{
 				DetectorType: detectorspb.DetectorType_AWS,
@@ -117,87 +143,28 @@ func (s scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 			}
 
 			if verify {
-				// REQUEST VALUES.
-				method := "GET"
-				service := "sts"
-				host := "sts.amazonaws.com"
-				region := "us-east-1"
-				endpoint := "https://sts.amazonaws.com"
-				datestamp := time.Now().UTC().Format("20060102")
-				amzDate := time.Now().UTC().Format("20060102T150405Z0700")
-
-				req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
-				if err != nil {
-					continue
-				}
-				req.Header.Set("Accept", "application/json")
-
-				// TASK 1: CREATE A CANONICAL REQUEST.
-				// http://docs.aws.amazon.com/general/latest/gr/sigv4-create-canonical-request.html
-				canonicalURI := "/"
-				canonicalHeaders := "host:" + host + "\n"
-				signedHeaders := "host"
-				algorithm := "AWS4-HMAC-SHA256"
-				credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", datestamp, region, service)
-
-				params := req.URL.Query()
-				params.Add("Action", "GetCallerIdentity")
-				params.Add("Version", "2011-06-15")
-				params.Add("X-Amz-Algorithm", algorithm)
-				params.Add("X-Amz-Credential", resIDMatch+"/"+credentialScope)
-				params.Add("X-Amz-Date", amzDate)
-				params.Add("X-Amz-Expires", "30")
-				params.Add("X-Amz-SignedHeaders", signedHeaders)
-
-				canonicalQuerystring := params.Encode()
-				payloadHash := GetHash("") // empty payload
-				canonicalRequest := method + "\n" + canonicalURI + "\n" + canonicalQuerystring + "\n" + canonicalHeaders + "\n" + signedHeaders + "\n" + payloadHash
-
-				// TASK 2: CREATE THE STRING TO SIGN.
-				stringToSign := algorithm + "\n" + amzDate + "\n" + credentialScope + "\n" + GetHash(canonicalRequest)
-
-				// TASK 3: CALCULATE THE SIGNATURE.
-				// https://docs.aws.amazon.com/general/latest/gr/sigv4-calculate-signature.html
-				hash := GetHMAC([]byte(fmt.Sprintf("AWS4%s", resSecretMatch)), []byte(datestamp))
-				hash = GetHMAC(hash, []byte(region))
-				hash = GetHMAC(hash, []byte(service))
-				hash = GetHMAC(hash, []byte("aws4_request"))
-
-				signature2 := GetHMAC(hash, []byte(stringToSign)) // Get Signature HMAC SHA256
-				signature := hex.EncodeToString(signature2)
-
-				// TASK 4: ADD SIGNING INFORMATION TO THE REQUEST.
-				params.Add("X-Amz-Signature", signature)
-				req.Header.Add("Content-type", "application/x-www-form-urlencoded; charset=utf-8")
-				req.URL.RawQuery = params.Encode()
-
-				res, err := client.Do(req)
-				if err == nil {
-
-					if res.StatusCode >= 200 && res.StatusCode < 300 {
-						identityInfo := identityRes{}
-						err := json.NewDecoder(res.Body).Decode(&identityInfo)
-						if err == nil {
-							s1.Verified = true
-							s1.ExtraData = map[string]string{
-								"account": identityInfo.GetCallerIdentityResponse.GetCallerIdentityResult.Account,
-								"user_id": identityInfo.GetCallerIdentityResponse.GetCallerIdentityResult.UserID,
-								"arn":     identityInfo.GetCallerIdentityResponse.GetCallerIdentityResult.Arn,
-							}
-						}
-						res.Body.Close()
-					} else {
-						// This function will check false positives for common test words, but also it will make sure the key appears "random" enough to be a real key.
-						if detectors.IsKnownFalsePositive(resSecretMatch, detectors.DefaultFalsePositives, true) {
-							continue
-						}
-
-						if res.StatusCode != 403 {
-							s1.VerificationError = fmt.Errorf("request to %v returned unexpected status %d", res.Request.URL, res.StatusCode)
-						}
+				isFalsePositive := false
+				for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+					verified, extraData, statusErr, err := s.verifyMatch(ctx, endpoint, resIDMatch, resSecretMatch)
+					if err != nil {
+						s1.VerificationError = err
+						continue
 					}
-				} else {
-					s1.VerificationError = err
+					if verified {
+						s1.Verified = true
+						s1.ExtraData = extraData
+						s1.VerificationError = nil
+						break
+					}
+					// This function will check false positives for common test words, but also it will make sure the key appears "random" enough to be a real key.
+					if detectors.IsKnownFalsePositive(resSecretMatch, detectors.DefaultFalsePositives, true) {
+						isFalsePositive = true
+						break
+					}
+					s1.VerificationError = statusErr
+				}
+				if isFalsePositive {
+					continue
 				}
 			}
 
@@ -216,6 +183,113 @@ func (s scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 	return awsCustomCleanResults(results), nil
 }
 
+// colocated returns the secret matches that start within pairWindow bytes of
+// offset. The matches must be in the order returned by the regexp package, so
+// the window can be found with a binary search instead of pairing every ID
+// with every secret in the chunk.
+func colocated(secretMatches [][]int, offset int) [][]int {
+	lo := sort.Search(len(secretMatches), func(i int) bool {
+		return secretMatches[i][2] >= offset-pairWindow
+	})
+	hi := sort.Search(len(secretMatches), func(i int) bool {
+		return secretMatches[i][2] > offset+pairWindow
+	})
+	return secretMatches[lo:hi]
+}
+
+// verifyMatch calls STS GetCallerIdentity with a SigV4-signed request. A
+// non-nil err means the request could not be completed. A response other than
+// success or 403 is reported as statusErr, so the caller can still apply its
+// false positive checks before surfacing it.
+func (s scanner) verifyMatch(ctx context.Context, endpoint, id, secret string) (verified bool, extraData map[string]string, statusErr error, err error) {
+	req, err := signedIdentityRequest(ctx, endpoint, id, secret, time.Now().UTC())
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		identityInfo := identityRes{}
+		if err := json.NewDecoder(res.Body).Decode(&identityInfo); err != nil {
+			return false, nil, nil, nil
+		}
+		result := identityInfo.GetCallerIdentityResponse.GetCallerIdentityResult
+		return true, map[string]string{
+			"account": result.Account,
+			"user_id": result.UserID,
+			"arn":     result.Arn,
+		}, nil, nil
+	}
+
+	if res.StatusCode != 403 {
+		statusErr = fmt.Errorf("request to %v returned unexpected status %d", res.Request.URL, res.StatusCode)
+	}
+	return false, nil, statusErr, nil
+}
+
+// signedIdentityRequest builds a GetCallerIdentity request against endpoint,
+// signed with the given key pair as of now.
+func signedIdentityRequest(ctx context.Context, endpoint, id, secret string, now time.Time) (*http.Request, error) {
+	// REQUEST VALUES.
+	method := "GET"
+	service := "sts"
+	region := "us-east-1"
+	datestamp := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z0700")
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// TASK 1: CREATE A CANONICAL REQUEST.
+	// http://docs.aws.amazon.com/general/latest/gr/sigv4-create-canonical-request.html
+	canonicalURI := "/"
+	canonicalHeaders := "host:" + req.URL.Host + "\n"
+	signedHeaders := "host"
+	algorithm := "AWS4-HMAC-SHA256"
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", datestamp, region, service)
+
+	params := req.URL.Query()
+	params.Add("Action", "GetCallerIdentity")
+	params.Add("Version", "2011-06-15")
+	params.Add("X-Amz-Algorithm", algorithm)
+	params.Add("X-Amz-Credential", id+"/"+credentialScope)
+	params.Add("X-Amz-Date", amzDate)
+	params.Add("X-Amz-Expires", "30")
+	params.Add("X-Amz-SignedHeaders", signedHeaders)
+
+	canonicalQuerystring := params.Encode()
+	payloadHash := GetHash("") // empty payload
+	canonicalRequest := method + "\n" + canonicalURI + "\n" + canonicalQuerystring + "\n" + canonicalHeaders + "\n" + signedHeaders + "\n" + payloadHash
+
+	// TASK 2: CREATE THE STRING TO SIGN.
+	stringToSign := algorithm + "\n" + amzDate + "\n" + credentialScope + "\n" + GetHash(canonicalRequest)
+
+	// TASK 3: CALCULATE THE SIGNATURE.
+	// https://docs.aws.amazon.com/general/latest/gr/sigv4-calculate-signature.html
+	hash := GetHMAC([]byte(fmt.Sprintf("AWS4%s", secret)), []byte(datestamp))
+	hash = GetHMAC(hash, []byte(region))
+	hash = GetHMAC(hash, []byte(service))
+	hash = GetHMAC(hash, []byte("aws4_request"))
+
+	signature2 := GetHMAC(hash, []byte(stringToSign)) // Get Signature HMAC SHA256
+	signature := hex.EncodeToString(signature2)
+
+	// TASK 4: ADD SIGNING INFORMATION TO THE REQUEST.
+	params.Add("X-Amz-Signature", signature)
+	req.Header.Add("Content-type", "application/x-www-form-urlencoded; charset=utf-8")
+	req.URL.RawQuery = params.Encode()
+
+	return req, nil
+}
+
 func awsCustomCleanResults(results []detectors.Result) []detectors.Result {
 	if len(results) == 0 {
 		return results
@@ -12,10 +12,17 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 )
 
-type Scanner struct{}
+type Scanner struct {
+	detectors.EndpointSetter
+}
 
-// Ensure the Scanner satisfies the interface at compile time.
+// Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+
+// DefaultEndpoint is the storage service used to verify storage account keys.
+// {account} is replaced by the name of the key's account.
+func (Scanner) DefaultEndpoint() string { return defaultStorageEndpoint }
 
 func mustFmtPat(id, pat string) *regexp.Regexp {
 	combinedID := strings.ReplaceAll(id, "_", "") + "|" + id
@@ -23,7 +30,7 @@ func mustFmtPat(id, pat string) *regexp.Regexp {
 }
 
 var (
-	// TODO: Azure storage access keys and investigate other types of creds.
+	// TODO: investigate other types of creds.
 
 	// Azure App Oauth
 	idPatFmt    = `(?i)(%s).{0,20}([a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})`
@@ -38,7 +45,7 @@ var (
 // Keywords are used for efficiently pre-filtering chunks.
 // Use identifiers in the secret preferably, or the provider name.
 func (s Scanner) Keywords() []string {
-	return []string{"azure"}
+	return []string{"azure", "accountkey", "sig="}
 }
 
 // FromData will find and optionally verify Azure secrets in a given set of bytes.
@@ -84,6 +91,13 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 		}
 	}
 
+	storageResults, err := s.storageKeyResults(ctx, verify, dataStr)
+	results = append(results, storageResults...)
+	if err != nil {
+		return results, err
+	}
+	results = append(results, sasResults(dataStr)...)
+
 	return results, nil
 }
 
@@ -0,0 +1,153 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+const (
+	testAccount = "myaccount"
+	testKey     = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8gISIjJCUmJygpKissLS4vMDEyMzQ1Njc4OTo7PD0+Pw=="
+	testSig     = "pUOZfYTxJ5g1DAm97yzbFxv0HtPkpfgIry%2FrDFYmMAk%3D"
+
+	testConnectionString = "DefaultEndpointsProtocol=https;AccountName=" + testAccount + ";AccountKey=" + testKey + ";EndpointSuffix=core.windows.net"
+	testSASURL           = "https://" + testAccount + ".blob.core.windows.net/backups/db.bak?sp=rl&st=2024-01-01T00:00:00Z&se=2025-01-01T00:00:00Z&spr=https&sv=2022-11-02&sr=b&sig=" + testSig
+	testAccountSAS       = "?sv=2022-11-02&ss=b&srt=sco&sp=rwdlac&se=2027-06-30T12:00Z&spr=https&sig=" + testSig
+)
+
+func TestAzure_StorageAccountKey(t *testing.T) {
+	data := fmt.Sprintf(`storage:
+  connection_string: "%s"
+`, testConnectionString)
+	results, err := Scanner{}.FromData(context.Background(), false, []byte(data))
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, testKey, string(results[0].Raw))
+		assert.Equal(t, testAccount, results[0].Redacted)
+		assert.Equal(t, map[string]string{"credential_type": "storage_account_key", "account_name": testAccount}, results[0].ExtraData)
+	}
+
+	// A key outside of a connection string is paired with the account names
+	// found nearby.
+	data = "AZURE_STORAGE_ACCOUNT: AccountName=otheraccount\nAccountKey=" + testKey + "\n"
+	results, err = Scanner{}.FromData(context.Background(), false, []byte(data))
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "otheraccount", results[0].Redacted)
+	}
+}
+
+func TestAzure_StorageAccountKeyVerification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("restype") != "account" || r.URL.Query().Get("comp") != "properties" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		want, err := signSharedKey(r, testAccount, testKey)
+		assert.NoError(t, err)
+		if r.Header.Get("Authorization") != "SharedKey "+testAccount+":"+want {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		data         string
+		wantVerified bool
+	}{
+		{name: "valid key", data: testConnectionString, wantVerified: true},
+		{name: "wrong account", data: strings.Replace(testConnectionString, testAccount, "otheraccount", 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scanner{}
+			assert.NoError(t, s.SetEndpoints(server.URL))
+			results, err := s.FromData(context.Background(), true, []byte(tt.data))
+			assert.NoError(t, err)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tt.wantVerified, results[0].Verified)
+				assert.NoError(t, results[0].VerificationError)
+				assert.False(t, results[0].VerifiedAt.IsZero())
+			}
+		})
+	}
+}
+
+func TestSignSharedKey(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://myaccount.blob.core.windows.net/?restype=account&comp=properties", nil)
+	assert.NoError(t, err)
+	req.Header.Set("x-ms-date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	req.Header.Set("x-ms-version", storageAPIVersion)
+
+	signature, err := signSharedKey(req, testAccount, testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "GxEYNJAkoqDXPhc5elb0elEYpWYAdgwITjaND1CtSno=", signature)
+}
+
+func TestAzure_SASToken(t *testing.T) {
+	detectors.SetClock(func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) })
+	defer detectors.SetClock(nil)
+
+	tests := []struct {
+		name          string
+		data          string
+		wantExtraData map[string]string
+	}{
+		{
+			name: "service SAS URL",
+			data: fmt.Sprintf("backup_url = %q\n", testSASURL),
+			wantExtraData: map[string]string{
+				"credential_type": "sas_token",
+				"permissions":     "read,list",
+				"expiry":          "2025-01-01T00:00:00Z",
+				"expired":         "true",
+				"resource":        "https://myaccount.blob.core.windows.net/backups/db.bak",
+			},
+		},
+		{
+			name: "account SAS query string",
+			data: "AZURE_SAS_TOKEN=" + strings.TrimPrefix(testAccountSAS, "?") + "\n",
+			wantExtraData: map[string]string{
+				"credential_type": "sas_token",
+				"permissions":     "read,write,delete,list,add,create",
+				"expiry":          "2027-06-30T12:00Z",
+				"expired":         "false",
+			},
+		},
+		{
+			name: "no expiry",
+			data: "https://example.com/callback?sv=2022-11-02&sp=r&sig=" + testSig,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Scanner{}.FromData(context.Background(), true, []byte(tt.data))
+			assert.NoError(t, err)
+			if tt.wantExtraData == nil {
+				assert.Empty(t, results)
+				return
+			}
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tt.wantExtraData, results[0].ExtraData)
+				assert.Equal(t, "pUOZfYTxJ5g1DAm97yzbFxv0HtPkpfgIry/rDFYmMAk=", string(results[0].Raw))
+				assert.NotContains(t, results[0].Redacted, "sig=")
+				assert.False(t, results[0].Verified)
+				if assert.NotNil(t, results[0].Expired) {
+					assert.Equal(t, tt.wantExtraData["expired"] == "true", *results[0].Expired)
+				}
+			}
+		})
+	}
+}
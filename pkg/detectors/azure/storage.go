@@ -0,0 +1,290 @@
+package azure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+var (
+	client = common.SaneHttpClient()
+
+	// Storage account keys are 64 random bytes, base64 encoded.
+	accountKeyPat  = regexp.MustCompile(`(?i)AccountKey\s*=\s*([A-Za-z0-9+/]{86}==)`)
+	accountNamePat = regexp.MustCompile(`(?i)AccountName\s*=\s*([a-z0-9]{3,24})\b`)
+	// sasPat matches the query string of a shared access signature, with
+	// the URL of the resource it grants access to if there is one.
+	sasPat = regexp.MustCompile(`(?i)(https://[a-z0-9]{3,24}\.(?:blob|queue|table|file|dfs)\.core\.windows\.net[^?\s"'<>]*)?[?&\s"'=]((?:[a-z]{2,5}=[^&\s"'<>]*&)*sig=[A-Za-z0-9%+/=]{43,}(?:&[a-z]{2,5}=[^&\s"'<>]*)*)`)
+)
+
+// defaultStorageEndpoint is the blob service of a storage account in the
+// public cloud. {account} is replaced by the account name.
+const defaultStorageEndpoint = "https://{account}.blob.core.windows.net"
+
+// storageAPIVersion is the version of the storage REST API used for
+// verification. Get Account Information requires 2018-03-28 or later.
+const storageAPIVersion = "2021-08-06"
+
+// sasPermissions names the permissions of a shared access signature's sp
+// parameter.
+var sasPermissions = map[rune]string{
+	'r': "read",
+	'a': "add",
+	'c': "create",
+	'w': "write",
+	'd': "delete",
+	'x': "delete_version",
+	'y': "permanent_delete",
+	'l': "list",
+	't': "tags",
+	'f': "filter",
+	'm': "move",
+	'e': "execute",
+	'o': "ownership",
+	'p': "permissions",
+	'i': "immutability",
+	'u': "update",
+	'q': "process",
+}
+
+// storageKeyResults finds storage account keys, pairing each with the account
+// name of its connection string, or with every account name found if the key
+// isn't in one.
+func (s Scanner) storageKeyResults(ctx context.Context, verify bool, dataStr string) ([]detectors.Result, error) {
+	var results []detectors.Result
+	for _, match := range accountKeyPat.FindAllStringSubmatchIndex(dataStr, -1) {
+		key := dataStr[match[2]:match[3]]
+		conn := connectionString(dataStr, match[0], match[1])
+
+		accounts := []string{conn["accountname"]}
+		if accounts[0] == "" {
+			accounts = accounts[:0]
+			for _, name := range accountNamePat.FindAllStringSubmatch(dataStr, -1) {
+				common.AddStringSliceItem(name[1], &accounts)
+			}
+		}
+		if len(accounts) == 0 {
+			// Without an account name the key can't be verified.
+			results = append(results, detectors.Result{
+				DetectorType: detectorspb.DetectorType_Azure,
+				Raw:          []byte(key),
+				ExtraData:    map[string]string{"credential_type": "storage_account_key"},
+			})
+			continue
+		}
+
+		for _, account := range accounts {
+			s1 := detectors.Result{
+				DetectorType: detectorspb.DetectorType_Azure,
+				Raw:          []byte(key),
+				RawV2:        []byte(account + key),
+				Redacted:     account,
+				ExtraData: map[string]string{
+					"credential_type": "storage_account_key",
+					"account_name":    account,
+				},
+			}
+
+			if verify {
+				defaultEndpoint := s.DefaultEndpoint()
+				if suffix := conn["endpointsuffix"]; suffix != "" {
+					defaultEndpoint = "https://{account}.blob." + suffix
+				}
+				for _, endpoint := range s.Endpoints(defaultEndpoint) {
+					verified, verificationErr := verifyAccountKey(ctx, endpoint, account, key)
+					s1.Verified = verified
+					s1.VerificationError = verificationErr
+					if verified {
+						break
+					}
+				}
+				s1.VerifiedAt = detectors.Now()
+			}
+
+			results = append(results, s1)
+		}
+	}
+	return results, nil
+}
+
+// connectionString returns the lowercased keys and values of the connection
+// string around data[start:end], which is delimited by whitespace or quotes.
+func connectionString(data string, start, end int) map[string]string {
+	const delimiters = " \t\r\n\"'`"
+	if i := strings.LastIndexAny(data[:start], delimiters); i >= 0 {
+		start = i + 1
+	} else {
+		start = 0
+	}
+	if i := strings.IndexAny(data[end:], delimiters); i >= 0 {
+		end += i
+	} else {
+		end = len(data)
+	}
+
+	fields := map[string]string{}
+	for _, part := range strings.Split(data[start:end], ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		fields[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// verifyAccountKey requests the account information of account from the
+// storage service at endpoint, signed with key. A successful response
+// verifies the key, and a forbidden one means it's not valid for the account.
+func verifyAccountKey(ctx context.Context, endpoint, account, key string) (bool, error) {
+	endpoint = strings.TrimRight(strings.ReplaceAll(endpoint, "{account}", account), "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/?restype=account&comp=properties", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", storageAPIVersion)
+	signature, err := signSharedKey(req, account, key)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "SharedKey "+account+":"+signature)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusForbidden:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
+	}
+}
+
+// signSharedKey returns the Shared Key signature of a request without a body,
+// as described in https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key.
+func signSharedKey(req *http.Request, account, key string) (string, error) {
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", fmt.Errorf("invalid account key: %w", err)
+	}
+
+	var headers []string
+	for name := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, name+":"+strings.TrimSpace(req.Header.Get(name)))
+		}
+	}
+	sort.Strings(headers)
+
+	resource := "/" + account + req.URL.EscapedPath()
+	if req.URL.Path == "" {
+		resource += "/"
+	}
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+
+	// The standard headers are all empty for a GET without a body.
+	stringToSign := req.Method + strings.Repeat("\n", 12) + strings.Join(headers, "\n") + "\n" + resource
+	mac := hmac.New(sha256.New, decodedKey)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// sasResults finds shared access signatures and classifies them by the
+// permissions and expiry in their query string. They aren't verified, since
+// that would require knowing a resource they grant access to.
+func sasResults(dataStr string) []detectors.Result {
+	var results []detectors.Result
+	for _, match := range sasPat.FindAllStringSubmatch(dataStr, -1) {
+		query, err := url.ParseQuery(match[2])
+		if err != nil {
+			continue
+		}
+		sig, expiry := query.Get("sig"), query.Get("se")
+		if sig == "" || expiry == "" || query.Get("sv") == "" {
+			continue
+		}
+		if query.Get("sp") == "" && query.Get("ss") == "" {
+			continue
+		}
+
+		extraData := map[string]string{
+			"credential_type": "sas_token",
+			"expiry":          expiry,
+		}
+		if permissions := sasPermissionNames(query.Get("sp")); permissions != "" {
+			extraData["permissions"] = permissions
+		}
+		if match[1] != "" {
+			extraData["resource"] = match[1]
+		}
+
+		query.Del("sig")
+		result := detectors.Result{
+			DetectorType: detectorspb.DetectorType_Azure,
+			Raw:          []byte(sig),
+			RawV2:        []byte(match[1] + "?" + match[2]),
+			Redacted:     query.Encode(),
+			ExtraData:    extraData,
+		}
+		if expiresAt, ok := parseSASTime(expiry); ok {
+			result.SetExpiry(expiresAt)
+			extraData["expired"] = strconv.FormatBool(result.IsExpired())
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// sasPermissionNames returns the comma separated names of the permissions in
+// sp, keeping unknown letters as they are.
+func sasPermissionNames(sp string) string {
+	var names []string
+	for _, p := range sp {
+		if name, ok := sasPermissions[p]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, string(p))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// parseSASTime parses a SAS start or expiry time, which is in one of the ISO
+// 8601 UTC formats accepted by the storage service.
+func parseSASTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,21 @@
+package detectors
+
+import "time"
+
+// clock is the scan clock used to timestamp verification.
+var clock = time.Now
+
+// SetClock replaces the scan clock, for example to produce deterministic
+// results in tests. A nil clock restores time.Now.
+func SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	clock = now
+}
+
+// Now returns the current time of the scan clock. Detectors should use it to
+// set Result.VerifiedAt.
+func Now() time.Time {
+	return clock()
+}
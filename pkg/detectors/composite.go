@@ -0,0 +1,169 @@
+package detectors
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CompositeDetector is an optional interface that a detector of credentials
+// made of several parts, such as a client ID and secret, implements to
+// declare the parts that must all be found before a credential can be
+// verified. The detector assembles candidates from the parts with
+// AssembleComposites, and, if it's also a MultiPartDetector, from the parts
+// found in other chunks with AssemblePartialComposites.
+type CompositeDetector interface {
+	Detector
+	// CompositeParts returns the parts of the detector's credentials.
+	CompositeParts() []CompositePart
+}
+
+// CompositePart is one part of a composite credential. Its value is the
+// first capture group of Pattern, with surrounding whitespace trimmed.
+type CompositePart struct {
+	// Name identifies the part, such as "id" or "secret". It is the Kind of
+	// the part's PartialMatches.
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Composite is a candidate credential assembled from one value of each part
+// of a CompositeDetector.
+type Composite struct {
+	// Values maps the name of each part to its value.
+	Values map[string]string
+	// Offsets maps the name of each part to the offset of the value's first
+	// match in the data. It is nil for candidates assembled from partial
+	// matches, whose offsets aren't known.
+	Offsets map[string]int
+}
+
+// compositeValue is a distinct value of a part, and where it came from.
+type compositeValue struct {
+	value  string
+	offset int
+	// current is set for values of partial matches found in the current
+	// chunk rather than pending from other chunks.
+	current bool
+}
+
+// AssembleComposites returns the candidates made of every combination of the
+// distinct values of parts matched in data, in the order the values are
+// first matched. A combination is returned once however often its values are
+// matched, so that it's only verified once. Nothing is returned unless every
+// part is matched, and no more candidates are returned than the maximum
+// number of results per chunk, see MaxResultsReached.
+func AssembleComposites(data string, parts []CompositePart) []Composite {
+	values := make([][]compositeValue, len(parts))
+	for i, part := range parts {
+		seen := map[string]struct{}{}
+		for _, match := range part.Pattern.FindAllStringSubmatchIndex(data, -1) {
+			if len(match) < 4 || match[2] < 0 {
+				continue
+			}
+			value := strings.TrimSpace(data[match[2]:match[3]])
+			if _, ok := seen[value]; ok {
+				continue
+			}
+			seen[value] = struct{}{}
+			values[i] = append(values[i], compositeValue{value: value, offset: match[2]})
+		}
+	}
+	return assembleComposites(parts, values, true, nil)
+}
+
+// CompositePartialMatches returns the values of parts matched in data as
+// partial matches, for MultiPartDetector.PartialMatches.
+func CompositePartialMatches(data []byte, parts []CompositePart) []PartialMatch {
+	dataStr := string(data)
+	var matches []PartialMatch
+	for _, part := range parts {
+		for _, match := range part.Pattern.FindAllStringSubmatch(dataStr, -1) {
+			if len(match) < 2 {
+				continue
+			}
+			matches = append(matches, PartialMatch{Kind: part.Name, Value: strings.TrimSpace(match[1])})
+		}
+	}
+	return matches
+}
+
+// AssemblePartialComposites returns the candidates made of the parts found in
+// a chunk, current, and the parts pending from other chunks of the same
+// file, as passed to MultiPartDetector.FromPartialMatches. Only candidates
+// with values from both are returned, since the others were assembled from
+// a single chunk already. It is otherwise like AssembleComposites.
+func AssemblePartialComposites(current, pending []PartialMatch, parts []CompositePart) []Composite {
+	values := make([][]compositeValue, len(parts))
+	for i, part := range parts {
+		seen := map[string]struct{}{}
+		add := func(matches []PartialMatch, current bool) {
+			for _, match := range matches {
+				if match.Kind != part.Name {
+					continue
+				}
+				if _, ok := seen[match.Value]; ok {
+					continue
+				}
+				seen[match.Value] = struct{}{}
+				values[i] = append(values[i], compositeValue{value: match.Value, current: current})
+			}
+		}
+		add(current, true)
+		add(pending, false)
+	}
+	return assembleComposites(parts, values, false, func(combination []compositeValue) bool {
+		var fromCurrent, fromPending bool
+		for _, v := range combination {
+			fromCurrent = fromCurrent || v.current
+			fromPending = fromPending || !v.current
+		}
+		return fromCurrent && fromPending
+	})
+}
+
+// assembleComposites returns the combinations of one of the values of each
+// part that accept, or all of them if it's nil.
+func assembleComposites(parts []CompositePart, values [][]compositeValue, withOffsets bool, accept func([]compositeValue) bool) []Composite {
+	for _, v := range values {
+		if len(v) == 0 {
+			return nil
+		}
+	}
+
+	var composites []Composite
+	// indexes is the index of the value of each part in the combination,
+	// advanced like an odometer with the last part changing fastest.
+	indexes := make([]int, len(parts))
+	combination := make([]compositeValue, len(parts))
+	for !MaxResultsReached(len(composites)) {
+		for i, index := range indexes {
+			combination[i] = values[i][index]
+		}
+		if accept == nil || accept(combination) {
+			composite := Composite{Values: make(map[string]string, len(parts))}
+			if withOffsets {
+				composite.Offsets = make(map[string]int, len(parts))
+			}
+			for i, part := range parts {
+				composite.Values[part.Name] = combination[i].value
+				if withOffsets {
+					composite.Offsets[part.Name] = combination[i].offset
+				}
+			}
+			composites = append(composites, composite)
+		}
+
+		i := len(indexes) - 1
+		for ; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(values[i]) {
+				break
+			}
+			indexes[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+	return composites
+}
@@ -0,0 +1,51 @@
+package detectors
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCompositeParts = []CompositePart{
+	{Name: "id", Pattern: regexp.MustCompile(`id: ?([a-z0-9]+)`)},
+	{Name: "secret", Pattern: regexp.MustCompile(`secret: ?([a-z0-9]+)`)},
+}
+
+func TestAssembleComposites(t *testing.T) {
+	data := "id: abc\nsecret: s1\nid: def\nsecret: s2\nid: abc\nsecret: s1\n"
+	composites := AssembleComposites(data, testCompositeParts)
+
+	// Repeated values are only combined once.
+	assert.Equal(t, []Composite{
+		{Values: map[string]string{"id": "abc", "secret": "s1"}, Offsets: map[string]int{"id": 4, "secret": 16}},
+		{Values: map[string]string{"id": "abc", "secret": "s2"}, Offsets: map[string]int{"id": 4, "secret": 35}},
+		{Values: map[string]string{"id": "def", "secret": "s1"}, Offsets: map[string]int{"id": 23, "secret": 16}},
+		{Values: map[string]string{"id": "def", "secret": "s2"}, Offsets: map[string]int{"id": 23, "secret": 35}},
+	}, composites)
+
+	// Nothing is assembled unless every part is found.
+	assert.Empty(t, AssembleComposites("id: abc\nid: def\n", testCompositeParts))
+}
+
+func TestAssembleComposites_MaxResults(t *testing.T) {
+	SetMaxResultsPerChunk(3)
+	defer SetMaxResultsPerChunk(DefaultMaxResultsPerChunk)
+
+	data := "id: a\nid: b\nid: c\nsecret: x\nsecret: y\nsecret: z\n"
+	assert.Len(t, AssembleComposites(data, testCompositeParts), 3)
+}
+
+func TestAssemblePartialComposites(t *testing.T) {
+	pending := CompositePartialMatches([]byte("id: abc\nsecret: s1\n"), testCompositeParts)
+	current := CompositePartialMatches([]byte("secret: s2\nsecret: s2\n"), testCompositeParts)
+	assert.Equal(t, []PartialMatch{{Kind: "id", Value: "abc"}, {Kind: "secret", Value: "s1"}}, pending)
+	assert.Equal(t, []PartialMatch{{Kind: "secret", Value: "s2"}, {Kind: "secret", Value: "s2"}}, current)
+
+	// Only combinations of current and pending parts are assembled, as the
+	// others were assembled from their chunk.
+	assert.Equal(t, []Composite{
+		{Values: map[string]string{"id": "abc", "secret": "s2"}},
+	}, AssemblePartialComposites(current, pending, testCompositeParts))
+	assert.Empty(t, AssemblePartialComposites(current, current, testCompositeParts))
+}
@@ -0,0 +1,28 @@
+package detectors
+
+import "math"
+
+// MaxConfidence is the highest Confidence a result can have. Verified
+// results should always be assigned MaxConfidence.
+const MaxConfidence = 100
+
+// ShannonEntropy returns the Shannon entropy of s in bits per byte.
+func ShannonEntropy(s string) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var entropy float64
+	length := float64(len(s))
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
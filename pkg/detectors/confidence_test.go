@@ -0,0 +1,22 @@
+package detectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{input: "", want: 0},
+		{input: "aaaaaaaa", want: 0},
+		{input: "abababab", want: 1},
+		{input: "abcdefgh", want: 3},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, ShannonEntropy(tt.input), 1e-9, tt.input)
+	}
+}
@@ -0,0 +1,91 @@
+package detectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	logContext "github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// ErrVerificationUnavailable is wrapped by the errors of verification requests
+// made with WithRetry once they consistently fail to connect, such as when
+// scanning without network access. Results with such a VerificationError are
+// neither verified nor known to be invalid.
+var ErrVerificationUnavailable = errors.New("verification unavailable")
+
+// DefaultOfflineThreshold is the number of consecutive verifications that
+// must fail to connect before verification is considered unavailable.
+const DefaultOfflineThreshold = 5
+
+var (
+	// connectionFailures is the number of consecutive verifications that
+	// failed to connect.
+	connectionFailures atomic.Int64
+	offlineThreshold   atomic.Int64
+	// offlineWarned is set once the warning that verification is
+	// unavailable was logged, until a verification connects again.
+	offlineWarned atomic.Bool
+)
+
+func init() {
+	offlineThreshold.Store(DefaultOfflineThreshold)
+}
+
+// SetOfflineThreshold sets the number of consecutive verifications across all
+// detectors that must fail to connect before verification is considered
+// unavailable, and starts counting them afresh. Values below 1 mean a single
+// one.
+func SetOfflineThreshold(n int) {
+	offlineThreshold.Store(int64(n))
+	connectionFailures.Store(0)
+	offlineWarned.Store(false)
+}
+
+// VerificationUnavailable reports whether the latest verifications
+// consistently failed to connect, so that unverified results may be valid.
+func VerificationUnavailable() bool {
+	threshold := offlineThreshold.Load()
+	if threshold < 1 {
+		threshold = 1
+	}
+	return connectionFailures.Load() >= threshold
+}
+
+// isConnectionError reports whether err is a failure to connect to the
+// verification endpoint, as opposed to a response from it.
+func isConnectionError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// recordConnectivity records whether the verification that returned err
+// connected, and returns err wrapped in ErrVerificationUnavailable if
+// verification is unavailable. A warning is logged once it becomes
+// unavailable.
+func recordConnectivity(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	if !isConnectionError(err) {
+		connectionFailures.Store(0)
+		offlineWarned.Store(false)
+		return err
+	}
+
+	connectionFailures.Add(1)
+	if !VerificationUnavailable() {
+		return err
+	}
+	if offlineWarned.CompareAndSwap(false, true) {
+		logContext.AddLogger(ctx).Logger().Info("WARNING: verification requests consistently fail to connect, " +
+			"likely because there is no network access. Secrets can't be verified and unverified results may be valid.")
+	}
+	return fmt.Errorf("%w: %w", ErrVerificationUnavailable, err)
+}
@@ -0,0 +1,92 @@
+package detectors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry_VerificationUnavailable(t *testing.T) {
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	defer SetRetryPolicy(DefaultRetryPolicy)
+	SetOfflineThreshold(3)
+	defer SetOfflineThreshold(DefaultOfflineThreshold)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	// Requests to a closed server fail to connect, as without network access.
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	defer server.Close()
+
+	var attempts int
+	verify := func(url string) func() error {
+		return func() error {
+			attempts++
+			res, err := http.Get(url)
+			if err != nil {
+				return err
+			}
+			res.Body.Close()
+			return nil
+		}
+	}
+
+	// Connection failures below the threshold are plain verification
+	// errors, and retried.
+	for i := 0; i < 2; i++ {
+		attempts = 0
+		err := WithRetry(context.Background(), verify(unreachable.URL))
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrVerificationUnavailable))
+		assert.Equal(t, 3, attempts)
+		assert.False(t, VerificationUnavailable())
+	}
+
+	// Once they're consistent, verification is unavailable and connection
+	// failures aren't retried anymore.
+	attempts = 0
+	err := WithRetry(context.Background(), verify(unreachable.URL))
+	assert.ErrorIs(t, err, ErrVerificationUnavailable)
+	assert.True(t, VerificationUnavailable())
+	attempts = 0
+	err = WithRetry(context.Background(), verify(unreachable.URL))
+	assert.ErrorIs(t, err, ErrVerificationUnavailable)
+	assert.Equal(t, 1, attempts)
+
+	// It's available again once a request connects, whatever the response.
+	assert.NoError(t, WithRetry(context.Background(), verify(server.URL)))
+	assert.False(t, VerificationUnavailable())
+	err = WithRetry(context.Background(), verify(unreachable.URL))
+	assert.False(t, errors.Is(err, ErrVerificationUnavailable))
+}
+
+func TestWithRetry_ResponseErrorsAreNotConnectionFailures(t *testing.T) {
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	defer SetRetryPolicy(DefaultRetryPolicy)
+	SetOfflineThreshold(1)
+	defer SetOfflineThreshold(DefaultOfflineThreshold)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := WithRetry(context.Background(), func() error {
+		res, err := http.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		return HTTPStatusError(res)
+	})
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrVerificationUnavailable))
+	assert.False(t, VerificationUnavailable())
+}
@@ -0,0 +1,80 @@
+package detectors
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// scanDedupeFiles is the number of files whose secrets a ScanDeduper
+// remembers. Sources send the chunks of a file one after the other, so by the
+// time this many other files were reported from, the chunks of the least
+// recently reported one are long scanned.
+const scanDedupeFiles = 1024
+
+// ScanDeduper remembers the secrets reported from the most recent files of a
+// scan, so that a secret found again in a later chunk of the same file, for
+// example because it is in the overlap between two chunks, is only reported
+// once. It is safe for concurrent use.
+type ScanDeduper struct {
+	mu sync.Mutex
+	// files holds the secrets of each file, and recent its files from the
+	// most to the least recently reported from, to forget the latter.
+	files  map[string]*list.Element
+	recent *list.List
+}
+
+type scanDedupeFile struct {
+	name string
+	seen map[scanDedupeKey]struct{}
+}
+
+type scanDedupeKey struct {
+	detectorType detectorspb.DetectorType
+	detectorName string
+	raw, rawV2   string
+}
+
+// NewScanDeduper returns an empty ScanDeduper.
+func NewScanDeduper() *ScanDeduper {
+	return &ScanDeduper{files: make(map[string]*list.Element), recent: list.New()}
+}
+
+// Seen reports whether the same secret was already found in the same file, and
+// records it otherwise. Results that don't come from a file on disk are never
+// considered seen, since their sources report each occurrence by design, such
+// as the same secret in several git commits.
+func (d *ScanDeduper) Seen(result ResultWithMetadata) bool {
+	file := result.SourceMetadata.GetFilesystem().GetFile()
+	if file == "" {
+		return false
+	}
+	key := scanDedupeKey{
+		detectorType: result.DetectorType,
+		detectorName: result.DetectorName,
+		raw:          string(result.Raw),
+		rawV2:        string(result.RawV2),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	elem, ok := d.files[file]
+	if ok {
+		d.recent.MoveToFront(elem)
+	} else {
+		elem = d.recent.PushFront(&scanDedupeFile{name: file, seen: make(map[scanDedupeKey]struct{})})
+		d.files[file] = elem
+		if d.recent.Len() > scanDedupeFiles {
+			oldest := d.recent.Back()
+			d.recent.Remove(oldest)
+			delete(d.files, oldest.Value.(*scanDedupeFile).name)
+		}
+	}
+	seen := elem.Value.(*scanDedupeFile).seen
+	if _, ok := seen[key]; ok {
+		return true
+	}
+	seen[key] = struct{}{}
+	return false
+}
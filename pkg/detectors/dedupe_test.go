@@ -0,0 +1,53 @@
+package detectors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+func fileResult(file, raw string) ResultWithMetadata {
+	return ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: file},
+			},
+		},
+		Result: Result{DetectorType: detectorspb.DetectorType_Github, Raw: []byte(raw)},
+	}
+}
+
+func TestScanDeduper(t *testing.T) {
+	d := NewScanDeduper()
+	assert.False(t, d.Seen(fileResult("a.txt", "secret")))
+	assert.True(t, d.Seen(fileResult("a.txt", "secret")))
+	assert.False(t, d.Seen(fileResult("b.txt", "secret")))
+	assert.False(t, d.Seen(fileResult("a.txt", "other")))
+
+	git := ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Git{Git: &source_metadatapb.Git{File: "a.txt"}},
+		},
+		Result: Result{DetectorType: detectorspb.DetectorType_Github, Raw: []byte("secret")},
+	}
+	assert.False(t, d.Seen(git))
+	assert.False(t, d.Seen(git))
+}
+
+func TestScanDeduper_Bounded(t *testing.T) {
+	d := NewScanDeduper()
+	assert.False(t, d.Seen(fileResult("first.txt", "secret")))
+	for i := 0; i < scanDedupeFiles; i++ {
+		assert.False(t, d.Seen(fileResult(fmt.Sprintf("%d.txt", i), "secret")))
+	}
+	assert.Len(t, d.files, scanDedupeFiles)
+
+	// The least recently reported file is forgotten, the others aren't.
+	assert.False(t, d.Seen(fileResult("first.txt", "secret")))
+	assert.True(t, d.Seen(fileResult(fmt.Sprintf("%d.txt", scanDedupeFiles-1), "secret")))
+	assert.Len(t, d.files, scanDedupeFiles)
+}
@@ -5,8 +5,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
@@ -39,6 +41,62 @@ type EndpointCustomizer interface {
 	DefaultEndpoint() string
 }
 
+// CaseSensitiveKeyworder is an optional interface that a detector can
+// implement to declare which of its Keywords must match the chunk exactly.
+// Keywords not listed keep matching regardless of case.
+type CaseSensitiveKeyworder interface {
+	CaseSensitiveKeywords() []string
+}
+
+// KeywordPatterner is an optional interface that a detector can implement to
+// pre-filter chunks with regular expressions in addition to its Keywords, for
+// secrets whose prefixes have too many variants to list. A chunk is passed to
+// the detector if it contains one of the keywords or matches a pattern.
+// Patterns should be cheap, such as a literal prefix followed by a character
+// class, since they're run on every chunk.
+type KeywordPatterner interface {
+	KeywordPatterns() []*regexp.Regexp
+}
+
+// Remediator is an optional interface that a detector can implement to revoke
+// a verified secret with its provider. It is destructive, so the engine only
+// calls it when remediation was explicitly enabled.
+type Remediator interface {
+	Remediate(ctx context.Context, raw []byte) error
+}
+
+// Closer is an optional interface that a detector holding resources for
+// verification, such as an HTTP client with a connection pool or a cache of
+// OAuth tokens, can implement to release them. The engine calls Close once
+// every chunk was scanned, so a detector isn't used after it's closed.
+type Closer interface {
+	Close() error
+}
+
+// MultiPartDetector is an optional interface that a detector of credentials
+// made of several parts, such as an ID and a secret, can implement to pair
+// parts found in different chunks of the same file. The engine remembers the
+// parts found in every chunk of each file, and once a chunk of the file
+// contains the detector's keywords, passes them to FromPartialMatches along
+// with the parts found in later chunks.
+type MultiPartDetector interface {
+	// PartialMatches returns the parts of credentials found in data.
+	PartialMatches(data []byte) []PartialMatch
+	// FromPartialMatches returns the results of pairing parts found in a
+	// chunk with parts pending from other chunks of the same file. Pairs
+	// within the chunk are reported by FromData, so only pairs of a current
+	// and a pending part are returned.
+	FromPartialMatches(ctx context.Context, verify bool, current, pending []PartialMatch) ([]Result, error)
+}
+
+// PartialMatch is one part of a multi-part credential.
+type PartialMatch struct {
+	// Kind identifies the part, such as "id" or "secret". Its values are
+	// defined by the detector.
+	Kind  string
+	Value string
+}
+
 type Result struct {
 	// DetectorType is the type of Detector.
 	DetectorType detectorspb.DetectorType
@@ -58,6 +116,29 @@ type Result struct {
 	ExtraData      map[string]string
 	StructuredData *detectorspb.StructuredData
 
+	// Severity is an optional classification of how dangerous the secret is,
+	// typically determined during verification.
+	Severity Severity
+	// Confidence is an optional score from 0 to MaxConfidence of how likely
+	// the result is a real secret. Zero means the detector doesn't score results.
+	Confidence int
+
+	// VerifiedAt is when verification of the result was last attempted,
+	// according to the scan clock. It is zero if verification wasn't
+	// attempted.
+	VerifiedAt time.Time
+
+	// Expired records whether a credential that expires, such as a token
+	// with an expiry claim, had expired at the time of the scan. A verified
+	// credential that expired was valid but no longer is. It is nil if the
+	// detector doesn't know when the credential expires. See SetExpiry.
+	Expired *bool
+
+	// Trace holds the decisions the detector made for the result, such as
+	// the patterns that matched and the outcome of verification. It is only
+	// populated by detectors that support it, when SetTracing was enabled.
+	Trace []TraceStep
+
 	// This field should only be populated if the verification process itself failed in a way that provides no
 	// information about the verification status of the candidate secret, such as if the verification request timed out.
 	VerificationError error
@@ -72,6 +153,12 @@ type ResultWithMetadata struct {
 	SourceType sourcespb.SourceType
 	// SourceName is the name of the Source.
 	SourceName string
+	// SourceUnitID identifies the unit of the source the result was found
+	// in, such as the path of a file, if the source sets it.
+	SourceUnitID string
+	// ArchiveEntry is the sanitized path of the archive entry the result was
+	// found in, if any.
+	ArchiveEntry string
 	Result
 	// Data from the sources.Chunk which this result was emitted for
 	Data []byte
@@ -84,6 +171,8 @@ func CopyMetadata(chunk *sources.Chunk, result Result) ResultWithMetadata {
 		SourceID:       chunk.SourceID,
 		SourceType:     chunk.SourceType,
 		SourceName:     chunk.SourceName,
+		SourceUnitID:   chunk.SourceUnitID,
+		ArchiveEntry:   chunk.ArchiveEntry,
 		Result:         result,
 		Data:           chunk.Data,
 	}
@@ -95,6 +95,11 @@ func TestDigitalOceanToken_FromChunk(t *testing.T) {
 					t.Fatalf("no raw secret present: \n %+v", got[i])
 				}
 				got[i].Raw = nil
+				if got[i].Verified && (got[i].ExtraData["email"] == "" || got[i].ExtraData["status"] == "") {
+					t.Fatalf("no account email or status present: \n %+v", got[i])
+				}
+				got[i].ExtraData = nil
+				got[i].VerifiedAt = time.Time{}
 			}
 			if diff := pretty.Compare(got, tt.want); diff != "" {
 				t.Errorf("DigitalOceanToken.FromData() %s diff: (-got +want)\n%s", tt.name, diff)
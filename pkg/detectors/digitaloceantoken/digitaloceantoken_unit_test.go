@@ -0,0 +1,40 @@
+package digitaloceantoken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLegacyToken = "7b3e9f1a5c8d2e6b4a0f9c7e3d1b5a8f2c6e4d0b9a7f3e1c5d8b2a6f4e0c9d7b"
+
+func TestDigitalOceanToken_Verification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/account" || strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") != testLegacyToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"account":{"email":"ops@example.com","status":"active"}}`))
+	}))
+	defer server.Close()
+
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	results, err := s.FromData(context.Background(), true, []byte("digitalocean_access_token = "+testLegacyToken))
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Verified)
+		assert.NoError(t, results[0].VerificationError)
+		assert.Equal(t, map[string]string{"email": "ops@example.com", "status": "active"}, results[0].ExtraData)
+	}
+
+	// Tokens only match near a keyword.
+	results, err = s.FromData(context.Background(), true, []byte("sha256 = "+testLegacyToken))
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
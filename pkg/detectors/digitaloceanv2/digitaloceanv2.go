@@ -2,6 +2,7 @@ package digitaloceanv2
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,10 +14,17 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 )
 
-type Scanner struct{}
+type Scanner struct {
+	detectors.EndpointSetter
+}
 
-// Ensure the Scanner satisfies the interface at compile time.
+// Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+
+// DefaultEndpoint is the base URL of the DigitalOcean API used to verify
+// personal access tokens and OAuth tokens.
+func (Scanner) DefaultEndpoint() string { return "https://api.digitalocean.com" }
 
 var (
 	client = common.SaneHttpClient()
@@ -79,23 +87,20 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 				}
 
 			case strings.HasPrefix(resMatch, "doo_v1_"), strings.HasPrefix(resMatch, "dop_v1_"):
-				req, err := http.NewRequestWithContext(ctx, "GET", "https://api.digitalocean.com/v2/account", nil)
-				if err != nil {
-					continue
-				}
-				req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", resMatch))
-				res, err := client.Do(req)
-				if err == nil {
-					defer res.Body.Close()
-					if res.StatusCode >= 200 && res.StatusCode < 300 {
-						s1.Verified = true
-					} else {
-						// This function will check false positives for common test words, but also it will make sure the key appears 'random' enough to be a real key.
-						if detectors.IsKnownFalsePositive(resMatch, detectors.DefaultFalsePositives, true) {
-							continue
-						}
+				for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+					verified, extraData, verificationErr := VerifyAccount(ctx, client, endpoint, resMatch)
+					s1.Verified = verified
+					s1.ExtraData = extraData
+					s1.VerificationError = verificationErr
+					if verified {
+						break
 					}
 				}
+				s1.VerifiedAt = detectors.Now()
+				// This function will check false positives for common test words, but also it will make sure the key appears 'random' enough to be a real key.
+				if !s1.Verified && detectors.IsKnownFalsePositive(resMatch, detectors.DefaultFalsePositives, true) {
+					continue
+				}
 			}
 		}
 
@@ -105,6 +110,44 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 	return results, nil
 }
 
+// VerifyAccount checks token against the account endpoint of the DigitalOcean
+// API at endpoint, such as https://api.digitalocean.com. Verified tokens are
+// annotated with the email and status of their account. An error is returned
+// unless the response says whether the token is valid.
+func VerifyAccount(ctx context.Context, client *http.Client, endpoint, token string) (bool, map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(endpoint, "/")+"/v2/account", nil)
+	if err != nil {
+		return false, nil, err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	res, err := client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		var account struct {
+			Account struct {
+				Email  string `json:"email"`
+				Status string `json:"status"`
+			} `json:"account"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&account); err != nil {
+			return false, nil, err
+		}
+		return true, map[string]string{
+			"email":  account.Account.Email,
+			"status": account.Account.Status,
+		}, nil
+	case http.StatusUnauthorized:
+		return false, nil, nil
+	default:
+		return false, nil, fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
+	}
+}
+
 func (s Scanner) Type() detectorspb.DetectorType {
 	return detectorspb.DetectorType_DigitalOceanV2
 }
@@ -0,0 +1,40 @@
+package detectors
+
+import "context"
+
+// EmitPolicy controls which unverified results detectors emit.
+type EmitPolicy int
+
+const (
+	// EmitAll emits every result, whether verified or not.
+	EmitAll EmitPolicy = iota
+	// EmitVerifiedOnly drops results that verification rejected, or that
+	// weren't verified at all. Results whose verification failed with an
+	// error are still emitted, since they may be valid.
+	EmitVerifiedOnly
+)
+
+type emitPolicyKey struct{}
+
+// WithEmitPolicy returns a copy of ctx that asks the detectors it is passed
+// to emit results according to policy.
+func WithEmitPolicy(ctx context.Context, policy EmitPolicy) context.Context {
+	return context.WithValue(ctx, emitPolicyKey{}, policy)
+}
+
+// EmitPolicyFromContext returns the emit policy of ctx, which is EmitAll
+// unless set with WithEmitPolicy.
+func EmitPolicyFromContext(ctx context.Context) EmitPolicy {
+	policy, _ := ctx.Value(emitPolicyKey{}).(EmitPolicy)
+	return policy
+}
+
+// ShouldEmit returns whether a detector should emit r, once verification was
+// attempted, under the emit policy of ctx. Detectors that produce many
+// unverified candidates should check it to skip the ones nobody asked for.
+func ShouldEmit(ctx context.Context, r Result) bool {
+	if EmitPolicyFromContext(ctx) != EmitVerifiedOnly {
+		return true
+	}
+	return r.Verified || r.VerificationError != nil
+}
@@ -0,0 +1,27 @@
+package detectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldEmit(t *testing.T) {
+	verified := Result{Verified: true}
+	invalid := Result{}
+	indeterminate := Result{VerificationError: errors.New("timeout")}
+
+	ctx := context.Background()
+	assert.Equal(t, EmitAll, EmitPolicyFromContext(ctx))
+	for _, r := range []Result{verified, invalid, indeterminate} {
+		assert.True(t, ShouldEmit(ctx, r))
+	}
+
+	ctx = WithEmitPolicy(ctx, EmitVerifiedOnly)
+	assert.Equal(t, EmitVerifiedOnly, EmitPolicyFromContext(ctx))
+	assert.True(t, ShouldEmit(ctx, verified))
+	assert.False(t, ShouldEmit(ctx, invalid))
+	assert.True(t, ShouldEmit(ctx, indeterminate))
+}
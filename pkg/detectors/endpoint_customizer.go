@@ -2,8 +2,10 @@ package detectors
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 )
 
 // EndpointSetter implements a sensible default for the SetEndpoints function
@@ -34,3 +36,49 @@ func (e *EndpointSetter) Endpoints(defaultEndpoint string) []string {
 	}
 	return e.endpoints
 }
+
+// SelfHostedEndpoints maps a detector type to the base endpoints of
+// self-hosted instances of its provider, such as the GitLab or GitHub
+// Enterprise server given in a source's configuration. Applying it points
+// those detectors' verification at the configured hosts in addition to the
+// ones they already use.
+type SelfHostedEndpoints map[detectorspb.DetectorType][]string
+
+// Add records endpoint as a self-hosted instance for detectors of type t.
+// Trailing slashes are removed so that, for example, "https://gitlab.com/"
+// and "https://gitlab.com" are treated as the same endpoint.
+func (s SelfHostedEndpoints) Add(t detectorspb.DetectorType, endpoint string) {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if endpoint == "" {
+		return
+	}
+	endpoints := s[t]
+	common.AddStringSliceItem(endpoint, &endpoints)
+	s[t] = endpoints
+}
+
+// Apply configures every detector in ds that has self-hosted endpoints and
+// supports endpoint customization. The self-hosted endpoints are tried first,
+// followed by any endpoints the detector was already configured with. Other
+// detectors are left untouched.
+func (s SelfHostedEndpoints) Apply(ds ...Detector) error {
+	for _, d := range ds {
+		endpoints, ok := s[d.Type()]
+		if !ok || len(endpoints) == 0 {
+			continue
+		}
+		customizer, ok := d.(EndpointCustomizer)
+		if !ok {
+			continue
+		}
+		existing := []string{customizer.DefaultEndpoint()}
+		if lister, ok := d.(interface{ Endpoints(string) []string }); ok {
+			existing = lister.Endpoints(customizer.DefaultEndpoint())
+		}
+		urls := append(append([]string{}, endpoints...), existing...)
+		if err := customizer.SetEndpoints(urls...); err != nil {
+			return fmt.Errorf("could not configure %s endpoints: %w", d.Type(), err)
+		}
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package detectors
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 )
 
 func TestEmbeddedEndpointSetter(t *testing.T) {
@@ -14,3 +17,43 @@ func TestEmbeddedEndpointSetter(t *testing.T) {
 	assert.Error(t, s.SetEndpoints())
 	assert.Equal(t, []string{"foo", "bar"}, s.Endpoints("baz"))
 }
+
+type customizableDetector struct {
+	EndpointSetter
+	detectorType detectorspb.DetectorType
+}
+
+func (customizableDetector) FromData(context.Context, bool, []byte) ([]Result, error) {
+	return nil, nil
+}
+func (customizableDetector) Keywords() []string               { return nil }
+func (d customizableDetector) Type() detectorspb.DetectorType { return d.detectorType }
+func (customizableDetector) DefaultEndpoint() string          { return "https://public.example.com" }
+
+func TestSelfHostedEndpoints(t *testing.T) {
+	gitlab := &customizableDetector{detectorType: detectorspb.DetectorType_Gitlab}
+	github := &customizableDetector{detectorType: detectorspb.DetectorType_Github}
+	assert.NoError(t, github.SetEndpoints("https://verifier.example.com"))
+
+	endpoints := SelfHostedEndpoints{}
+	endpoints.Add(detectorspb.DetectorType_Gitlab, "https://gitlab.example.com/")
+	endpoints.Add(detectorspb.DetectorType_Gitlab, "https://gitlab.example.com")
+	endpoints.Add(detectorspb.DetectorType_Github, "https://ghe.example.com/api/v3")
+	endpoints.Add(detectorspb.DetectorType_Github, "")
+	assert.NoError(t, endpoints.Apply(gitlab, github))
+
+	assert.Equal(t,
+		[]string{"https://gitlab.example.com", "https://public.example.com"},
+		gitlab.Endpoints(gitlab.DefaultEndpoint()))
+	assert.Equal(t,
+		[]string{"https://ghe.example.com/api/v3", "https://verifier.example.com"},
+		github.Endpoints(github.DefaultEndpoint()))
+}
+
+func TestSelfHostedEndpoints_OtherTypesUntouched(t *testing.T) {
+	d := &customizableDetector{detectorType: detectorspb.DetectorType_AWS}
+	endpoints := SelfHostedEndpoints{}
+	endpoints.Add(detectorspb.DetectorType_Gitlab, "https://gitlab.example.com")
+	assert.NoError(t, endpoints.Apply(d))
+	assert.Equal(t, []string{d.DefaultEndpoint()}, d.Endpoints(d.DefaultEndpoint()))
+}
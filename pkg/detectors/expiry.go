@@ -0,0 +1,46 @@
+package detectors
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// SetExpiry records in r whether its credential, which expires at expiresAt,
+// has expired according to the scan clock.
+func (r *Result) SetExpiry(expiresAt time.Time) {
+	expired := !Now().Before(expiresAt)
+	r.Expired = &expired
+}
+
+// IsExpired returns true if the credential of r is known to have expired. It
+// is false both for active credentials and ones whose expiry is unknown.
+func (r Result) IsExpired() bool {
+	return r.Expired != nil && *r.Expired
+}
+
+// JWTExpiry returns the time the JSON Web Token expires, read from its exp
+// claim. It returns false if token isn't a JWT or has no exp claim. The
+// token's signature isn't checked.
+func JWTExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
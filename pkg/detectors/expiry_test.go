@@ -0,0 +1,62 @@
+package detectors
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult_SetExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+
+	tests := []struct {
+		name        string
+		expiresAt   *time.Time
+		wantExpired *bool
+	}{
+		{name: "active", expiresAt: timePtr(now.Add(time.Hour)), wantExpired: boolPtr(false)},
+		{name: "expired", expiresAt: timePtr(now.Add(-time.Hour)), wantExpired: boolPtr(true)},
+		{name: "expires now", expiresAt: timePtr(now), wantExpired: boolPtr(true)},
+		{name: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Result{Verified: true}
+			if tt.expiresAt != nil {
+				r.SetExpiry(*tt.expiresAt)
+			}
+			assert.Equal(t, tt.wantExpired, r.Expired)
+			assert.Equal(t, tt.wantExpired != nil && *tt.wantExpired, r.IsExpired())
+			assert.True(t, r.Verified)
+		})
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+	}
+
+	expiresAt, ok := JWTExpiry(jwt(`{"sub":"user","exp":1767225600}`))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), expiresAt.UTC())
+
+	for _, token := range []string{
+		jwt(`{"sub":"user"}`),
+		jwt(`not json`),
+		jwt(`{"exp":"tomorrow"}`),
+		"eyJhbGciOiJIUzI1NiJ9.!!!.c2lnbmF0dXJl",
+		"not-a-jwt",
+	} {
+		_, ok := JWTExpiry(token)
+		assert.False(t, ok, token)
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,189 @@
+package gcpapikey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+type Scanner struct {
+	detectors.EndpointSetter
+}
+
+// Ensure the Scanner satisfies the interfaces at compile time.
+var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+
+// DefaultEndpoint is the base URL of the Google APIs used for verification.
+func (Scanner) DefaultEndpoint() string { return "https://www.googleapis.com" }
+
+var (
+	client = common.SaneHttpClient()
+
+	keyPat = regexp.MustCompile(`\b(AIza[0-9A-Za-z\-_]{35})(?:[^0-9A-Za-z\-_]|$)`)
+)
+
+// verifyPath is a Books API search. The Books API is free to call, so
+// verification never bills the key's owner, and it is enabled for keys that
+// have not been restricted to specific APIs.
+const verifyPath = "/books/v1/volumes?maxResults=1&q=isbn:0"
+
+// Keywords are used for efficiently pre-filtering chunks.
+// Use identifiers in the secret preferably, or the provider name.
+func (s Scanner) Keywords() []string {
+	return []string{"AIza"}
+}
+
+// FromData will find and optionally verify Google API keys in a given set of bytes.
+func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
+	dataStr := string(data)
+
+	for _, match := range keyPat.FindAllStringSubmatch(dataStr, -1) {
+		if len(match) != 2 {
+			continue
+		}
+		resMatch := strings.TrimSpace(match[1])
+
+		s1 := detectors.Result{
+			DetectorType: detectorspb.DetectorType_GoogleApiKey,
+			Raw:          []byte(resMatch),
+		}
+
+		if verify {
+			for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+				verified, extraData, verificationErr := verifyKey(ctx, endpoint, resMatch)
+				if verified {
+					s1.Verified = true
+					s1.ExtraData = extraData
+					s1.VerificationError = nil
+					break
+				}
+				s1.VerificationError = verificationErr
+			}
+		}
+
+		if !s1.Verified && s1.VerificationError == nil && detectors.IsKnownFalsePositive(resMatch, detectors.DefaultFalsePositives, true) {
+			continue
+		}
+
+		results = append(results, s1)
+	}
+
+	return results, nil
+}
+
+// Error reasons returned by Google APIs for a key that exists but may not be
+// used for the request. They are reported as the key's restriction.
+var restrictionReasons = map[string]struct{}{
+	"API_KEY_SERVICE_BLOCKED":       {},
+	"API_KEY_HTTP_REFERRER_BLOCKED": {},
+	"API_KEY_IP_ADDRESS_BLOCKED":    {},
+	"API_KEY_ANDROID_APP_BLOCKED":   {},
+	"API_KEY_IOS_APP_BLOCKED":       {},
+	"SERVICE_DISABLED":              {},
+	"accessNotConfigured":           {},
+	"RATE_LIMIT_EXCEEDED":           {},
+	"RESOURCE_EXHAUSTED":            {},
+	"dailyLimitExceeded":            {},
+	"rateLimitExceeded":             {},
+	"userRateLimitExceeded":         {},
+}
+
+// Error reasons returned by Google APIs for a key that does not exist.
+var invalidReasons = map[string]struct{}{
+	"API_KEY_INVALID": {},
+	"keyInvalid":      {},
+}
+
+// verifyKey calls the Books API with key. A successful response verifies the
+// key. A forbidden or quota error also proves the key exists, so it too is
+// verified, with the reason recorded as a restriction hint. An invalid key
+// error means the key does not exist; anything else is returned as an error.
+func verifyKey(ctx context.Context, endpoint, key string) (bool, map[string]string, error) {
+	reqURL := strings.TrimRight(endpoint, "/") + verifyPath + "&key=" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return false, nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return true, nil, nil
+	}
+
+	var errRes errorRes
+	if err := json.NewDecoder(res.Body).Decode(&errRes); err != nil {
+		return false, nil, fmt.Errorf("unexpected status %d from %s: %w", res.StatusCode, endpoint, err)
+	}
+	reasons := errRes.reasons()
+	for _, reason := range reasons {
+		if _, ok := invalidReasons[reason]; ok {
+			return false, nil, nil
+		}
+	}
+	for _, reason := range reasons {
+		if _, ok := restrictionReasons[reason]; ok {
+			extraData := map[string]string{"restriction": reason}
+			if errRes.Error.Message != "" {
+				extraData["message"] = errRes.Error.Message
+			}
+			return true, extraData, nil
+		}
+	}
+	// Older APIs report an invalid key as a bare 400 without a reason code.
+	if res.StatusCode == http.StatusBadRequest && strings.Contains(errRes.Error.Message, "API key not valid") {
+		return false, nil, nil
+	}
+	return false, nil, fmt.Errorf("unexpected status %d from %s: %s", res.StatusCode, endpoint, errRes.Error.Message)
+}
+
+// errorRes is the error body returned by Google APIs.
+type errorRes struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Errors  []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+		Details []struct {
+			Reason string `json:"reason"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// reasons returns the machine-readable reasons in the error, most specific
+// first, followed by the overall status.
+func (e errorRes) reasons() []string {
+	var reasons []string
+	for _, detail := range e.Error.Details {
+		if detail.Reason != "" {
+			reasons = append(reasons, detail.Reason)
+		}
+	}
+	for _, err := range e.Error.Errors {
+		if err.Reason != "" {
+			reasons = append(reasons, err.Reason)
+		}
+	}
+	if e.Error.Status != "" {
+		reasons = append(reasons, e.Error.Status)
+	}
+	return reasons
+}
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_GoogleApiKey
+}
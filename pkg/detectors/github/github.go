@@ -1,11 +1,13 @@
 package github
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
@@ -19,10 +21,14 @@ type Scanner struct{ detectors.EndpointSetter }
 var _ detectors.Detector = (*Scanner)(nil)
 var _ detectors.Versioner = (*Scanner)(nil)
 var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+var _ detectors.Remediator = (*Scanner)(nil)
 
 func (Scanner) Version() int            { return 2 }
 func (Scanner) DefaultEndpoint() string { return "https://api.github.com" }
 
+// fineGrainedPrefix is the prefix of fine-grained personal access tokens.
+const fineGrainedPrefix = "github_pat_"
+
 var (
 	// Oauth token
 	// https://developer.github.com/v3/#oauth2-token-sent-in-a-header
@@ -74,35 +80,18 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 			client := common.SaneHttpClient()
 			// https://developer.github.com/v3/users/#get-the-authenticated-user
 			for _, url := range s.Endpoints(s.DefaultEndpoint()) {
-				req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/user", url), nil)
-				if err != nil {
-					continue
-				}
-				req.Header.Add("Content-Type", "application/json; charset=utf-8")
-				req.Header.Add("Authorization", fmt.Sprintf("token %s", token))
-				res, err := client.Do(req)
-				if err == nil {
-					if res.StatusCode >= 200 && res.StatusCode < 300 {
-						var userResponse userRes
-						err = json.NewDecoder(res.Body).Decode(&userResponse)
-						res.Body.Close()
-						if err == nil {
-							s1.Verified = true
-							s1.ExtraData = map[string]string{
-								"username":     userResponse.Login,
-								"url":          userResponse.UserURL,
-								"account_type": userResponse.Type,
-								"site_admin":   fmt.Sprintf("%t", userResponse.SiteAdmin),
-								"name":         userResponse.Name,
-								"company":      userResponse.Company,
-							}
-						}
-					}
+				verified, extraData, verificationErr := verifyToken(ctx, client, url, token)
+				if verified {
+					s1.Verified = true
+					s1.ExtraData = extraData
+					s1.VerificationError = nil
+					break
 				}
+				s1.VerificationError = verificationErr
 			}
 		}
 
-		if !s1.Verified && detectors.IsKnownFalsePositive(string(s1.Raw), detectors.DefaultFalsePositives, true) {
+		if !s1.Verified && detectors.IsKnownFalsePositive(tokenBody(token), detectors.DefaultFalsePositives, true) {
 			continue
 		}
 
@@ -112,6 +101,96 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 	return
 }
 
+// tokenBody returns the token without its type prefix, which would otherwise
+// be flagged by the false positive word lists (e.g. "github" in github_pat_).
+func tokenBody(token string) string {
+	if strings.HasPrefix(token, fineGrainedPrefix) {
+		return strings.TrimPrefix(token, fineGrainedPrefix)
+	}
+	if _, body, ok := strings.Cut(token, "_"); ok {
+		return body
+	}
+	return token
+}
+
+// verifyToken requests the authenticated user from the GitHub API at url. An
+// error is only returned if the validity of the token couldn't be determined.
+func verifyToken(ctx context.Context, client *http.Client, url, token string) (bool, map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/user", url), nil)
+	if err != nil {
+		return false, nil, err
+	}
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	req.Header.Add("Authorization", fmt.Sprintf("token %s", token))
+	res, err := client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		var userResponse userRes
+		if err := json.NewDecoder(res.Body).Decode(&userResponse); err != nil {
+			return false, nil, err
+		}
+		extraData := map[string]string{
+			"username":     userResponse.Login,
+			"url":          userResponse.UserURL,
+			"account_type": userResponse.Type,
+			"site_admin":   fmt.Sprintf("%t", userResponse.SiteAdmin),
+			"name":         userResponse.Name,
+			"company":      userResponse.Company,
+		}
+		// Fine-grained tokens don't have OAuth scopes.
+		if scopes := res.Header.Get("X-OAuth-Scopes"); scopes != "" {
+			extraData["scopes"] = scopes
+		}
+		return true, extraData, nil
+	case res.StatusCode == http.StatusUnauthorized:
+		return false, nil, nil
+	default:
+		return false, nil, fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
+	}
+}
+
+// Remediate revokes the token with the GitHub credential revocation API, which
+// takes the token itself as proof of possession rather than authenticating.
+// https://docs.github.com/en/rest/credentials/revoke
+func (s Scanner) Remediate(ctx context.Context, raw []byte) error {
+	client := common.SaneHttpClient()
+	var err error
+	for _, url := range s.Endpoints(s.DefaultEndpoint()) {
+		if err = revokeToken(ctx, client, url, string(raw)); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func revokeToken(ctx context.Context, client *http.Client, url, token string) error {
+	body, err := json.Marshal(map[string][]string{"credentials": {token}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/credentials/revoke", url), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/vnd.github+json")
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
+	}
+	return nil
+}
+
 func (s Scanner) Type() detectorspb.DetectorType {
 	return detectorspb.DetectorType_Github
 }
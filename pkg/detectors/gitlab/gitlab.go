@@ -2,6 +2,7 @@ package gitlab
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -54,30 +55,16 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 		}
 
 		if verify {
-			// there are 4 read 'scopes' for a gitlab token: api, read_user, read_repo, and read_registry
-			// they all grant access to different parts of the API. I couldn't find an endpoint that every
-			// one of these scopes has access to, so we just check an example endpoint for each scope. If any
-			// of them contain data, we know we have a valid key, but if they all fail, we don't
-
 			client := common.SaneHttpClient()
 			for _, baseURL := range s.Endpoints(s.DefaultEndpoint()) {
-				// test `read_user` scope
-				req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/v4/user", nil)
-				if err != nil {
-					continue
-				}
-				req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", resMatch))
-				res, err := client.Do(req)
-				if err == nil {
-					res.Body.Close() // The request body is unused.
-
-					// 200 means good key and has `read_user` scope
-					// 403 means good key but not the right scope
-					// 401 is bad key
-					if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusForbidden {
-						secret.Verified = true
-					}
+				verified, extraData, verificationErr := VerifyToken(ctx, client, baseURL, resMatch)
+				if verified {
+					secret.Verified = true
+					secret.ExtraData = extraData
+					secret.VerificationError = nil
+					break
 				}
+				secret.VerificationError = verificationErr
 			}
 		}
 
@@ -91,6 +78,76 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 	return results, nil
 }
 
+// VerifyToken checks token against the GitLab instance at baseURL. The base
+// URL has the same form as GitlabConfig.Endpoint, e.g. https://gitlab.com or
+// https://gitlab.example.com, with or without the /api/v4 suffix.
+//
+// There are 4 read 'scopes' for a gitlab token: api, read_user, read_repo,
+// and read_registry, and no endpoint that every one of them has access to.
+// The user endpoint is used to judge the token: 200 means a good key with the
+// `read_user` scope, 403 means a good key without it and 401 is a bad key.
+// Known tokens are annotated with the username and, if the instance supports
+// it, the token's scopes.
+func VerifyToken(ctx context.Context, client *http.Client, baseURL, token string) (bool, map[string]string, error) {
+	apiURL := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v4") + "/api/v4"
+
+	var user struct {
+		Username string `json:"username"`
+	}
+	statusCode, err := getJSON(ctx, client, apiURL+"/user", token, &user)
+	if err != nil {
+		return false, nil, err
+	}
+	switch statusCode {
+	case http.StatusOK, http.StatusForbidden:
+	case http.StatusUnauthorized:
+		return false, nil, nil
+	default:
+		return false, nil, fmt.Errorf("request to %s/user returned unexpected status %d", apiURL, statusCode)
+	}
+
+	extraData := map[string]string{}
+	if user.Username != "" {
+		extraData["username"] = user.Username
+	}
+
+	// Available since GitLab 15.5. Older instances return 404, in which case
+	// the scopes are simply left out.
+	var self struct {
+		Scopes []string `json:"scopes"`
+	}
+	if statusCode, err := getJSON(ctx, client, apiURL+"/personal_access_tokens/self", token, &self); err == nil && statusCode == http.StatusOK {
+		extraData["scopes"] = strings.Join(self.Scopes, ",")
+	}
+
+	if len(extraData) == 0 {
+		extraData = nil
+	}
+	return true, extraData, nil
+}
+
+// getJSON makes an authenticated GET request to url and decodes a successful
+// response into v.
+func getJSON(ctx context.Context, client *http.Client, url, token string, v any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			return res.StatusCode, err
+		}
+	}
+	return res.StatusCode, nil
+}
+
 func (s Scanner) Type() detectorspb.DetectorType {
 	return detectorspb.DetectorType_Gitlab
 }
@@ -110,6 +110,14 @@ func TestGitlab_FromChunk(t *testing.T) {
 					t.Fatal("no raw secret present")
 				}
 				got[i].Raw = nil
+				if got[i].Verified {
+					for _, key := range []string{"username", "scopes"} {
+						if got[i].ExtraData[key] == "" {
+							t.Fatalf("no %s in extra data", key)
+						}
+					}
+				}
+				got[i].ExtraData = nil
 			}
 			if diff := pretty.Compare(got, tt.want); diff != "" {
 				t.Errorf("Gitlab.FromData() %s diff: (-got +want)\n%s", tt.name, diff)
@@ -0,0 +1,106 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+const (
+	testToken        = "Kp3xZ8qLw2vNc7RtY5hB"
+	testNoScopeToken = "Tq9Wm4Xe7Zr2Lp6Vc3Nb"
+	testErrorToken   = "Hd5Yk8Ja2Ms6Qf9Ug3Wx"
+)
+
+// newGitlabServer serves the GitLab API under prefix, as a self-hosted
+// instance under a relative URL root would.
+func newGitlabServer(t *testing.T, prefix string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+"/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer " + testToken:
+			_, _ = w.Write([]byte(`{"id":1,"username":"jdoe"}`))
+		case "Bearer " + testNoScopeToken:
+			w.WriteHeader(http.StatusForbidden)
+		case "Bearer " + testErrorToken:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	mux.HandleFunc(prefix+"/api/v4/personal_access_tokens/self", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":42,"name":"ci","scopes":["api","read_user"]}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGitlab_Verification(t *testing.T) {
+	server := newGitlabServer(t, "")
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		token         string
+		wantVerified  bool
+		wantExtraData map[string]string
+		wantVerifyErr bool
+	}{
+		{
+			name:          "token with read_user scope",
+			token:         testToken,
+			wantVerified:  true,
+			wantExtraData: map[string]string{"username": "jdoe", "scopes": "api,read_user"},
+		},
+		{name: "token without read_user scope", token: testNoScopeToken, wantVerified: true},
+		{name: "server error", token: testErrorToken, wantVerifyErr: true},
+		{name: "revoked token", token: "Zb7Nf2Rq5Wk9Xm3Lt8Vd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scanner{}
+			assert.NoError(t, s.SetEndpoints(server.URL))
+			results, err := s.FromData(context.Background(), true, []byte("gitlab token: "+tt.token))
+			assert.NoError(t, err)
+			if !assert.Len(t, results, 1) {
+				return
+			}
+			result := results[0]
+			assert.Equal(t, tt.token, string(result.Raw))
+			assert.Equal(t, tt.wantVerified, result.Verified)
+			assert.Equal(t, tt.wantVerifyErr, result.VerificationError != nil)
+			assert.Equal(t, tt.wantExtraData, result.ExtraData)
+		})
+	}
+}
+
+func TestVerifyToken_SelfHosted(t *testing.T) {
+	server := newGitlabServer(t, "/gitlab")
+	defer server.Close()
+
+	for _, baseURL := range []string{
+		server.URL + "/gitlab",
+		server.URL + "/gitlab/",
+		server.URL + "/gitlab/api/v4",
+	} {
+		t.Run(baseURL, func(t *testing.T) {
+			verified, extraData, err := VerifyToken(context.Background(), common.SaneHttpClient(), baseURL, testToken)
+			assert.NoError(t, err)
+			assert.True(t, verified)
+			assert.Equal(t, "jdoe", extraData["username"])
+			assert.Equal(t, "api,read_user", extraData["scopes"])
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package detectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+// IntrospectionEndpoint is an OAuth 2.0 token introspection endpoint, as
+// described in RFC 7662, along with the client credentials used to
+// authenticate to it.
+type IntrospectionEndpoint struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	// Client is the HTTP client used for requests. Nil means a
+	// common.SaneHttpClient.
+	Client *http.Client
+}
+
+// introspectionRes is the response of an introspection endpoint. Only active
+// is required.
+type introspectionRes struct {
+	Active   bool   `json:"active"`
+	Scope    string `json:"scope"`
+	ClientID string `json:"client_id"`
+	Username string `json:"username"`
+	Exp      int64  `json:"exp"`
+}
+
+// VerifyByIntrospection verifies token by asking the introspection endpoint
+// whether it is active. The result r is verified if it is, and records the
+// scopes, client and user of the token in its ExtraData. If the endpoint
+// reports when the token expires, whether it expired is recorded too, so an
+// inactive token that expired can be told apart from a revoked one.
+// Requests are retried with WithRetry, and failures that say nothing about
+// the token are recorded as r's VerificationError. An error is only returned
+// if ctx is done.
+func VerifyByIntrospection(ctx context.Context, r *Result, token string, endpoint IntrospectionEndpoint) error {
+	var res *introspectionRes
+	err := WithRetry(ctx, func() error {
+		var err error
+		res, err = introspect(ctx, token, endpoint)
+		return err
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+		return err
+	}
+	r.VerifiedAt = Now()
+	r.VerificationError = err
+	if err != nil {
+		return nil
+	}
+
+	r.Verified = res.Active
+	if res.Exp > 0 {
+		r.SetExpiry(time.Unix(res.Exp, 0))
+	}
+	if !res.Active {
+		return nil
+	}
+	if r.ExtraData == nil {
+		r.ExtraData = map[string]string{}
+	}
+	for key, value := range map[string]string{
+		"scopes":    strings.Join(strings.Fields(res.Scope), ","),
+		"client_id": res.ClientID,
+		"username":  res.Username,
+	} {
+		if value != "" {
+			r.ExtraData[key] = value
+		}
+	}
+	return nil
+}
+
+// introspect makes a single introspection request for token.
+func introspect(ctx context.Context, token string, endpoint IntrospectionEndpoint) (*introspectionRes, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if endpoint.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(endpoint.ClientID), url.QueryEscape(endpoint.ClientSecret))
+	}
+
+	client := endpoint.Client
+	if client == nil {
+		client = common.SaneHttpClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, HTTPStatusError(resp)
+	}
+
+	var res introspectionRes
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
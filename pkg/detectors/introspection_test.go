@@ -0,0 +1,104 @@
+package detectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyByIntrospection(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	defer SetRetryPolicy(DefaultRetryPolicy)
+
+	responses := map[string]any{
+		"active-token": map[string]any{
+			"active":    true,
+			"scope":     "read write",
+			"client_id": "app",
+			"exp":       now.Add(time.Hour).Unix(),
+		},
+		"expired-token": map[string]any{
+			"active": false,
+			"exp":    now.Add(-time.Hour).Unix(),
+		},
+		"revoked-token": map[string]any{"active": false},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "s3cr%2Ft" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		res, ok := responses[r.PostFormValue("token")]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer server.Close()
+	endpoint := IntrospectionEndpoint{URL: server.URL, ClientID: "client", ClientSecret: "s3cr/t"}
+
+	tests := []struct {
+		name         string
+		token        string
+		endpoint     IntrospectionEndpoint
+		wantVerified bool
+		wantExpired  *bool
+		wantExtra    map[string]string
+		wantErr      bool
+	}{
+		{
+			name:         "active",
+			token:        "active-token",
+			endpoint:     endpoint,
+			wantVerified: true,
+			wantExpired:  boolPtr(false),
+			wantExtra:    map[string]string{"scopes": "read,write", "client_id": "app"},
+		},
+		{name: "inactive and expired", token: "expired-token", endpoint: endpoint, wantExpired: boolPtr(true)},
+		{name: "inactive", token: "revoked-token", endpoint: endpoint},
+		{name: "endpoint error", token: "unknown-token", endpoint: endpoint, wantErr: true},
+		{
+			name:     "wrong client credentials",
+			token:    "active-token",
+			endpoint: IntrospectionEndpoint{URL: server.URL, ClientID: "client", ClientSecret: "wrong"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Result
+			err := VerifyByIntrospection(context.Background(), &r, tt.token, tt.endpoint)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVerified, r.Verified)
+			assert.Equal(t, tt.wantExpired, r.Expired)
+			assert.Equal(t, tt.wantExtra, r.ExtraData)
+			assert.Equal(t, now, r.VerifiedAt)
+			if tt.wantErr {
+				assert.Error(t, r.VerificationError)
+			} else {
+				assert.NoError(t, r.VerificationError)
+			}
+		})
+	}
+}
+
+func TestVerifyByIntrospection_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var r Result
+	err := VerifyByIntrospection(ctx, &r, "token", IntrospectionEndpoint{URL: server.URL})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, r.VerifiedAt.IsZero())
+}
@@ -0,0 +1,28 @@
+package detectors
+
+import "sync/atomic"
+
+// DefaultMaxResultsPerChunk is the default maximum number of results a
+// detector returns for a single chunk.
+const DefaultMaxResultsPerChunk = 100
+
+var maxResultsPerChunk atomic.Int64
+
+func init() {
+	maxResultsPerChunk.Store(DefaultMaxResultsPerChunk)
+}
+
+// SetMaxResultsPerChunk sets the maximum number of results a detector returns
+// for a single chunk. This protects against pathological inputs producing an
+// enormous number of candidates and verification requests. A value <= 0
+// removes the limit.
+func SetMaxResultsPerChunk(n int) {
+	maxResultsPerChunk.Store(int64(n))
+}
+
+// MaxResultsReached returns true if count has reached the configured maximum
+// number of results per chunk.
+func MaxResultsReached(count int) bool {
+	max := maxResultsPerChunk.Load()
+	return max > 0 && int64(count) >= max
+}
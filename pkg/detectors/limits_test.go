@@ -0,0 +1,22 @@
+package detectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxResultsReached(t *testing.T) {
+	defer SetMaxResultsPerChunk(DefaultMaxResultsPerChunk)
+
+	assert.False(t, MaxResultsReached(DefaultMaxResultsPerChunk-1))
+	assert.True(t, MaxResultsReached(DefaultMaxResultsPerChunk))
+
+	SetMaxResultsPerChunk(2)
+	assert.False(t, MaxResultsReached(1))
+	assert.True(t, MaxResultsReached(2))
+	assert.True(t, MaxResultsReached(3))
+
+	SetMaxResultsPerChunk(0)
+	assert.False(t, MaxResultsReached(1_000_000))
+}
@@ -2,9 +2,11 @@ package mailgun
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
@@ -12,10 +14,15 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 )
 
-type Scanner struct{}
+type Scanner struct {
+	detectors.EndpointSetter
+}
 
-// Ensure the Scanner satisfies the interface at compile time.
+// Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+
+func (Scanner) DefaultEndpoint() string { return "https://api.mailgun.net/v3/domains" }
 
 var (
 	client = common.SaneHttpClient()
@@ -51,30 +58,13 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 			}
 
 			if verify {
-				req, err := http.NewRequestWithContext(ctx, "GET", "https://api.mailgun.net/v3/domains", nil)
-				if err != nil {
+				verified, extraData, verificationErr := s.verifyMatch(ctx, resMatch)
+				s1.Verified = verified
+				s1.ExtraData = extraData
+				s1.VerificationError = verificationErr
+				if !verified && verificationErr == nil && detectors.IsKnownFalsePositive(resMatch, detectors.DefaultFalsePositives, true) {
 					continue
 				}
-				
-				// If resMatch has "key" prefix, use it as the username for basic auth.
-				if strings.HasPrefix(resMatch, "key-") {
-					req.SetBasicAuth("api", resMatch)
-				} else {
-					req.Header.Add("Authorization", fmt.Sprintf("Basic %s", resMatch))
-				}
-
-				res, err := client.Do(req)
-				if err == nil {
-					defer res.Body.Close()
-					if res.StatusCode >= 200 && res.StatusCode < 300 {
-						s1.Verified = true
-					} else {
-						if detectors.IsKnownFalsePositive(resMatch, detectors.DefaultFalsePositives, true) {
-							continue
-						}
-					}
-				}
-
 			}
 
 			results = append(results, s1)
@@ -84,6 +74,46 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 	return results, nil
 }
 
+// verifyMatch lists the account's domains with the given key. A 401 or 403
+// means the key is invalid; any other unexpected response is returned as an
+// error.
+func (s Scanner) verifyMatch(ctx context.Context, key string) (bool, map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.Endpoints(s.DefaultEndpoint())[0], nil)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// The original token format is already the encoded basic auth credentials.
+	if len(key) == 72 {
+		req.Header.Add("Authorization", fmt.Sprintf("Basic %s", key))
+	} else {
+		req.SetBasicAuth("api", key)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		var domains domainsRes
+		if err := json.NewDecoder(res.Body).Decode(&domains); err != nil {
+			return true, nil, nil
+		}
+		return true, map[string]string{"domains": strconv.Itoa(domains.TotalCount)}, nil
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return false, nil, nil
+	default:
+		return false, nil, fmt.Errorf("request to %v returned unexpected status %d", res.Request.URL, res.StatusCode)
+	}
+}
+
+type domainsRes struct {
+	TotalCount int `json:"total_count"`
+}
+
 func (s Scanner) Type() detectorspb.DetectorType {
 	return detectorspb.DetectorType_Mailgun
 }
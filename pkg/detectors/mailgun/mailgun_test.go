@@ -129,6 +129,12 @@ func TestMailgun_FromChunk(t *testing.T) {
 					t.Fatalf("no raw secret present: \n %+v", got[i])
 				}
 				got[i].Raw = nil
+				if got[i].Verified {
+					if _, ok := got[i].ExtraData["domains"]; !ok {
+						t.Fatalf("no domain count present: \n %+v", got[i])
+					}
+				}
+				got[i].ExtraData = nil
 			}
 			if diff := pretty.Compare(got, tt.want); diff != "" {
 				t.Errorf("Mailgun.FromData() %s  diff: (-got +want)\n%s", tt.name, diff)
@@ -0,0 +1,80 @@
+package mailgun
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testKeyDashToken = "key-3ax6xnjp29jd6fds4gc373sgvjxteol0"
+	testHexToken     = "9c4bd0e3a1f87d2b6e5c09a4f3b1d872-8e2f1a6c-4b7d3e90"
+	testRevokedToken = "key-7qw2mzr8kx4vn1bt6yc9hd5fs0lgj3pe"
+	testErrorToken   = "key-z5k12dxbg2n9ufi0jwr47yqy8tbheqbm"
+)
+
+func TestMailgun_Pattern(t *testing.T) {
+	data := fmt.Sprintf("MAILGUN_API_KEY=%s\nmailgun_key: %s\nnot_a_key=key-tooshort\n", testKeyDashToken, testHexToken)
+	results, err := Scanner{}.FromData(context.Background(), false, []byte(data))
+	assert.NoError(t, err)
+
+	var raw []string
+	for _, result := range results {
+		raw = append(raw, string(result.Raw))
+	}
+	sort.Strings(raw)
+	assert.Equal(t, []string{testHexToken, testKeyDashToken}, raw)
+}
+
+func TestMailgun_Verification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, key, ok := r.BasicAuth()
+		if !ok || user != "api" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch key {
+		case testKeyDashToken, testHexToken:
+			_, _ = w.Write([]byte(`{"total_count":2,"items":[{"name":"mg.example.com"},{"name":"mail.example.org"}]}`))
+		case testErrorToken:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		token         string
+		wantVerified  bool
+		wantVerifyErr bool
+	}{
+		{name: "key-dash token", token: testKeyDashToken, wantVerified: true},
+		{name: "hex token", token: testHexToken, wantVerified: true},
+		{name: "revoked token", token: testRevokedToken},
+		{name: "server error", token: testErrorToken, wantVerifyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scanner{}
+			assert.NoError(t, s.SetEndpoints(server.URL))
+			results, err := s.FromData(context.Background(), true, []byte("mailgun "+tt.token))
+			assert.NoError(t, err)
+			if !assert.Len(t, results, 1) {
+				return
+			}
+			result := results[0]
+			assert.Equal(t, tt.wantVerified, result.Verified)
+			assert.Equal(t, tt.wantVerifyErr, result.VerificationError != nil)
+			if tt.wantVerified {
+				assert.Equal(t, "2", result.ExtraData["domains"])
+			}
+		})
+	}
+}
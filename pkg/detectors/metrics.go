@@ -0,0 +1,104 @@
+package detectors
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+var (
+	detectorMatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "detector_matches_total",
+		Help:      "Total number of candidate secrets matched by a detector.",
+	},
+		[]string{"detector_type"})
+
+	detectorVerificationAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "detector_verification_attempts_total",
+		Help:      "Total number of verification attempts made by a detector.",
+	},
+		[]string{"detector_type"})
+
+	detectorVerificationSuccesses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "detector_verification_successes_total",
+		Help:      "Total number of candidate secrets successfully verified by a detector.",
+	},
+		[]string{"detector_type"})
+
+	detectorVerificationLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "detector_verification_latency_seconds",
+		Help:      "Time taken by a detector to verify a candidate secret.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	},
+		[]string{"detector_type"})
+)
+
+// RecordMatches records the number of candidate secrets a detector matched.
+func RecordMatches(detectorType detectorspb.DetectorType, count int) {
+	detectorMatches.WithLabelValues(detectorType.String()).Add(float64(count))
+}
+
+// RecordVerification records a single verification attempt by a detector,
+// whether it succeeded, and how long it took.
+func RecordVerification(detectorType detectorspb.DetectorType, verified bool, latency time.Duration) {
+	label := detectorType.String()
+	detectorVerificationAttempts.WithLabelValues(label).Inc()
+	if verified {
+		detectorVerificationSuccesses.WithLabelValues(label).Inc()
+	}
+	detectorVerificationLatency.WithLabelValues(label).Observe(latency.Seconds())
+}
+
+// DetectorMetrics is a point in time snapshot of the metrics recorded for a
+// detector type.
+type DetectorMetrics struct {
+	Matches                uint64
+	VerificationAttempts   uint64
+	VerificationSuccesses  uint64
+	VerificationLatencySum time.Duration
+}
+
+// GetDetectorMetrics returns a snapshot of the metrics recorded for the
+// provided detector type.
+func GetDetectorMetrics(detectorType detectorspb.DetectorType) DetectorMetrics {
+	label := detectorType.String()
+	return DetectorMetrics{
+		Matches:                uint64(counterValue(detectorMatches.WithLabelValues(label))),
+		VerificationAttempts:   uint64(counterValue(detectorVerificationAttempts.WithLabelValues(label))),
+		VerificationSuccesses:  uint64(counterValue(detectorVerificationSuccesses.WithLabelValues(label))),
+		VerificationLatencySum: histogramSum(detectorVerificationLatency.WithLabelValues(label)),
+	}
+}
+
+func counterValue(c prometheus.Counter) float64 {
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		return 0
+	}
+	return m.GetCounter().GetValue()
+}
+
+func histogramSum(o prometheus.Observer) time.Duration {
+	h, ok := o.(prometheus.Histogram)
+	if !ok {
+		return 0
+	}
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		return 0
+	}
+	return time.Duration(m.GetHistogram().GetSampleSum() * float64(time.Second))
+}
@@ -0,0 +1,25 @@
+package detectors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+func TestDetectorMetrics(t *testing.T) {
+	detectorType := detectorspb.DetectorType_Alibaba
+	before := GetDetectorMetrics(detectorType)
+
+	RecordMatches(detectorType, 3)
+	RecordVerification(detectorType, true, time.Second)
+	RecordVerification(detectorType, false, time.Second)
+
+	after := GetDetectorMetrics(detectorType)
+	assert.Equal(t, uint64(3), after.Matches-before.Matches)
+	assert.Equal(t, uint64(2), after.VerificationAttempts-before.VerificationAttempts)
+	assert.Equal(t, uint64(1), after.VerificationSuccesses-before.VerificationSuccesses)
+	assert.Equal(t, 2*time.Second, after.VerificationLatencySum-before.VerificationLatencySum)
+}
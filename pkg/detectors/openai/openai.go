@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
-	"strconv"
+	"strings"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
@@ -14,99 +14,141 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 )
 
-type Scanner struct{}
+type Scanner struct {
+	detectors.EndpointSetter
+}
 
-// Ensure the Scanner satisfies the interface at compile time.
+// Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
 
-// The magic string T3BlbkFJ is the base64-encoded string: OpenAI
-var keyPat = regexp.MustCompile(`\b(sk-[[:alnum:]]{20}T3BlbkFJ[[:alnum:]]{20})\b`)
+func (Scanner) DefaultEndpoint() string { return "https://api.openai.com" }
 
-// TODO: Add secret context?? Information about access, ownership etc
-type orgResponse struct {
-	Data []organization `json:"data"`
-}
+var (
+	client = common.SaneHttpClient()
+
+	keyPats = []*regexp.Regexp{
+		// User keys. The magic string T3BlbkFJ that most of them contain is
+		// the base64-encoded string: OpenAI
+		regexp.MustCompile(`\b(sk-[[:alnum:]]{48})\b`),
+		// Project keys, which also allow - and _ and are much longer.
+		regexp.MustCompile(`\b(sk-proj-[[:alnum:]_\-]{40,200})(?:[^[:alnum:]_\-]|$)`),
+	}
+)
 
-type organization struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	User        string `json:"name"`
-	Description string `json:"description"`
-	Personal    bool   `json:"personal"`
-	Default     bool   `json:"is_default"`
-	Role        string `json:"role"`
+// projectKeyPrefix identifies keys scoped to a single project.
+const projectKeyPrefix = "sk-proj-"
+
+// minKeyEntropy is the Shannon entropy below which a key is considered a
+// placeholder, such as sk- followed by repeated or sequential characters. Real
+// keys are random and score close to 6 bits per byte.
+const minKeyEntropy = 3.5
+
+type modelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
 }
 
 // Keywords are used for efficiently pre-filtering chunks.
 // Use identifiers in the secret preferably, or the provider name.
 func (s Scanner) Keywords() []string {
-	return []string{"T3BlbkFJ"}
+	return []string{"T3BlbkFJ", "sk-", "openai"}
 }
 
 // FromData will find and optionally verify OpenAI secrets in a given set of bytes.
 func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
 	dataStr := string(data)
 
-	matches := keyPat.FindAllStringSubmatch(dataStr, -1)
-
-	for _, match := range matches {
-		// First match is entire regex, second is the first group.
-		if len(match) != 2 {
-			continue
-		}
+	for _, keyPat := range keyPats {
+		matches := keyPat.FindAllStringSubmatch(dataStr, -1)
 
-		token := match[1]
-
-		s1 := detectors.Result{
-			DetectorType: detectorspb.DetectorType_OpenAI,
-			Redacted:     token[:3] + "..." + token[47:],
-			Raw:          []byte(token),
-		}
+		for _, match := range matches {
+			// First match is entire regex, second is the first group.
+			if len(match) != 2 {
+				continue
+			}
 
-		if verify {
-			client := common.SaneHttpClient()
-			// Undocumented API
-			// https://api.openai.com/v1/organizations
-			req, err := http.NewRequestWithContext(ctx, "GET", "https://api.openai.com/v1/organizations", nil)
-			if err != nil {
+			token := match[1]
+			if isPlaceholder(token) {
 				continue
 			}
-			req.Header.Add("Content-Type", "application/json; charset=utf-8")
-			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-			res, err := client.Do(req)
-			if err == nil {
-				if res.StatusCode >= 200 && res.StatusCode < 300 {
-					var orgs orgResponse
-					err = json.NewDecoder(res.Body).Decode(&orgs)
-					res.Body.Close()
-					if err == nil {
+
+			s1 := detectors.Result{
+				DetectorType: detectorspb.DetectorType_OpenAI,
+				Redacted:     token[:3] + "..." + token[len(token)-4:],
+				Raw:          []byte(token),
+			}
+
+			if verify {
+				for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+					verified, extraData, verificationErr := verifyToken(ctx, endpoint, token)
+					if verified {
 						s1.Verified = true
-						org := orgs.Data[0]
-						s1.ExtraData = map[string]string{
-							"id":          org.ID,
-							"title":       org.Title,
-							"user":        org.User,
-							"description": org.Description,
-							"role":        org.Role,
-							"is_personal": strconv.FormatBool(org.Personal),
-							"is_default":  strconv.FormatBool(org.Default),
-							"total_orgs":  fmt.Sprintf("%d", len(orgs.Data)),
-						}
+						s1.ExtraData = extraData
+						s1.VerificationError = nil
+						break
 					}
+					s1.VerificationError = verificationErr
 				}
 			}
-		}
 
-		if !s1.Verified && detectors.IsKnownFalsePositive(string(s1.Raw), detectors.DefaultFalsePositives, true) {
-			continue
-		}
+			if !s1.Verified && detectors.IsKnownFalsePositive(string(s1.Raw), detectors.DefaultFalsePositives, true) {
+				continue
+			}
 
-		results = append(results, s1)
+			results = append(results, s1)
+		}
 	}
 
 	return
 }
 
+// isPlaceholder reports whether token is obviously an example rather than a
+// real key, so no verification request is made for it.
+func isPlaceholder(token string) bool {
+	body := strings.TrimPrefix(strings.TrimPrefix(token, projectKeyPrefix), "sk-")
+	return detectors.IsKnownFalsePositive(body, detectors.DefaultFalsePositives, false) ||
+		detectors.ShannonEntropy(body) < minKeyEntropy
+}
+
+// verifyToken lists the models available to token. A 401 means the token is
+// invalid, any other unexpected status is returned as an error. Verified
+// tokens are annotated with their key type, organization and the first model
+// they can access.
+func verifyToken(ctx context.Context, endpoint, token string) (bool, map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(endpoint, "/")+"/v1/models", nil)
+	if err != nil {
+		return false, nil, err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	res, err := client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		return false, nil, nil
+	case res.StatusCode < 200 || res.StatusCode >= 300:
+		return false, nil, fmt.Errorf("request to %v returned unexpected status %d", res.Request.URL, res.StatusCode)
+	}
+
+	extraData := map[string]string{"key_type": "user"}
+	if strings.HasPrefix(token, projectKeyPrefix) {
+		extraData["key_type"] = "project"
+	}
+	if org := res.Header.Get("Openai-Organization"); org != "" {
+		extraData["organization"] = org
+	}
+	var models modelsResponse
+	if err := json.NewDecoder(res.Body).Decode(&models); err == nil && len(models.Data) > 0 {
+		extraData["first_model"] = models.Data[0].ID
+	}
+	return true, extraData, nil
+}
+
 func (s Scanner) Type() detectorspb.DetectorType {
 	return detectorspb.DetectorType_OpenAI
 }
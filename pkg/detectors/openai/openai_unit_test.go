@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testUserKey    = "sk-Qm7Rt2Vx9Lp4Nc8ZgT3BlbkFJKw3Hd6Fj1Ub5Gs0Yq2Xe7Ln"
+	testProjectKey = "sk-proj-Vb4Nq9Xm2Kr7Lt1Wc6Hz3Fd8Gj5Ps0Ea_Uy2Rk7Mn4Qx9T3BlbkFJLp1Zw6Cv3Hb8Ds5Gf0Jq"
+	testRevokedKey = "sk-Zx8Wn3Kq6Rv1Mt9LpT3BlbkFJHc4Gf7Db2Js5Ya0Ue8Xo3Ni"
+	testErrorKey   = "sk-Hd2Jq7Xw4Ln9Rt1KmT3BlbkFJZb6Vc3Gf8Ps5Ya0Ue2Mo7Qi"
+)
+
+func TestOpenAI_Pattern(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{name: "user key", data: "OPENAI_API_KEY=" + testUserKey, want: []string{testUserKey}},
+		{name: "project key", data: fmt.Sprintf("openai:\n  key: %q\n", testProjectKey), want: []string{testProjectKey}},
+		{name: "too short", data: "openai sk-Qm7Rt2Vx9Lp4Nc8ZT3BlbkFJKw3Hd6Fj1Ub5"},
+		{name: "placeholder", data: "OPENAI_API_KEY=sk-xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"},
+		{name: "low entropy placeholder", data: "OPENAI_API_KEY=sk-123412341234123412341234123412341234123412341234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Scanner{}.FromData(context.Background(), false, []byte(tt.data))
+			assert.NoError(t, err)
+			var raw []string
+			for _, result := range results {
+				raw = append(raw, string(result.Raw))
+			}
+			assert.Equal(t, tt.want, raw)
+		})
+	}
+}
+
+func TestOpenAI_Verification(t *testing.T) {
+	var placeholderRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Header.Get("Authorization") {
+		case "Bearer " + testUserKey:
+			w.Header().Set("Openai-Organization", "org-x7k2m9")
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model"},{"id":"gpt-4o-mini","object":"model"}]}`))
+		case "Bearer " + testProjectKey:
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"whisper-1","object":"model"}]}`))
+		case "Bearer " + testErrorKey:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			placeholderRequests++
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		key           string
+		wantVerified  bool
+		wantExtraData map[string]string
+		wantVerifyErr bool
+	}{
+		{
+			name:          "user key",
+			key:           testUserKey,
+			wantVerified:  true,
+			wantExtraData: map[string]string{"key_type": "user", "organization": "org-x7k2m9", "first_model": "gpt-4o"},
+		},
+		{
+			name:          "project key",
+			key:           testProjectKey,
+			wantVerified:  true,
+			wantExtraData: map[string]string{"key_type": "project", "first_model": "whisper-1"},
+		},
+		{name: "revoked key", key: testRevokedKey},
+		{name: "server error", key: testErrorKey, wantVerifyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scanner{}
+			assert.NoError(t, s.SetEndpoints(server.URL))
+			results, err := s.FromData(context.Background(), true, []byte("openai "+tt.key))
+			assert.NoError(t, err)
+			if !assert.Len(t, results, 1) {
+				return
+			}
+			result := results[0]
+			assert.Equal(t, tt.wantVerified, result.Verified)
+			assert.Equal(t, tt.wantVerifyErr, result.VerificationError != nil)
+			assert.Equal(t, tt.wantExtraData, result.ExtraData)
+		})
+	}
+
+	// Placeholders must not be sent to the API at all.
+	placeholderRequests = 0
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	_, err := s.FromData(context.Background(), true, []byte("openai sk-xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"))
+	assert.NoError(t, err)
+	assert.Zero(t, placeholderRequests)
+}
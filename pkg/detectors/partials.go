@@ -0,0 +1,125 @@
+package detectors
+
+import (
+	"container/list"
+	"sync"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+const (
+	// maxPendingFiles is the number of files whose partial matches are kept
+	// during a scan. Sources send the chunks of a file one after the other,
+	// so the least recently seen file is forgotten to make room for another.
+	maxPendingFiles = 10000
+	// maxPendingPerFile is the number of partial matches kept per detector
+	// and file.
+	maxPendingPerFile = 100
+)
+
+// PendingPartials remembers the partial matches of MultiPartDetectors found in
+// the chunks of the most recent files of a scan, so they can be paired with
+// the ones found in later chunks of the same file. It is safe for concurrent
+// use.
+type PendingPartials struct {
+	mu sync.Mutex
+	// files holds the pending partial matches of each file, and recent its
+	// files from the most to the least recently seen, to forget the latter.
+	files  map[string]*list.Element
+	recent *list.List
+}
+
+type pendingKey struct {
+	detectorType detectorspb.DetectorType
+	version      int
+	file         string
+}
+
+// pendingPartialsFile holds the partial matches of each detector in a file.
+type pendingPartialsFile struct {
+	name    string
+	pending map[pendingKey]*pendingFile
+}
+
+// pendingFile holds the partial matches of a detector in a file, and whether
+// any chunk of the file contained the detector's keywords.
+type pendingFile struct {
+	keyword bool
+	matches []PartialMatch
+}
+
+// NewPendingPartials returns an empty PendingPartials.
+func NewPendingPartials() *PendingPartials {
+	return &PendingPartials{files: make(map[string]*list.Element), recent: list.New()}
+}
+
+// Swap records the current partial matches of detector in a chunk of the
+// file of metadata, and returns the ones that were already pending for it,
+// except those also in current. keyword is whether the chunk contained the
+// detector's keywords. Nothing is returned until a chunk of the file did, so
+// that parts are only paired in files that mention the detector's service.
+//
+// Chunks of a file may be scanned in any order, so each pair of parts from
+// different chunks is returned exactly once, by the later of the two calls.
+// Nothing is recorded for chunks that aren't from a file.
+func (p *PendingPartials) Swap(detector Detector, metadata *source_metadatapb.MetaData, keyword bool, current []PartialMatch) []PartialMatch {
+	key, ok := newPendingKey(detector, metadata)
+	if !ok || (len(current) == 0 && !keyword) {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elem, ok := p.files[key.file]
+	if ok {
+		p.recent.MoveToFront(elem)
+	} else {
+		elem = p.recent.PushFront(&pendingPartialsFile{name: key.file, pending: make(map[pendingKey]*pendingFile)})
+		p.files[key.file] = elem
+		if p.recent.Len() > maxPendingFiles {
+			oldest := p.recent.Back()
+			p.recent.Remove(oldest)
+			delete(p.files, oldest.Value.(*pendingPartialsFile).name)
+		}
+	}
+	pendingFiles := elem.Value.(*pendingPartialsFile).pending
+	file, ok := pendingFiles[key]
+	if !ok {
+		file = &pendingFile{}
+		pendingFiles[key] = file
+	}
+	file.keyword = file.keyword || keyword
+
+	var pending []PartialMatch
+	if file.keyword && len(current) > 0 {
+		for _, match := range file.matches {
+			if !slices.Contains(current, match) {
+				pending = append(pending, match)
+			}
+		}
+	}
+	for _, match := range current {
+		if len(file.matches) >= maxPendingPerFile {
+			break
+		}
+		if !slices.Contains(file.matches, match) {
+			file.matches = append(file.matches, match)
+		}
+	}
+	return pending
+}
+
+func newPendingKey(detector Detector, metadata *source_metadatapb.MetaData) (pendingKey, bool) {
+	file := metadata.GetFilesystem().GetFile()
+	if file == "" {
+		return pendingKey{}, false
+	}
+	key := pendingKey{detectorType: detector.Type(), file: file}
+	if v, ok := detector.(Versioner); ok {
+		key.version = v.Version()
+	}
+	return key, true
+}
@@ -0,0 +1,75 @@
+package detectors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+type partialDetector struct{}
+
+func (partialDetector) FromData(context.Context, bool, []byte) ([]Result, error) { return nil, nil }
+func (partialDetector) Keywords() []string                                       { return nil }
+func (partialDetector) Type() detectorspb.DetectorType                           { return detectorspb.DetectorType_SpotifyKey }
+
+func fileMetadata(file string) *source_metadatapb.MetaData {
+	return &source_metadatapb.MetaData{
+		Data: &source_metadatapb.MetaData_Filesystem{
+			Filesystem: &source_metadatapb.Filesystem{File: file},
+		},
+	}
+}
+
+func TestPendingPartials(t *testing.T) {
+	p := NewPendingPartials()
+	d := partialDetector{}
+	id := PartialMatch{Kind: "id", Value: "x"}
+	secret := PartialMatch{Kind: "secret", Value: "y"}
+
+	// Parts found before any chunk of the file contained the keywords are
+	// kept, but not paired yet.
+	assert.Empty(t, p.Swap(d, fileMetadata("a.txt"), false, []PartialMatch{id}))
+	assert.Empty(t, p.Swap(d, fileMetadata("a.txt"), false, []PartialMatch{secret}))
+	assert.Empty(t, p.Swap(d, fileMetadata("a.txt"), true, nil))
+
+	// The earlier parts are returned with a later one, but not parts that are
+	// also current, such as in the overlap between chunks.
+	other := PartialMatch{Kind: "id", Value: "z"}
+	assert.Equal(t, []PartialMatch{id, secret}, p.Swap(d, fileMetadata("a.txt"), false, []PartialMatch{other}))
+	assert.Equal(t, []PartialMatch{id, other}, p.Swap(d, fileMetadata("a.txt"), false, []PartialMatch{secret}))
+
+	// Files are paired separately.
+	assert.Empty(t, p.Swap(d, fileMetadata("b.txt"), true, []PartialMatch{secret}))
+	assert.Equal(t, []PartialMatch{secret}, p.Swap(d, fileMetadata("b.txt"), false, []PartialMatch{id}))
+
+	// Chunks that aren't from a file are never paired.
+	git := &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Git{Git: &source_metadatapb.Git{File: "a.txt"}}}
+	assert.Empty(t, p.Swap(d, git, true, []PartialMatch{id}))
+	assert.Empty(t, p.Swap(d, git, true, []PartialMatch{secret}))
+}
+
+func TestPendingPartials_Bounded(t *testing.T) {
+	p := NewPendingPartials()
+	d := partialDetector{}
+	id := PartialMatch{Kind: "id", Value: "x"}
+	secret := PartialMatch{Kind: "secret", Value: "y"}
+
+	assert.Empty(t, p.Swap(d, fileMetadata("first.txt"), true, []PartialMatch{id}))
+	for i := 0; i < maxPendingFiles; i++ {
+		assert.Empty(t, p.Swap(d, fileMetadata(fmt.Sprintf("%d.txt", i)), true, []PartialMatch{id}))
+	}
+	assert.Len(t, p.files, maxPendingFiles)
+
+	// The least recently seen file is forgotten, but pairing goes on in the
+	// others and in new files.
+	assert.Empty(t, p.Swap(d, fileMetadata("first.txt"), true, []PartialMatch{secret}))
+	last := fileMetadata(fmt.Sprintf("%d.txt", maxPendingFiles-1))
+	assert.Equal(t, []PartialMatch{id}, p.Swap(d, last, false, []PartialMatch{secret}))
+	assert.Equal(t, []PartialMatch{secret}, p.Swap(d, fileMetadata("first.txt"), false, []PartialMatch{id}))
+	assert.Len(t, p.files, maxPendingFiles)
+}
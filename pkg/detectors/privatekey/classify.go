@@ -0,0 +1,79 @@
+package privatekey
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Key formats reported in the key_type extra data, as identified by the PEM
+// block type.
+const (
+	formatRSA     = "RSA"
+	formatEC      = "EC"
+	formatDSA     = "DSA"
+	formatOpenSSH = "OpenSSH"
+	formatPKCS8   = "PKCS#8"
+)
+
+var beginPat = regexp.MustCompile(`(?i)BEGIN\s*([ A-Z0-9_-]*?)\s*PRIVATE KEY`)
+
+// keyFormat classifies a PEM private key by the type in its BEGIN line: the
+// algorithm specific PKCS#1 (RSA), SEC 1 (EC) and DSA formats, OpenSSH's own
+// format, or PKCS#8, whose block type doesn't name an algorithm. It returns
+// an empty string for other types.
+func keyFormat(pemKey string) string {
+	match := beginPat.FindStringSubmatch(pemKey)
+	if match == nil {
+		return ""
+	}
+	switch strings.ToUpper(match[1]) {
+	case "RSA":
+		return formatRSA
+	case "EC":
+		return formatEC
+	case "DSA":
+		return formatDSA
+	case "OPENSSH":
+		return formatOpenSSH
+	case "", "ENCRYPTED":
+		return formatPKCS8
+	default:
+		return ""
+	}
+}
+
+// keyAlgorithm returns the algorithm and bit length of a parsed private key,
+// or an empty algorithm if it's not supported.
+func keyAlgorithm(parsedKey interface{}) (string, int) {
+	switch key := parsedKey.(type) {
+	case *rsa.PrivateKey:
+		return "rsa", key.N.BitLen()
+	case *ecdsa.PrivateKey:
+		return "ecdsa", key.Curve.Params().BitSize
+	case *ed25519.PrivateKey, ed25519.PrivateKey:
+		return "ed25519", 256
+	case *dsa.PrivateKey:
+		return "dsa", key.P.BitLen()
+	default:
+		return "", 0
+	}
+}
+
+// keyExtraData returns the extra data describing a private key: the format
+// of its PEM block, and the algorithm and bit length of the parsed key.
+func keyExtraData(pemKey string, parsedKey interface{}) map[string]string {
+	extraData := map[string]string{}
+	if format := keyFormat(pemKey); format != "" {
+		extraData["key_type"] = format
+	}
+	if algorithm, bits := keyAlgorithm(parsedKey); algorithm != "" {
+		extraData["algorithm"] = algorithm
+		extraData["bit_length"] = strconv.Itoa(bits)
+	}
+	return extraData
+}
@@ -19,16 +19,26 @@ var (
 )
 
 func FingerprintPEMKey(in []byte) (string, error) {
+	parsedKey, err := parsePEMKey(in)
+	if err != nil {
+		return "", err
+	}
+	return fingerprintKey(parsedKey)
+}
+
+// parsePEMKey parses a PEM private key, cracking its passphrase if it's
+// protected by a common one.
+func parsePEMKey(in []byte) (interface{}, error) {
 	parsedKey, err := ssh.ParseRawPrivateKey(in)
 	if err != nil && strings.Contains(err.Error(), "private key is passphrase protected") {
-		parsedKey, err = crack(in)
-		if err != nil {
-			return "", err
-		}
-	} else if err != nil {
-		return "", err
+		return crack(in)
 	}
+	return parsedKey, err
+}
 
+// fingerprintKey returns the hex SHA-1 fingerprint of the public key of a
+// parsed private key.
+func fingerprintKey(parsedKey interface{}) (string, error) {
 	var pubKey interface{}
 	switch privateKey := parsedKey.(type) {
 	case *rsa.PrivateKey:
@@ -47,15 +47,22 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) ([]dete
 			continue
 		}
 
+		// Keys that don't parse are dropped, which also drops keys that were
+		// truncated by chunking as they're missing their END line.
+		parsedKey, err := parsePEMKey([]byte(token))
+		if err != nil {
+			continue
+		}
+		fingerprint, err := fingerprintKey(parsedKey)
+		if err != nil {
+			continue
+		}
+
 		secret := detectors.Result{
 			DetectorType: detectorspb.DetectorType_PrivateKey,
 			Raw:          []byte(token),
 			Redacted:     token[0:64],
-		}
-
-		fingerprint, err := FingerprintPEMKey([]byte(token))
-		if err != nil {
-			continue
+			ExtraData:    keyExtraData(token, parsedKey),
 		}
 
 		if verify {
@@ -208,6 +208,12 @@ func TestPrivatekey_FromChunk(t *testing.T) {
 					t.Fatal("no raw secret present")
 				}
 				got[i].Raw = nil
+				for _, key := range []string{"key_type", "algorithm", "bit_length"} {
+					if got[i].ExtraData[key] == "" {
+						t.Fatalf("no %s in extra data", key)
+					}
+				}
+				got[i].ExtraData = nil
 			}
 			if diff := pretty.Compare(got, tt.want); diff != "" {
 				t.Errorf("PrivatekeyCI.FromData() %s diff: (-got +want)\n%s", tt.name, diff)
@@ -0,0 +1,24 @@
+package detectors
+
+import (
+	"context"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+// SetVerificationRateLimit sets the global maximum number of verification
+// requests per second. A value <= 0 removes the limit. It's enforced by the
+// shared clients of common, such as common.SaneHttpClient, so detectors
+// verifying with them don't need to do anything.
+func SetVerificationRateLimit(requestsPerSecond float64) {
+	common.SetVerificationRateLimit(requestsPerSecond)
+}
+
+// WaitForVerification blocks until the global rate limit allows another
+// verification request. Only detectors verifying with a client of their own
+// should call it, immediately before each verification network call; the
+// shared clients of common already wait. An error is only returned if the
+// context is done before the request is allowed.
+func WaitForVerification(ctx context.Context) error {
+	return common.WaitForVerification(ctx)
+}
@@ -0,0 +1,51 @@
+package detectors
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForVerification_RateLimited(t *testing.T) {
+	SetVerificationRateLimit(50)
+	defer SetVerificationRateLimit(0)
+
+	const requests = 26
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, WaitForVerification(context.Background()))
+		}()
+	}
+	wg.Wait()
+
+	// With a burst of 1, n requests take at least (n-1)/rate seconds.
+	assert.GreaterOrEqual(t, time.Since(start), 450*time.Millisecond)
+}
+
+func TestWaitForVerification_Unlimited(t *testing.T) {
+	SetVerificationRateLimit(0)
+
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, WaitForVerification(context.Background()))
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestWaitForVerification_ContextCanceled(t *testing.T) {
+	SetVerificationRateLimit(0.1)
+	defer SetVerificationRateLimit(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// The first request consumes the only token.
+	assert.NoError(t, WaitForVerification(ctx))
+	assert.Error(t, WaitForVerification(ctx))
+}
@@ -0,0 +1,169 @@
+package detectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy determines how verification requests that failed transiently,
+// such as by being rate limited, are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Values below 1 mean a single attempt.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles with each
+	// further retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including delays requested
+	// by a Retry-After header.
+	MaxDelay time.Duration
+	// Jitter is the fraction, from 0 to 1, of each delay that is randomized
+	// so that detectors don't retry in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the retry policy used unless SetRetryPolicy was
+// called.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Jitter:      0.5,
+}
+
+var (
+	retryPolicyMu sync.RWMutex
+	retryPolicy   = DefaultRetryPolicy
+)
+
+// SetRetryPolicy sets the retry policy of verification requests made with
+// WithRetry across all detectors.
+func SetRetryPolicy(policy RetryPolicy) {
+	retryPolicyMu.Lock()
+	defer retryPolicyMu.Unlock()
+	retryPolicy = policy
+}
+
+func currentRetryPolicy() RetryPolicy {
+	retryPolicyMu.RLock()
+	defer retryPolicyMu.RUnlock()
+	return retryPolicy
+}
+
+// delay returns how long to wait before the given retry, starting at 1.
+// retryAfter is the delay requested by the provider, if any.
+func (p RetryPolicy) delay(retry int, retryAfter time.Duration) time.Duration {
+	delay := retryAfter
+	if delay <= 0 {
+		delay = p.BaseDelay << (retry - 1)
+		if p.Jitter > 0 {
+			delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+		}
+	}
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay < 0) {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// RetryableError is a verification error worth retrying, such as a rate
+// limited or failed response from the provider.
+type RetryableError struct {
+	Err error
+	// RetryAfter is the delay before retrying requested by the provider. It
+	// is zero if it didn't request one.
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// HTTPStatusError returns an error for a verification response with an
+// unexpected status, which is a RetryableError for 429 and 5xx statuses,
+// honoring the response's Retry-After header. Other statuses, such as 401 and
+// 403, aren't retryable as retrying won't change the outcome.
+func HTTPStatusError(res *http.Response) error {
+	err := fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode < http.StatusInternalServerError {
+		return err
+	}
+	return &RetryableError{Err: err, RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"))}
+}
+
+// parseRetryAfter returns the delay of a Retry-After header value, which is
+// either a number of seconds or an HTTP date. It returns zero if the value is
+// empty or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// isRetryable returns true if err is a RetryableError or a network error.
+func isRetryable(err error) bool {
+	var retryableErr *RetryableError
+	if errors.As(err, &retryableErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// WithRetry calls verify, which makes one verification request, until it
+// returns nil, an error that isn't retryable or the retry policy's attempts
+// are exhausted, and returns its last error. Errors are retryable if they're
+// a RetryableError, see HTTPStatusError, or a network error. If ctx is done
+// before an attempt, its error is returned.
+//
+// Once verifications across all detectors consistently fail to connect, see
+// SetOfflineThreshold, the errors of those that fail to connect wrap
+// ErrVerificationUnavailable and aren't retried.
+func WithRetry(ctx context.Context, verify func() error) error {
+	return recordConnectivity(ctx, withRetry(ctx, verify))
+}
+
+func withRetry(ctx context.Context, verify func() error) error {
+	policy := currentRetryPolicy()
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := verify()
+		if err == nil || ctx.Err() != nil || attempt >= policy.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+		if VerificationUnavailable() && isConnectionError(err) {
+			return err
+		}
+
+		var retryAfter time.Duration
+		var retryableErr *RetryableError
+		if errors.As(err, &retryableErr) {
+			retryAfter = retryableErr.RetryAfter
+		}
+		timer := time.NewTimer(policy.delay(attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package detectors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+	defer SetRetryPolicy(DefaultRetryPolicy)
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "fails twice then succeeds", statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, wantAttempts: 3},
+		{name: "keeps failing", statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}, wantAttempts: 3, wantErr: true},
+		{name: "unauthorized isn't retried", statuses: []int{http.StatusUnauthorized, http.StatusOK}, wantAttempts: 1, wantErr: true},
+		{name: "forbidden isn't retried", statuses: []int{http.StatusForbidden, http.StatusOK}, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[attempts])
+				attempts++
+			}))
+			defer server.Close()
+
+			err := WithRetry(context.Background(), func() error {
+				res, err := http.Get(server.URL)
+				if err != nil {
+					return err
+				}
+				defer res.Body.Close()
+				if res.StatusCode != http.StatusOK {
+					return HTTPStatusError(res)
+				}
+				return nil
+			})
+			assert.Equal(t, tt.wantAttempts, attempts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithRetry_Cancelled(t *testing.T) {
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour})
+	defer SetRetryPolicy(DefaultRetryPolicy)
+
+	// Cancelling the context stops waiting for the next attempt.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	var attempts int
+	err := WithRetry(ctx, func() error {
+		attempts++
+		return &RetryableError{Err: errors.New("rate limited")}
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: 0.5}
+	for retry := 1; retry <= 3; retry++ {
+		full := policy.BaseDelay << (retry - 1)
+		delay := policy.delay(retry, 0)
+		assert.LessOrEqual(t, delay, full)
+		assert.GreaterOrEqual(t, delay, full/2)
+	}
+	assert.Equal(t, time.Second, policy.delay(10, 0))
+	// Retry-After is honored up to MaxDelay.
+	assert.Equal(t, 300*time.Millisecond, policy.delay(1, 300*time.Millisecond))
+	assert.Equal(t, time.Second, policy.delay(1, time.Minute))
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	res := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"7"}}}
+	var retryableErr *RetryableError
+	assert.ErrorAs(t, HTTPStatusError(res), &retryableErr)
+	assert.Equal(t, 7*time.Second, retryableErr.RetryAfter)
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	res = &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {date}}}
+	assert.ErrorAs(t, HTTPStatusError(res), &retryableErr)
+	assert.InDelta(t, time.Minute, retryableErr.RetryAfter, float64(2*time.Second))
+
+	res = &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}}
+	assert.False(t, errors.As(HTTPStatusError(res), &retryableErr))
+}
@@ -0,0 +1,31 @@
+package detectors
+
+// Severity classifies how dangerous a detected credential is. Detectors that
+// don't classify their results leave it as SeverityUnset.
+type Severity int
+
+const (
+	SeverityUnset Severity = iota
+	SeverityInfo
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,21 @@
+package detectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverity_String(t *testing.T) {
+	tests := map[Severity]string{
+		SeverityUnset:    "",
+		SeverityInfo:     "info",
+		SeverityLow:      "low",
+		SeverityMedium:   "medium",
+		SeverityHigh:     "high",
+		SeverityCritical: "critical",
+	}
+	for severity, want := range tests {
+		assert.Equal(t, want, severity.String())
+	}
+}
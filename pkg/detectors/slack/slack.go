@@ -6,28 +6,54 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
-
-	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 )
 
-type Scanner struct{}
+type Scanner struct {
+	detectors.EndpointSetter
+}
 
 // Check that the Slack scanner implements the SecretScanner interface at compile time.
-var _ detectors.Detector = Scanner{}
+var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+
+// DefaultEndpoint is the base URL of the Slack Web API used for verification.
+func (Scanner) DefaultEndpoint() string { return "https://slack.com" }
 
 var (
-	tokenPats = map[string]*regexp.Regexp{
-		"Slack Bot Token":               regexp.MustCompile(`xoxb\-[0-9]{10,13}\-[0-9]{10,13}[a-zA-Z0-9\-]*`),
-		"Slack User Token":              regexp.MustCompile(`xoxp\-[0-9]{10,13}\-[0-9]{10,13}[a-zA-Z0-9\-]*`),
-		"Slack Workspace Access Token":  regexp.MustCompile(`xoxa\-[0-9]{10,13}\-[0-9]{10,13}[a-zA-Z0-9\-]*`),
-		"Slack Workspace Refresh Token": regexp.MustCompile(`xoxr\-[0-9]{10,13}\-[0-9]{10,13}[a-zA-Z0-9\-]*`),
-	}
-	verifyURL = "https://slack.com/api/auth.test"
+	client = common.SaneHttpClient()
+
+	// Rotating tokens are prefixed with xoxe, either on their own for the
+	// refresh tokens or as xoxe.xoxb and xoxe.xoxp for the access tokens.
+	tokenPat = regexp.MustCompile(`(xox[baprs]|xoxe(?:\.xox[bp])?)-[A-Za-z0-9-]{10,}`)
 )
 
+// tokenTypes maps the prefix of a token to its type.
+var tokenTypes = map[string]string{
+	"xoxb":      "bot",
+	"xoxp":      "user",
+	"xoxa":      "app",
+	"xoxr":      "refresh",
+	"xoxs":      "session",
+	"xoxe":      "refresh",
+	"xoxe.xoxb": "bot",
+	"xoxe.xoxp": "user",
+}
+
+// invalidErrors are the errors auth.test returns for a token that isn't
+// valid, as opposed to failures that say nothing about the token.
+var invalidErrors = map[string]struct{}{
+	"invalid_auth":     {},
+	"not_authed":       {},
+	"account_inactive": {},
+	"token_revoked":    {},
+	"token_expired":    {},
+}
+
 type authRes struct {
 	Ok     bool   `json:"ok"`
 	URL    string `json:"url"`  // Workspace URL
@@ -42,45 +68,71 @@ type authRes struct {
 // Keywords are used for efficiently pre-filtering chunks.
 // Use identifiers in the secret preferably, or the provider name.
 func (s Scanner) Keywords() []string {
-	return []string{"xoxb-", "xoxp-", "xoxa-", "xoxr-"}
+	return []string{"xox"}
 }
 
 // FromData will find and optionally verify Slack secrets in a given set of bytes.
 func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
 	dataStr := string(data)
 
-	for _, tokenPat := range tokenPats {
-		tokens := tokenPat.FindAllString(dataStr, -1)
+	for _, match := range tokenPat.FindAllStringSubmatch(dataStr, -1) {
+		token := strings.TrimRight(match[0], "-")
 
-		for _, token := range tokens {
-			s := detectors.Result{
-				DetectorType: detectorspb.DetectorType_Slack,
-				Raw:          []byte(token),
-			}
-			if verify {
-				client := common.SaneHttpClient()
-				req, err := http.NewRequestWithContext(ctx, "POST", verifyURL, nil)
-				if err != nil {
-					continue
-				}
-				req.Header.Add("Content-Type", "application/json; charset=utf-8")
-				req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-				res, err := client.Do(req)
-				if err == nil {
-					defer res.Body.Close()
-					var authResponse authRes
-					if err := json.NewDecoder(res.Body).Decode(&authResponse); err != nil {
-						continue
-					}
-					s.Verified = authResponse.Ok
+		s1 := detectors.Result{
+			DetectorType: detectorspb.DetectorType_Slack,
+			Raw:          []byte(token),
+			ExtraData:    map[string]string{"token_type": tokenTypes[match[1]]},
+		}
+
+		if verify {
+			for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+				res, verificationErr := authTest(ctx, endpoint, token)
+				s1.VerificationError = verificationErr
+				if res.Ok {
+					s1.Verified = true
+					s1.ExtraData["team"] = res.Team
+					s1.ExtraData["user"] = res.User
+					break
 				}
 			}
-
-			results = append(results, s)
+			s1.VerifiedAt = detectors.Now()
 		}
+
+		results = append(results, s1)
 	}
 
-	return
+	return results, nil
+}
+
+// authTest calls auth.test with token. An error is returned unless the
+// response says whether the token is valid.
+func authTest(ctx context.Context, endpoint, token string) (authRes, error) {
+	var res authRes
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/api/auth.test", nil)
+	if err != nil {
+		return res, err
+	}
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	resp, err := client.Do(req)
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return res, fmt.Errorf("unexpected HTTP response status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return res, err
+	}
+	if res.Ok {
+		return res, nil
+	}
+	if _, ok := invalidErrors[res.Error]; ok {
+		return res, nil
+	}
+	return res, fmt.Errorf("unexpected error from auth.test: %s", res.Error)
 }
 
 func (s Scanner) Type() detectorspb.DetectorType {
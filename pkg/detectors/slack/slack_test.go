@@ -69,6 +69,17 @@ func TestScanner_FromChunk(t *testing.T) {
 					t.Fatal("no raw secret present")
 				}
 				got[i].Raw = nil
+				if got[i].ExtraData["token_type"] == "" {
+					t.Fatal("no token type present")
+				}
+				if got[i].Verified && (got[i].ExtraData["team"] == "" || got[i].ExtraData["user"] == "") {
+					t.Fatal("no team or user present")
+				}
+				got[i].ExtraData = nil
+				if got[i].VerifiedAt.IsZero() {
+					t.Fatal("no verification time present")
+				}
+				got[i].VerifiedAt = time.Time{}
 			}
 			if diff := pretty.Compare(got, tt.wantResults); diff != "" {
 				t.Errorf("Scanner.FromData) %s diff: (-got +want)\n%s", tt.name, diff)
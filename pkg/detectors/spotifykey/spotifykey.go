@@ -2,12 +2,17 @@ package spotifykey
 
 import (
 	"context"
+	"errors"
+	"net/http"
+
 	"golang.org/x/oauth2"
 
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	logContext "github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
 
 	"golang.org/x/oauth2/clientcredentials"
@@ -15,15 +20,39 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 )
 
-type Scanner struct{}
+type Scanner struct{ detectors.EndpointSetter }
 
-// Ensure the Scanner satisfies the interface at compile time.
+// Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+var _ detectors.MultiPartDetector = (*Scanner)(nil)
+var _ detectors.CompositeDetector = (*Scanner)(nil)
+var _ detectors.Closer = (*Scanner)(nil)
+
+// DefaultEndpoint is the accounts service base URL used for verification
+// unless overridden, for example to route requests through an internal
+// gateway. The token path is appended to whichever endpoint is used.
+func (Scanner) DefaultEndpoint() string { return "https://accounts.spotify.com" }
 
 var (
+	// client is shared by verifications so that connections to the token
+	// endpoint are reused. See Close.
+	client = common.SaneHttpClient()
+
 	// Make sure that your group is surrounded in boundary characters such as below to reduce false positives.
 	secretPat = regexp.MustCompile(detectors.PrefixRegex([]string{"key", "secret"}) + `\b([A-Za-z0-9]{32})\b`)
 	idPat     = regexp.MustCompile(detectors.PrefixRegex([]string{"id"}) + `\b([A-Za-z0-9]{32})\b`)
+
+	keywordPat = regexp.MustCompile(`(?i)spotify`)
+)
+
+const (
+	// maxPairDistance is the distance in bytes between a secret and its ID
+	// beyond which co-location no longer contributes to confidence.
+	maxPairDistance = 512
+	// maxKeywordDistance is the distance in bytes between a secret and the
+	// spotify keyword within which the keyword is considered adjacent.
+	maxKeywordDistance = 128
 )
 
 // Keywords are used for efficiently pre-filtering chunks.
@@ -32,52 +61,253 @@ func (s Scanner) Keywords() []string {
 	return []string{"spotify"}
 }
 
+// Names of the parts of the credentials of the scanner.
+const (
+	partialID     = "id"
+	partialSecret = "secret"
+)
+
+// CompositeParts returns the client secret and ID, which are only verified
+// together.
+func (s Scanner) CompositeParts() []detectors.CompositePart {
+	return []detectors.CompositePart{
+		{Name: partialSecret, Pattern: secretPat},
+		{Name: partialID, Pattern: idPat},
+	}
+}
+
 // FromData will find and optionally verify SpotifyKey secrets in a given set of bytes.
 func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, common.SaneHttpClient())
+	logger := logContext.AddLogger(ctx).Logger()
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
 
 	dataStr := string(data)
+	keywordIndexes := keywordPat.FindAllStringIndex(dataStr, -1)
 
-	matches := secretPat.FindAllStringSubmatch(dataStr, -1)
-	idMatches := idPat.FindAllStringSubmatch(dataStr, -1)
+	composites := detectors.AssembleComposites(dataStr, s.CompositeParts())
+	detectors.RecordMatches(s.Type(), len(composites))
+	if detectors.MaxResultsReached(len(composites)) {
+		logger.V(2).Info("maximum results per chunk reached, skipping remaining candidates", "detector", s.Type().String(), "results", len(composites))
+	}
+	for _, composite := range composites {
+		id, secret := composite.Values[partialID], composite.Values[partialSecret]
+		secretIdx, idIdx := composite.Offsets[partialSecret], composite.Offsets[partialID]
+		s1 := newResult(id, secret)
+		s1.Confidence = confidence(secret, secretIdx, idIdx, keywordIndexes)
+		s1.AddTrace(detectors.TraceStepPattern, "secret matched at offset %d, paired with id matched at offset %d", secretIdx, idIdx)
+		traceKeyword(&s1, secretIdx, keywordIndexes)
 
-	for _, match := range matches {
-		if len(match) != 2 {
+		if verify {
+			if err := s.verify(ctx, &s1, id, secret); err != nil {
+				return results, err
+			}
+		}
+		if !detectors.ShouldEmit(ctx, s1) {
 			continue
 		}
-		resMatch := strings.TrimSpace(match[1])
-		for _, idMatch := range idMatches {
-			if len(idMatch) != 2 {
-				continue
-			}
-			idresMatch := strings.TrimSpace(idMatch[1])
-			s1 := detectors.Result{
-				DetectorType: detectorspb.DetectorType_SpotifyKey,
-				Raw:          []byte(resMatch),
-			}
 
-			if verify {
-				config := &clientcredentials.Config{
-					ClientID:     idresMatch,
-					ClientSecret: resMatch,
-					TokenURL:     "https://accounts.spotify.com/api/token",
-				}
-				token, err := config.Token(ctx)
-				if err == nil {
-					if token.Type() == "Bearer" {
-						s1.Verified = true
-					}
-				}
-			}
+		results = append(results, s1)
+	}
 
-			results = append(results, s1)
-		}
+	return results, nil
+}
+
+// PartialMatches returns the client IDs and secrets found in data, so that
+// they can be paired with ones found in other chunks of the same file.
+func (s Scanner) PartialMatches(data []byte) []detectors.PartialMatch {
+	return detectors.CompositePartialMatches(data, s.CompositeParts())
+}
 
+// FromPartialMatches pairs the client IDs and secrets found in a chunk with
+// the ones pending from other chunks, and optionally verifies the pairs.
+func (s Scanner) FromPartialMatches(ctx context.Context, verify bool, current, pending []detectors.PartialMatch) (results []detectors.Result, err error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+
+	composites := detectors.AssemblePartialComposites(current, pending, s.CompositeParts())
+	detectors.RecordMatches(s.Type(), len(composites))
+	for _, composite := range composites {
+		id, secret := composite.Values[partialID], composite.Values[partialSecret]
+		s1 := newResult(id, secret)
+		// The parts are in different chunks, so they're not close enough
+		// for co-location to contribute to confidence.
+		s1.Confidence = confidence(secret, 0, maxPairDistance, nil)
+		s1.AddTrace(detectors.TraceStepPattern, "id and secret matched in different chunks of the same file")
+		s1.AddTrace(detectors.TraceStepKeyword, "spotify keyword found in a chunk of the file")
+		if verify {
+			if err := s.verify(ctx, &s1, id, secret); err != nil {
+				return results, err
+			}
+		}
+		if !detectors.ShouldEmit(ctx, s1) {
+			continue
+		}
+		results = append(results, s1)
 	}
 
 	return results, nil
 }
 
+// newResult returns the result of the client credentials id and secret. The
+// secret is its Raw value, as for results found before the ID was recorded,
+// and the ID, which isn't secret, is shown in its place when redacted so the
+// credentials can be identified for remediation.
+func newResult(id, secret string) detectors.Result {
+	return detectors.Result{
+		DetectorType: detectorspb.DetectorType_SpotifyKey,
+		Raw:          []byte(secret),
+		RawV2:        []byte(id + secret),
+		Redacted:     id,
+		ExtraData:    map[string]string{"client_id": id},
+	}
+}
+
+// verify verifies the client credentials id and secret of s1 against the
+// token endpoint. An error is only returned if ctx is done.
+func (s Scanner) verify(ctx context.Context, s1 *detectors.Result, id, secret string) error {
+	for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+		config := &clientcredentials.Config{
+			ClientID:     id,
+			ClientSecret: secret,
+			TokenURL:     strings.TrimRight(endpoint, "/") + "/api/token",
+		}
+		start := time.Now()
+		var token *oauth2.Token
+		err := detectors.WithRetry(ctx, func() error {
+			var err error
+			token, err = config.Token(ctx)
+			return retryableTokenError(err)
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return err
+		}
+		s1.VerifiedAt = detectors.Now()
+		switch {
+		case err == nil:
+			if token.Type() == "Bearer" {
+				s1.Verified = true
+				// Client credentials only grant access to public catalog data.
+				s1.Severity = detectors.SeverityMedium
+				s1.Confidence = detectors.MaxConfidence
+				s1.AddTrace(detectors.TraceStepVerification, "verified by %s", endpoint)
+			} else {
+				s1.AddTrace(detectors.TraceStepVerification, "%s returned a %q token, not verified", endpoint, token.Type())
+			}
+		case isInvalidCredentials(err):
+			// The credentials were rejected, so the secret is not valid.
+			s1.AddTrace(detectors.TraceStepVerification, "credentials rejected by %s", endpoint)
+		default:
+			s1.VerificationError = err
+			s1.AddTrace(detectors.TraceStepVerification, "verification against %s failed: %v", endpoint, err)
+		}
+		detectors.RecordVerification(s.Type(), s1.Verified, time.Since(start))
+		if s1.Verified {
+			s1.VerificationError = nil
+			break
+		}
+	}
+	return nil
+}
+
+// Close closes the idle connections of the client shared by verifications.
+func (s Scanner) Close() error {
+	client.CloseIdleConnections()
+	return nil
+}
+
 func (s Scanner) Type() detectorspb.DetectorType {
 	return detectorspb.DetectorType_SpotifyKey
 }
+
+// confidence scores an unverified candidate from 0 to 99 based on the
+// randomness of the secret, how close it is to its ID, and whether the
+// spotify keyword appears near it.
+func confidence(secret string, secretIdx, idIdx int, keywordIndexes [][]int) int {
+	// A random 32 character alphanumeric string has entropy close to 5 bits.
+	entropyScore := 40 * detectors.ShannonEntropy(secret) / 5
+	if entropyScore > 40 {
+		entropyScore = 40
+	}
+
+	var distanceScore float64
+	if distance := abs(secretIdx - idIdx); distance < maxPairDistance {
+		distanceScore = 30 * (1 - float64(distance)/maxPairDistance)
+	}
+
+	var keywordScore float64
+	for _, keyword := range keywordIndexes {
+		if abs(secretIdx-keyword[0]) <= maxKeywordDistance {
+			keywordScore = 29
+			break
+		}
+		// The keyword is present in the data, but not adjacent to this secret.
+		keywordScore = 10
+	}
+
+	return int(entropyScore + distanceScore + keywordScore)
+}
+
+// traceKeyword records in the trace of s1 whether the spotify keyword is
+// adjacent to the secret at secretIdx, as scored by confidence.
+func traceKeyword(s1 *detectors.Result, secretIdx int, keywordIndexes [][]int) {
+	if !detectors.Tracing() {
+		return
+	}
+	if len(keywordIndexes) == 0 {
+		s1.AddTrace(detectors.TraceStepKeyword, "no spotify keyword found")
+		return
+	}
+	nearest := abs(secretIdx - keywordIndexes[0][0])
+	for _, keyword := range keywordIndexes[1:] {
+		if distance := abs(secretIdx - keyword[0]); distance < nearest {
+			nearest = distance
+		}
+	}
+	if nearest <= maxKeywordDistance {
+		s1.AddTrace(detectors.TraceStepKeyword, "spotify keyword found %d bytes from the secret", nearest)
+	} else {
+		s1.AddTrace(detectors.TraceStepKeyword, "spotify keyword found, but not within %d bytes of the secret", maxKeywordDistance)
+	}
+}
+
+// retryableTokenError returns err as a detectors.RetryableError if the token
+// request failed, or the token endpoint rate limited or failed to handle it.
+// oauth2 doesn't wrap errors of the request itself, so any error that isn't a
+// response from the endpoint is considered to be one.
+func retryableTokenError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) || retrieveErr.Response == nil {
+		return &detectors.RetryableError{Err: err}
+	}
+	var retryableErr *detectors.RetryableError
+	if errors.As(detectors.HTTPStatusError(retrieveErr.Response), &retryableErr) {
+		retryableErr.Err = err
+		return retryableErr
+	}
+	return err
+}
+
+// isInvalidCredentials reports whether a token request failed because the
+// token endpoint rejected the credentials, as opposed to the request itself
+// failing.
+func isInvalidCredentials(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) || retrieveErr.Response == nil {
+		return false
+	}
+	switch retrieveErr.Response.StatusCode {
+	case http.StatusBadRequest, http.StatusUnauthorized:
+		return true
+	default:
+		return false
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
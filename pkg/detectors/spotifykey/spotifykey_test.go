@@ -51,6 +51,11 @@ func TestSpotifyKey_FromChunk(t *testing.T) {
 				{
 					DetectorType: detectorspb.DetectorType_SpotifyKey,
 					Verified:     true,
+					Severity:     detectors.SeverityMedium,
+					Confidence:   detectors.MaxConfidence,
+					RawV2:        []byte(clientID + clientSecret),
+					Redacted:     clientID,
+					ExtraData:    map[string]string{"client_id": clientID},
 				},
 			},
 			wantErr: false,
@@ -67,6 +72,9 @@ func TestSpotifyKey_FromChunk(t *testing.T) {
 				{
 					DetectorType: detectorspb.DetectorType_SpotifyKey,
 					Verified:     false,
+					RawV2:        []byte(clientID + inactiveClientSecret),
+					Redacted:     clientID,
+					ExtraData:    map[string]string{"client_id": clientID},
 				},
 			},
 			wantErr: false,
@@ -96,6 +104,11 @@ func TestSpotifyKey_FromChunk(t *testing.T) {
 					t.Fatalf("no raw secret present: \n %+v", got[i])
 				}
 				got[i].Raw = nil
+				got[i].VerifiedAt = time.Time{}
+				// Unverified confidence depends on the test data layout.
+				if !got[i].Verified {
+					got[i].Confidence = 0
+				}
 			}
 			if diff := pretty.Compare(got, tt.want); diff != "" {
 				t.Errorf("SpotifyKey.FromData() %s diff: (-got +want)\n%s", tt.name, diff)
@@ -0,0 +1,431 @@
+package spotifykey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+const (
+	testClientID     = "8d3f8e2a9b7c4d1e6f5a0b9c8d7e6f5a"
+	testClientSecret = "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d"
+)
+
+var testData = []byte(fmt.Sprintf("spotify client_id: %s\nspotify secret: %s\n", testClientID, testClientSecret))
+
+func TestSpotifyKey_Metrics(t *testing.T) {
+	before := detectors.GetDetectorMetrics(detectorspb.DetectorType_SpotifyKey)
+
+	results, err := Scanner{}.FromData(context.Background(), false, testData)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, results)
+
+	after := detectors.GetDetectorMetrics(detectorspb.DetectorType_SpotifyKey)
+	assert.Equal(t, uint64(len(results)), after.Matches-before.Matches)
+	assert.Equal(t, before.VerificationAttempts, after.VerificationAttempts)
+
+	// Candidates are counted even if they aren't emitted.
+	ctx := detectors.WithEmitPolicy(context.Background(), detectors.EmitVerifiedOnly)
+	results, err = Scanner{}.FromData(ctx, false, testData)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, after.Matches+1, detectors.GetDetectorMetrics(detectorspb.DetectorType_SpotifyKey).Matches)
+}
+
+func TestSpotifyKey_Confidence(t *testing.T) {
+	padding := strings.Repeat("x ", 400)
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "co-located, keyword adjacent, high entropy",
+			data: fmt.Sprintf("spotify client_id: %s\nspotify secret: %s\n", testClientID, testClientSecret),
+		},
+		{
+			name: "keyword far from secret",
+			data: fmt.Sprintf("spotify %s client_id: %s\nsecret: %s\n", padding, testClientID, testClientSecret),
+		},
+		{
+			name: "id far from secret",
+			data: fmt.Sprintf("spotify client_id: %s\n%s\nspotify secret: %s\n", testClientID, padding, testClientSecret),
+		},
+		{
+			name: "low entropy secret",
+			data: fmt.Sprintf("spotify client_id: %s\nspotify secret: %s\n", testClientID, "aaaaaaaaaaaaaaaabbbbbbbbbbbbbbb1"),
+		},
+	}
+
+	var scores []int
+	for _, tt := range tests {
+		results, err := Scanner{}.FromData(context.Background(), false, []byte(tt.data))
+		assert.NoError(t, err)
+		if !assert.Len(t, results, 1, tt.name) {
+			return
+		}
+		confidence := results[0].Confidence
+		assert.Greater(t, confidence, 0, tt.name)
+		assert.Less(t, confidence, detectors.MaxConfidence, tt.name)
+		scores = append(scores, confidence)
+	}
+	for i, score := range scores[1:] {
+		assert.Greater(t, scores[0], score, tests[i+1].name)
+	}
+}
+
+func TestSpotifyKey_CustomEndpoint(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != testClientID || secret != testClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	s := &Scanner{}
+	endpoints := detectors.SelfHostedEndpoints{}
+	endpoints.Add(detectorspb.DetectorType_SpotifyKey, server.URL+"/")
+	assert.NoError(t, endpoints.Apply(s))
+	results, err := s.FromData(context.Background(), true, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Verified)
+		assert.Equal(t, detectors.SeverityMedium, results[0].Severity)
+		assert.Equal(t, detectors.MaxConfidence, results[0].Confidence)
+	}
+}
+
+func TestSpotifyKey_CompositeVerifiedOnce(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	// The same ID and secret repeated make up a single credential.
+	data := append(append([]byte{}, testData...), testData...)
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	results, err := s.FromData(context.Background(), true, data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, testClientSecret, string(results[0].Raw))
+		assert.True(t, results[0].Verified)
+	}
+}
+
+func TestSpotifyKey_VerificationError(t *testing.T) {
+	detectors.SetRetryPolicy(detectors.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	defer detectors.SetRetryPolicy(detectors.DefaultRetryPolicy)
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantError bool
+	}{
+		{name: "server error", status: http.StatusInternalServerError, body: "internal error", wantError: true},
+		{name: "invalid credentials", status: http.StatusBadRequest, body: `{"error":"invalid_client"}`, wantError: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			s := Scanner{}
+			assert.NoError(t, s.SetEndpoints(server.URL))
+			results, err := s.FromData(context.Background(), true, testData)
+			assert.NoError(t, err)
+			if !assert.Len(t, results, 1) {
+				return
+			}
+			assert.False(t, results[0].Verified)
+			if tt.wantError {
+				assert.Error(t, results[0].VerificationError)
+			} else {
+				assert.NoError(t, results[0].VerificationError)
+			}
+		})
+	}
+}
+
+func TestSpotifyKey_VerificationUnavailable(t *testing.T) {
+	detectors.SetRetryPolicy(detectors.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+	defer detectors.SetRetryPolicy(detectors.DefaultRetryPolicy)
+	detectors.SetOfflineThreshold(2)
+	defer detectors.SetOfflineThreshold(detectors.DefaultOfflineThreshold)
+
+	// The token endpoint can't be connected to, as without network access.
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+
+	for i, wantUnavailable := range []bool{false, true, true} {
+		results, err := s.FromData(context.Background(), true, testData)
+		assert.NoError(t, err)
+		if !assert.Len(t, results, 1) {
+			return
+		}
+		assert.False(t, results[0].Verified)
+		assert.Error(t, results[0].VerificationError)
+		assert.Equal(t, wantUnavailable, errors.Is(results[0].VerificationError, detectors.ErrVerificationUnavailable), "scan %d", i)
+	}
+}
+
+func TestSpotifyKey_MaxResults(t *testing.T) {
+	const maxResults = 50
+	detectors.SetMaxResultsPerChunk(maxResults)
+	defer detectors.SetMaxResultsPerChunk(detectors.DefaultMaxResultsPerChunk)
+
+	// 100 IDs and 100 secrets produce 10,000 candidate pairs.
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&sb, "spotify id: %032x\nspotify secret: %032x\n", 1000+i, 5000+i)
+	}
+	results, err := Scanner{}.FromData(context.Background(), false, []byte(sb.String()))
+	assert.NoError(t, err)
+	assert.Len(t, results, maxResults)
+}
+
+func TestSpotifyKey_VerifiedAt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	scanTime := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	detectors.SetClock(func() time.Time { return scanTime })
+	defer detectors.SetClock(nil)
+
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	for _, verify := range []bool{false, true} {
+		results, err := s.FromData(context.Background(), verify, testData)
+		assert.NoError(t, err)
+		if !assert.Len(t, results, 1) {
+			return
+		}
+		if verify {
+			assert.Equal(t, scanTime, results[0].VerifiedAt)
+		} else {
+			assert.True(t, results[0].VerifiedAt.IsZero())
+		}
+	}
+}
+
+func TestSpotifyKey_FromPartialMatches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != testClientID || secret != testClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	pending := s.PartialMatches([]byte("spotify client_id: " + testClientID + "\n"))
+	current := s.PartialMatches([]byte("secret: " + testClientSecret + "\n"))
+	assert.Equal(t, []detectors.PartialMatch{{Kind: partialID, Value: testClientID}}, pending)
+	assert.Equal(t, []detectors.PartialMatch{{Kind: partialSecret, Value: testClientSecret}}, current)
+
+	results, err := s.FromPartialMatches(context.Background(), true, current, pending)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, testClientSecret, string(results[0].Raw))
+		assert.Equal(t, testClientID, results[0].ExtraData["client_id"])
+		assert.True(t, results[0].Verified)
+	}
+
+	// Parts of the same kind aren't paired.
+	results, err = s.FromPartialMatches(context.Background(), false, current, current)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSpotifyKey_ClientID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	results, err := s.FromData(context.Background(), true, testData)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Verified)
+		assert.Equal(t, testClientSecret, string(results[0].Raw))
+		assert.Equal(t, testClientID+testClientSecret, string(results[0].RawV2))
+		assert.Equal(t, testClientID, results[0].ExtraData["client_id"])
+		// The ID is shown in place of the secret when redacted.
+		assert.Equal(t, testClientID, results[0].Redacted)
+		assert.NotContains(t, results[0].Redacted, testClientSecret)
+	}
+}
+
+func TestSpotifyKey_Close(t *testing.T) {
+	var closed atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	results, err := s.FromData(context.Background(), true, testData)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Verified)
+	}
+	// The connection is kept open to be reused until the scanner is closed.
+	assert.Zero(t, closed.Load())
+
+	assert.NoError(t, s.Close())
+	assert.Eventually(t, func() bool { return closed.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSpotifyKey_Trace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	s := &Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+
+	// Without tracing, no steps are recorded.
+	results, err := s.FromData(context.Background(), true, testData)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Nil(t, results[0].Trace)
+	}
+
+	detectors.SetTracing(true)
+	defer detectors.SetTracing(false)
+	results, err = s.FromData(context.Background(), true, testData)
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 1) {
+		return
+	}
+	var steps []string
+	for _, step := range results[0].Trace {
+		steps = append(steps, step.Step)
+	}
+	assert.Equal(t, []string{detectors.TraceStepPattern, detectors.TraceStepKeyword, detectors.TraceStepVerification}, steps)
+	assert.Contains(t, results[0].Trace[1].Detail, "bytes from the secret")
+	assert.Equal(t, "verified by "+server.URL, results[0].Trace[2].Detail)
+}
+
+func TestSpotifyKey_VerificationRetry(t *testing.T) {
+	detectors.SetRetryPolicy(detectors.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	defer detectors.SetRetryPolicy(detectors.DefaultRetryPolicy)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if requests <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"temporarily_unavailable"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	s := Scanner{}
+	assert.NoError(t, s.SetEndpoints(server.URL))
+	results, err := s.FromData(context.Background(), true, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, requests)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Verified)
+		assert.NoError(t, results[0].VerificationError)
+	}
+}
+
+func TestSpotifyKey_EmitVerifiedOnly(t *testing.T) {
+	detectors.SetRetryPolicy(detectors.RetryPolicy{MaxAttempts: 1})
+	defer detectors.SetRetryPolicy(detectors.DefaultRetryPolicy)
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantEmit bool
+	}{
+		{name: "verified", status: http.StatusOK, body: `{"access_token":"token","token_type":"Bearer","expires_in":3600}`, wantEmit: true},
+		{name: "invalid credentials", status: http.StatusBadRequest, body: `{"error":"invalid_client"}`},
+		{name: "server error", status: http.StatusInternalServerError, body: "internal error", wantEmit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			s := Scanner{}
+			assert.NoError(t, s.SetEndpoints(server.URL))
+			ctx := detectors.WithEmitPolicy(context.Background(), detectors.EmitVerifiedOnly)
+			results, err := s.FromData(ctx, true, testData)
+			assert.NoError(t, err)
+			if tt.wantEmit {
+				assert.Len(t, results, 1)
+			} else {
+				assert.Empty(t, results)
+			}
+
+			// Everything is emitted by default.
+			results, err = s.FromData(context.Background(), true, testData)
+			assert.NoError(t, err)
+			assert.Len(t, results, 1)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package detectors
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Kinds of trace steps recorded by detectors.
+const (
+	// TraceStepPattern records which patterns matched to produce a result.
+	TraceStepPattern = "pattern"
+	// TraceStepKeyword records whether a keyword was found near the secret.
+	TraceStepKeyword = "keyword"
+	// TraceStepVerification records the outcome of a verification attempt.
+	TraceStepVerification = "verification"
+)
+
+// TraceStep is one decision a detector made while producing a result.
+type TraceStep struct {
+	// Step is the kind of decision, such as TraceStepPattern.
+	Step string
+	// Detail describes the decision in a human readable way.
+	Detail string
+}
+
+var tracing atomic.Bool
+
+// SetTracing enables recording the decisions detectors make for each result
+// in Result.Trace, to help tune detectors against false positives. It is
+// disabled by default, since formatting the steps has a cost on every match.
+func SetTracing(enabled bool) {
+	tracing.Store(enabled)
+}
+
+// Tracing returns true if detectors should record trace steps.
+func Tracing() bool {
+	return tracing.Load()
+}
+
+// AddTrace appends a step to the trace of the result if tracing is enabled.
+// The detail is only formatted when it's recorded.
+func (r *Result) AddTrace(step, format string, args ...any) {
+	if !Tracing() {
+		return
+	}
+	r.Trace = append(r.Trace, TraceStep{Step: step, Detail: fmt.Sprintf(format, args...)})
+}
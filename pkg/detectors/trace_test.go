@@ -0,0 +1,22 @@
+package detectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult_AddTrace(t *testing.T) {
+	var r Result
+	r.AddTrace(TraceStepPattern, "matched at offset %d", 4)
+	assert.Nil(t, r.Trace)
+
+	SetTracing(true)
+	defer SetTracing(false)
+	r.AddTrace(TraceStepPattern, "matched at offset %d", 4)
+	r.AddTrace(TraceStepVerification, "rejected")
+	assert.Equal(t, []TraceStep{
+		{Step: TraceStepPattern, Detail: "matched at offset 4"},
+		{Step: TraceStepVerification, Detail: "rejected"},
+	}, r.Trace)
+}
@@ -2,9 +2,12 @@ package twilio
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
@@ -12,16 +15,39 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 )
 
-type Scanner struct{}
+type Scanner struct {
+	detectors.EndpointSetter
+}
 
-// Ensure the Scanner satisfies the interface at compile time.
+// Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+var _ detectors.KeywordPatterner = (*Scanner)(nil)
+
+// DefaultEndpoint is the base URL of the Twilio REST API used to verify API
+// keys.
+func (Scanner) DefaultEndpoint() string { return "https://api.twilio.com" }
 
 var (
 	identifierPat = regexp.MustCompile(`(?i)sid.{0,20}AC[0-9a-f]{32}`) // Should we have this? Seems restrictive.
 	sidPat        = regexp.MustCompile(`\bAC[0-9a-f]{32}\b`)
 	keyPat        = regexp.MustCompile(`\b[0-9a-f]{32}\b`)
 	client        = common.SaneHttpClient()
+
+	// apiKeyPat matches API key SIDs, which are paired with a secret.
+	apiKeyPat       = regexp.MustCompile(`\b(SK[0-9a-f]{32})\b`)
+	apiKeySecretPat = regexp.MustCompile(`\b([0-9A-Za-z]{32})\b`)
+)
+
+// pairWindow is the maximum distance in bytes between the start of an API
+// key SID and the start of a secret for the two to be tried as a pair.
+const pairWindow = 1024
+
+// Key types recorded in the key_type ExtraData of results, to tell API keys
+// apart from account auth tokens.
+const (
+	keyTypeAuthToken = "auth_token"
+	keyTypeAPIKey    = "api_key"
 )
 
 // Keywords are used for efficiently pre-filtering chunks.
@@ -30,14 +56,29 @@ func (s Scanner) Keywords() []string {
 	return []string{"sid"}
 }
 
+// KeywordPatterns pre-filter chunks containing an API key SID, which aren't
+// necessarily labelled as one.
+func (s Scanner) KeywordPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{apiKeyPat}
+}
+
 // FromData will find and optionally verify Twilio secrets in a given set of bytes.
 func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
 	dataStr := string(data)
 
+	// API keys and auth tokens are cleaned separately, so that an
+	// unverified one of either kind isn't hidden by a verified one of the
+	// other.
+	apiKeyResults, err := s.apiKeysFromData(ctx, verify, dataStr)
+	if err != nil {
+		return apiKeyResults, err
+	}
+	apiKeyResults = detectors.CleanResults(apiKeyResults)
+
 	identifierMatches := identifierPat.FindAllString(dataStr, -1)
 
 	if len(identifierMatches) == 0 {
-		return
+		return apiKeyResults, nil
 	}
 
 	keyMatches := keyPat.FindAllString(dataStr, -1)
@@ -50,6 +91,7 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 				Raw:          []byte(sid),
 				RawV2:        []byte(sid + key),
 				Redacted:     sid,
+				ExtraData:    map[string]string{"key_type": keyTypeAuthToken},
 			}
 
 			if verify {
@@ -87,7 +129,136 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 		}
 	}
 
-	return detectors.CleanResults(results), nil
+	return append(apiKeyResults, detectors.CleanResults(results)...), nil
+}
+
+// apiKeysFromData finds API key SIDs paired with a secret near them, and
+// optionally verifies them. An error is only returned if ctx is done.
+func (s Scanner) apiKeysFromData(ctx context.Context, verify bool, dataStr string) (results []detectors.Result, err error) {
+	keyMatches := apiKeyPat.FindAllStringSubmatchIndex(dataStr, -1)
+	if len(keyMatches) == 0 {
+		return nil, nil
+	}
+	secretMatches := apiKeySecretPat.FindAllStringSubmatchIndex(dataStr, -1)
+
+	for _, keyMatch := range keyMatches {
+		keySID := dataStr[keyMatch[2]:keyMatch[3]]
+		for _, secretMatch := range colocated(secretMatches, keyMatch[2]) {
+			secret := dataStr[secretMatch[2]:secretMatch[3]]
+
+			s1 := detectors.Result{
+				DetectorType: detectorspb.DetectorType_Twilio,
+				Raw:          []byte(keySID),
+				RawV2:        []byte(keySID + secret),
+				Redacted:     keySID,
+				ExtraData:    map[string]string{"key_type": keyTypeAPIKey},
+			}
+
+			if verify {
+				if err := s.verifyAPIKey(ctx, &s1, keySID, secret); err != nil {
+					return results, err
+				}
+			}
+
+			if !s1.Verified && detectors.IsKnownFalsePositive(secret, detectors.DefaultFalsePositives, true) {
+				continue
+			}
+
+			results = append(results, s1)
+			// A verified secret is the one paired with this SID.
+			if s1.Verified {
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+// colocated returns the secret matches that start within pairWindow bytes of
+// offset. The matches must be in the order returned by the regexp package, so
+// the window can be found with a binary search instead of pairing every SID
+// with every secret in the chunk.
+func colocated(secretMatches [][]int, offset int) [][]int {
+	lo := sort.Search(len(secretMatches), func(i int) bool {
+		return secretMatches[i][2] >= offset-pairWindow
+	})
+	hi := sort.Search(len(secretMatches), func(i int) bool {
+		return secretMatches[i][2] > offset+pairWindow
+	})
+	return secretMatches[lo:hi]
+}
+
+// accountsRes is the response of the accounts endpoint.
+type accountsRes struct {
+	Accounts []struct {
+		SID          string `json:"sid"`
+		FriendlyName string `json:"friendly_name"`
+		Status       string `json:"status"`
+	} `json:"accounts"`
+}
+
+// verifyAPIKey verifies an API key by listing the accounts it can access,
+// recording the friendly name and status of its account in s1. Standard API
+// keys authenticate but aren't allowed to access accounts, so they are
+// verified without them. An error is only returned if ctx is done.
+func (s Scanner) verifyAPIKey(ctx context.Context, s1 *detectors.Result, keySID, secret string) error {
+	for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+		var verified bool
+		var res *accountsRes
+		err := detectors.WithRetry(ctx, func() error {
+			var err error
+			verified, res, err = listAccounts(ctx, endpoint, keySID, secret)
+			return err
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return err
+		}
+		s1.VerifiedAt = detectors.Now()
+		s1.VerificationError = err
+		if !verified {
+			continue
+		}
+		s1.Verified = true
+		if res != nil && len(res.Accounts) > 0 {
+			account := res.Accounts[0]
+			s1.ExtraData["account_sid"] = account.SID
+			s1.ExtraData["friendly_name"] = account.FriendlyName
+			s1.ExtraData["status"] = account.Status
+		}
+		break
+	}
+	return nil
+}
+
+// listAccounts lists the accounts the API key can access. verified is true if
+// the key authenticated, and res is nil if it isn't allowed to list accounts.
+// An error is returned unless the response says whether the key is valid.
+func listAccounts(ctx context.Context, endpoint, keySID, secret string) (verified bool, res *accountsRes, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(endpoint, "/")+"/2010-04-01/Accounts.json", nil)
+	if err != nil {
+		return false, nil, err
+	}
+	req.SetBasicAuth(keySID, secret)
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var accounts accountsRes
+		if err := json.NewDecoder(resp.Body).Decode(&accounts); err != nil {
+			return false, nil, err
+		}
+		return true, &accounts, nil
+	case http.StatusForbidden:
+		return true, nil, nil
+	case http.StatusUnauthorized:
+		return false, nil, nil
+	default:
+		return false, nil, detectors.HTTPStatusError(resp)
+	}
 }
 
 func (s Scanner) Type() detectorspb.DetectorType {
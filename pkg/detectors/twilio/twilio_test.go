@@ -52,6 +52,7 @@ func TestTwilio_FromChunk(t *testing.T) {
 					DetectorType: detectorspb.DetectorType_Twilio,
 					Verified:     true,
 					Redacted:     id,
+					ExtraData:    map[string]string{"key_type": "auth_token"},
 				},
 			},
 			wantErr: false,
@@ -69,6 +70,7 @@ func TestTwilio_FromChunk(t *testing.T) {
 					DetectorType: detectorspb.DetectorType_Twilio,
 					Verified:     false,
 					Redacted:     id,
+					ExtraData:    map[string]string{"key_type": "auth_token"},
 				},
 			},
 			wantErr: false,
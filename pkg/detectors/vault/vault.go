@@ -0,0 +1,175 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// Scanner detects HashiCorp Vault tokens. Vault is self-hosted, so tokens are
+// only verified against the servers set with SetEndpoints, such as with
+// --verifier, or the VAULT_ADDR environment variable.
+type Scanner struct {
+	detectors.EndpointSetter
+}
+
+// Ensure the Scanner satisfies the interfaces at compile time.
+var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.EndpointCustomizer = (*Scanner)(nil)
+
+// DefaultEndpoint is the address of the Vault server used for verification,
+// read from VAULT_ADDR like the Vault CLI does. It is empty if it isn't set.
+func (Scanner) DefaultEndpoint() string { return os.Getenv("VAULT_ADDR") }
+
+var (
+	client = common.SaneHttpClient()
+
+	// tokenPat matches service (hvs.) and batch (hvb.) tokens, which have
+	// had these prefixes since Vault 1.10.
+	tokenPat = regexp.MustCompile(`\b(hv[sb]\.[A-Za-z0-9_-]{24,})`)
+	// legacyTokenPat matches service tokens created before then, which are
+	// only matched near a keyword.
+	legacyTokenPat = regexp.MustCompile(detectors.PrefixRegex([]string{"vault"}) + `\b(s\.[A-Za-z0-9]{24})\b`)
+)
+
+// Keywords are used for efficiently pre-filtering chunks.
+// Use identifiers in the secret preferably, or the provider name.
+func (s Scanner) Keywords() []string {
+	return []string{"hvs.", "hvb.", "vault"}
+}
+
+// FromData will find and optionally verify Vault tokens in a given set of bytes.
+func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
+	dataStr := string(data)
+
+	var tokens []string
+	for _, match := range tokenPat.FindAllStringSubmatch(dataStr, -1) {
+		tokens = append(tokens, match[1])
+	}
+	for _, match := range legacyTokenPat.FindAllStringSubmatch(dataStr, -1) {
+		tokens = append(tokens, match[1])
+	}
+
+	seen := map[string]struct{}{}
+	for _, token := range tokens {
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+		if detectors.IsKnownFalsePositive(token, detectors.DefaultFalsePositives, false) {
+			continue
+		}
+		s1 := detectors.Result{
+			DetectorType: detectorspb.DetectorType_HashiCorpVaultToken,
+			Raw:          []byte(token),
+		}
+
+		if verify {
+			if err := s.verify(ctx, &s1, token); err != nil {
+				return results, err
+			}
+		}
+
+		results = append(results, s1)
+	}
+
+	return results, nil
+}
+
+// lookupRes is the response of the token lookup-self endpoint.
+type lookupRes struct {
+	Data struct {
+		Policies   []string `json:"policies"`
+		TTL        int64    `json:"ttl"`
+		ExpireTime string   `json:"expire_time"`
+	} `json:"data"`
+}
+
+// verify looks up token on each configured Vault server until one knows it,
+// recording its policies and TTL in s1. A token that may not look itself up
+// exists but is restricted, and is verified with the restriction recorded.
+// Nothing is verified if no server is configured. An error is only returned
+// if ctx is done.
+func (s Scanner) verify(ctx context.Context, s1 *detectors.Result, token string) error {
+	for _, endpoint := range s.Endpoints(s.DefaultEndpoint()) {
+		if endpoint == "" {
+			continue
+		}
+		var res *lookupRes
+		var restricted bool
+		err := detectors.WithRetry(ctx, func() error {
+			var err error
+			res, restricted, err = lookupSelf(ctx, endpoint, token)
+			return err
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return err
+		}
+		s1.VerifiedAt = detectors.Now()
+		s1.VerificationError = err
+		switch {
+		case restricted:
+			s1.Verified = true
+			s1.ExtraData = map[string]string{"restriction": "token lookup denied"}
+		case res != nil:
+			s1.Verified = true
+			s1.ExtraData = map[string]string{
+				"policies": strings.Join(res.Data.Policies, ","),
+				"ttl":      strconv.FormatInt(res.Data.TTL, 10),
+			}
+			if expireTime, err := time.Parse(time.RFC3339, res.Data.ExpireTime); err == nil {
+				s1.SetExpiry(expireTime)
+			}
+		}
+		if s1.Verified {
+			break
+		}
+	}
+	return nil
+}
+
+// lookupSelf returns the token's lookup-self response, or whether the token
+// exists but isn't allowed to look itself up. Both are empty if the token was
+// rejected. An error is returned unless the response says whether the token
+// is valid.
+func lookupSelf(ctx context.Context, endpoint, token string) (*lookupRes, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(endpoint, "/")+"/v1/auth/token/lookup-self", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var res lookupRes
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			return nil, false, err
+		}
+		return &res, false, nil
+	case http.StatusForbidden:
+		return nil, true, nil
+	case http.StatusUnauthorized, http.StatusBadRequest:
+		return nil, false, nil
+	default:
+		return nil, false, detectors.HTTPStatusError(resp)
+	}
+}
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_HashiCorpVaultToken
+}
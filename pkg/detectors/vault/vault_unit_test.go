@@ -0,0 +1,136 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+const (
+	testServiceToken    = "hvs.CAESIJ3kq9Xb7tLmRz2WvN5yPd8sFh1cKo4GuEa6QjYrTn0BGh4KHGh2cy5zT2ZxN2dZRjJaRVd4bXVjTzNwSkl0R1Q"
+	testBatchToken      = "hvb.AAAAAQJx3Kp8Wm2Rz9TvN5yPd8sFh1cKo4GuEa6QjYrTn0B"
+	testLegacyToken     = "s.Xk3Lq9Wb7tRmPz2VvN5yJd8s"
+	testRestrictedToken = "hvs.CAESIFr4nGk2Tz8Wq1Lm5Yd9Vh3Xc7Bp0Ns6Ju2Ke4Ra8Qo"
+	testInvalidToken    = "hvs.CAESIB7wQ2zXk5Lm9Rt3Vn1Yp8Jd4Gs6Hc0Fa2Ke7Uo5Ti"
+	testErrorToken      = "hvs.CAESIM9pR3tXz7Lk1Wq5Vn8Yd2Jh6Gs4Fc0Ba3Ke9Uo7Ti"
+)
+
+func TestVault_Pattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantRaw string
+	}{
+		{name: "service token", data: `export VAULT_TOKEN="` + testServiceToken + `"`, wantRaw: testServiceToken},
+		{name: "service token without keyword", data: "token: " + testServiceToken, wantRaw: testServiceToken},
+		{name: "batch token", data: "X-Vault-Token: " + testBatchToken, wantRaw: testBatchToken},
+		{name: "legacy token", data: "vault_token = " + testLegacyToken, wantRaw: testLegacyToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Scanner{}.FromData(context.Background(), false, []byte(tt.data))
+			assert.NoError(t, err)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tt.wantRaw, string(results[0].Raw))
+				assert.False(t, results[0].Verified)
+			}
+		})
+	}
+
+	for _, data := range []string{
+		// Legacy tokens need a keyword.
+		"session = " + testLegacyToken,
+		// Too short.
+		"VAULT_TOKEN=hvs.CAESIJ3kq9Xb7tLm",
+		// Recovery tokens aren't matched.
+		"VAULT_TOKEN=hvr.CAESIJ3kq9Xb7tLmRz2WvN5yPd8sFh1cKo4G",
+	} {
+		results, err := Scanner{}.FromData(context.Background(), false, []byte(data))
+		assert.NoError(t, err)
+		assert.Empty(t, results, data)
+	}
+}
+
+func TestVault_Verification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/auth/token/lookup-self" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Header.Get("X-Vault-Token") {
+		case testServiceToken:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data":{"accessor":"8609694a-cdbc-db9b-d345-e782dbb562ed","display_name":"token","expire_time":"2023-07-02T12:00:00Z","policies":["default","deploy"],"ttl":86400}}`))
+		case testRestrictedToken:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		case testErrorToken:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid token"]}`))
+		}
+	}))
+	defer server.Close()
+
+	scanTime := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	detectors.SetClock(func() time.Time { return scanTime })
+	defer detectors.SetClock(nil)
+	detectors.SetRetryPolicy(detectors.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+	defer detectors.SetRetryPolicy(detectors.DefaultRetryPolicy)
+
+	tests := []struct {
+		name          string
+		token         string
+		wantVerified  bool
+		wantVerifyErr bool
+		wantExtra     map[string]string
+	}{
+		{name: "verified token", token: testServiceToken, wantVerified: true, wantExtra: map[string]string{"policies": "default,deploy", "ttl": "86400"}},
+		{name: "restricted token", token: testRestrictedToken, wantVerified: true, wantExtra: map[string]string{"restriction": "token lookup denied"}},
+		{name: "invalid token", token: testInvalidToken},
+		{name: "server error", token: testErrorToken, wantVerifyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scanner{}
+			assert.NoError(t, s.SetEndpoints(server.URL))
+			results, err := s.FromData(context.Background(), true, []byte("VAULT_TOKEN="+tt.token))
+			assert.NoError(t, err)
+			if !assert.Len(t, results, 1) {
+				return
+			}
+			result := results[0]
+			assert.Equal(t, tt.wantVerified, result.Verified)
+			assert.Equal(t, tt.wantVerifyErr, result.VerificationError != nil)
+			assert.Equal(t, scanTime, result.VerifiedAt)
+			assert.Equal(t, tt.wantExtra, result.ExtraData)
+		})
+	}
+
+	t.Run("address from VAULT_ADDR", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", server.URL)
+		results, err := Scanner{}.FromData(context.Background(), true, []byte("VAULT_TOKEN="+testServiceToken))
+		assert.NoError(t, err)
+		if assert.Len(t, results, 1) {
+			assert.True(t, results[0].Verified)
+			assert.False(t, results[0].IsExpired())
+		}
+	})
+
+	t.Run("no address", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", "")
+		results, err := Scanner{}.FromData(context.Background(), true, []byte("VAULT_TOKEN="+testServiceToken))
+		assert.NoError(t, err)
+		if assert.Len(t, results, 1) {
+			assert.False(t, results[0].Verified)
+			assert.True(t, results[0].VerifiedAt.IsZero())
+		}
+	})
+}
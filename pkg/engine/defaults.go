@@ -270,6 +270,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/fusebill"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/fxmarket"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/gcp"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/gcpapikey"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/geckoboard"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/gemini"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/gengo"
@@ -699,6 +700,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/userflow"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/userstack"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/vatlayer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/vault"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/vbout"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/vercel"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/verifier"
@@ -757,7 +759,7 @@ func DefaultDetectors() []detectors.Detector {
 		&alibaba.Scanner{},
 		aws.New(),
 		&azure.Scanner{},
-		&slack.Scanner{}, // has 4 secret types
+		&slack.Scanner{}, // bot, user, app, refresh and session tokens
 		&gitlabv2.Scanner{},
 		&gitlab.Scanner{},
 		&sendgrid.Scanner{},
@@ -772,6 +774,7 @@ func DefaultDetectors() []detectors.Detector {
 		&github.Scanner{},
 		&twilio.Scanner{},
 		&gcp.Scanner{},
+		&gcpapikey.Scanner{},
 		&circleci.Scanner{},
 		&uri.Scanner{},
 		&razorpay.Scanner{},
@@ -795,6 +798,7 @@ func DefaultDetectors() []detectors.Detector {
 		// &auth0oauth.Scanner{},
 		&mailjetsms.Scanner{},
 		&digitaloceantoken.Scanner{},
+		&vault.Scanner{},        // verified against VAULT_ADDR or --verifier endpoints
 		&paystack.Scanner{},
 		&contentfulpersonalaccesstoken.Scanner{},
 		&hunter.Scanner{},
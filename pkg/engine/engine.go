@@ -2,15 +2,18 @@ package engine
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	ahocorasick "github.com/petar-dambovaliev/aho-corasick"
+	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/proto"
 
@@ -40,10 +43,37 @@ type Engine struct {
 	// If there are multiple unverified results for the same chunk for the same detector,
 	// only the first one will be kept.
 	filterUnverified bool
+	// remediate enables revoking verified secrets for detectors that
+	// implement detectors.Remediator.
+	remediate bool
+	// remediations holds the outcome of revoking each verified secret, by
+	// detector type and raw value, so that a secret found several times in
+	// a scan is only revoked once.
+	remediations sync.Map
+	// emitPolicy is passed to detectors to tell them which unverified
+	// results to emit.
+	emitPolicy detectors.EmitPolicy
+	// detectorTimeout is how long a detector may take to scan a single
+	// chunk before it is abandoned.
+	detectorTimeout time.Duration
 
 	// prefilter is a ahocorasick struct used for doing efficient string
 	// matching given a set of words (keywords from the rules in the config)
 	prefilter ahocorasick.AhoCorasick
+
+	// chunkFingerprints, if set, is used to skip chunks that were scanned
+	// by a previous run.
+	chunkFingerprints *sources.ChunkFingerprints
+
+	// scanDeduper drops results already reported from the same file by an
+	// earlier chunk.
+	scanDeduper *detectors.ScanDeduper
+	// pendingPartials holds the parts of multi-part credentials found in
+	// each file, to pair them with parts found in its other chunks.
+	pendingPartials *detectors.PendingPartials
+	// verifiedNotifier, if set, notifies a VerifiedHook of verified
+	// secrets.
+	verifiedNotifier *verifiedNotifier
 }
 
 type EngineOption func(*Engine)
@@ -83,6 +113,50 @@ func WithFilterUnverified(filter bool) EngineOption {
 	}
 }
 
+// WithRemediation sets the remediate flag on the engine. If set to true,
+// verified secrets are revoked by the detectors that support it.
+func WithRemediation(remediate bool) EngineOption {
+	return func(e *Engine) {
+		e.remediate = remediate
+	}
+}
+
+// WithOnlyVerified asks detectors that support it to skip results that
+// verification rejected. Results whose verification failed with an error are
+// still emitted. See detectors.EmitVerifiedOnly.
+func WithOnlyVerified(onlyVerified bool) EngineOption {
+	return func(e *Engine) {
+		e.emitPolicy = detectors.EmitAll
+		if onlyVerified {
+			e.emitPolicy = detectors.EmitVerifiedOnly
+		}
+	}
+}
+
+// DefaultDetectorTimeout is how long a detector may take to scan a single
+// chunk by default.
+const DefaultDetectorTimeout = 10 * time.Second
+
+// WithDetectorTimeout sets how long a detector may take to scan a single
+// chunk, including verification. The detector's context is cancelled once it
+// elapses, and a detector that doesn't return, such as one stuck matching a
+// pathological input, is abandoned so that it doesn't stall the scan. A
+// value <= 0 means DefaultDetectorTimeout.
+func WithDetectorTimeout(timeout time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.detectorTimeout = timeout
+	}
+}
+
+// WithChunkFingerprints configures the engine to skip chunks whose
+// fingerprint was recorded by a previous run. The fingerprints of all chunks
+// seen are saved when the engine finishes.
+func WithChunkFingerprints(fingerprints *sources.ChunkFingerprints) EngineOption {
+	return func(e *Engine) {
+		e.chunkFingerprints = fingerprints
+	}
+}
+
 // WithFilterDetectors applies a filter to the configured list of detectors. If
 // the filterFunc returns true, the detector will be included for scanning.
 // This option applies to the existing list of detectors configured, so the
@@ -113,6 +187,8 @@ func Start(ctx context.Context, options ...EngineOption) *Engine {
 		chunks:          make(chan *sources.Chunk),
 		results:         make(chan detectors.ResultWithMetadata),
 		detectorAvgTime: sync.Map{},
+		scanDeduper:     detectors.NewScanDeduper(),
+		pendingPartials: detectors.NewPendingPartials(),
 	}
 
 	for _, option := range options {
@@ -125,6 +201,9 @@ func Start(ctx context.Context, options ...EngineOption) *Engine {
 		ctx.Logger().Info("No concurrency specified, defaulting to max", "cpu", numCPU)
 		e.concurrency = numCPU
 	}
+	if e.detectorTimeout <= 0 {
+		e.detectorTimeout = DefaultDetectorTimeout
+	}
 	ctx.Logger().V(2).Info("engine started", "workers", e.concurrency)
 
 	sourcesWg, egCtx := errgroup.WithContext(ctx)
@@ -194,6 +273,14 @@ func Start(ctx context.Context, options ...EngineOption) *Engine {
 	return e
 }
 
+// useSelfHostedEndpoint points verification for the detectors of type t at a
+// self-hosted instance of their provider, as configured for a source.
+func (e *Engine) useSelfHostedEndpoint(t detectorspb.DetectorType, endpoint string) error {
+	endpoints := detectors.SelfHostedEndpoints{}
+	endpoints.Add(t, endpoint)
+	return endpoints.Apply(e.detectors[true]...)
+}
+
 // Finish waits for running sources to complete and workers to finish scanning
 // chunks before closing their respective channels. Once Finish is called, no
 // more sources may be scanned by the engine.
@@ -209,6 +296,14 @@ func (e *Engine) Finish(ctx context.Context, logFunc func(error, string, ...any)
 	// wait for the workers to finish processing all of the chunks and putting
 	// results onto the results channel
 	e.workersWg.Wait()
+	e.closeDetectors(ctx)
+	e.verifiedNotifier.close(ctx)
+
+	if e.chunkFingerprints != nil {
+		if err := e.chunkFingerprints.Save(); err != nil {
+			ctx.Logger().Error(err, "unable to save chunk fingerprints")
+		}
+	}
 
 	// TODO: re-evaluate whether this is needed and investigate why if so
 	//
@@ -218,6 +313,22 @@ func (e *Engine) Finish(ctx context.Context, logFunc func(error, string, ...any)
 	close(e.results)
 }
 
+// closeDetectors releases the resources held by the detectors that implement
+// detectors.Closer.
+func (e *Engine) closeDetectors(ctx context.Context) {
+	for _, verify := range []bool{true, false} {
+		for _, d := range e.detectors[verify] {
+			closer, ok := d.(detectors.Closer)
+			if !ok {
+				continue
+			}
+			if err := closer.Close(); err != nil {
+				ctx.Logger().Error(err, "unable to close detector", "detector", d.Type().String())
+			}
+		}
+	}
+}
+
 func (e *Engine) ChunksChan() chan *sources.Chunk {
 	return e.chunks
 }
@@ -245,6 +356,10 @@ func (e *Engine) dedupeAndSend(chunkResults []detectors.ResultWithMetadata) {
 			continue
 		}
 		dedupeMap[key] = struct{}{}
+		if e.scanDeduper.Seen(result) {
+			continue
+		}
+		e.verifiedNotifier.notify(result)
 		e.results <- result
 	}
 
@@ -273,9 +388,13 @@ func (e *Engine) DetectorAvgTime() map[string][]time.Duration {
 
 func (e *Engine) detectorWorker(ctx context.Context) {
 	for originalChunk := range e.chunks {
+		if e.chunkFingerprints != nil && e.chunkFingerprints.Seen(originalChunk) {
+			continue
+		}
 		for chunk := range sources.Chunker(originalChunk) {
 			var chunkResults []detectors.ResultWithMetadata
 			matchedKeywords := make(map[string]struct{})
+			exactKeywords := make(map[string]struct{})
 			atomic.AddUint64(&e.bytesScanned, uint64(len(chunk.Data)))
 			for _, decoder := range e.decoders {
 				var decoderType detectorspb.DecoderType
@@ -299,38 +418,50 @@ func (e *Engine) detectorWorker(ctx context.Context) {
 
 				// build a map of all keywords that were matched in the chunk
 				for _, m := range e.prefilter.FindAll(string(decoded.Data)) {
-					matchedKeywords[strings.ToLower(string(decoded.Data[m.Start():m.End()]))] = struct{}{}
+					match := string(decoded.Data[m.Start():m.End()])
+					matchedKeywords[strings.ToLower(match)] = struct{}{}
+					exactKeywords[match] = struct{}{}
 				}
 
 				for verify, detectorsSet := range e.detectors {
-					for _, detector := range detectorsSet {
-						chunkContainsKeyword := false
-						for _, kw := range detector.Keywords() {
-							if _, ok := matchedKeywords[strings.ToLower(kw)]; ok {
-								chunkContainsKeyword = true
-								break
-							}
-						}
-
-						if !chunkContainsKeyword {
+					for _, detector := range prioritizeDetectors(detectorsSet, chunk.DetectorHints) {
+						keywordMatched := containsKeyword(detector, matchedKeywords, exactKeywords) ||
+							matchesKeywordPattern(detector, decoded.Data)
+						_, multiPart := detector.(detectors.MultiPartDetector)
+						if !keywordMatched && !multiPart {
 							continue
 						}
 
 						start := time.Now()
 
-						results, err := func() ([]detectors.Result, error) {
-							ctx, cancel := context.WithTimeout(ctx, time.Second*10)
-							defer cancel()
-							defer common.Recover(ctx)
-							return detector.FromData(ctx, verify, decoded.Data)
-						}()
+						var results []detectors.Result
+						if keywordMatched {
+							var err error
+							results, err = e.fromData(ctx, detector, verify, decoded.Data)
+							if errors.Is(err, errDetectorTimeout) {
+								ctx.Logger().Error(err, "abandoned detector on chunk",
+									"detector", detector.Type().String(),
+									"source_type", decoded.SourceType.String(),
+									"metadata", decoded.SourceMetadata,
+								)
+								continue
+							}
+							if err != nil {
+								ctx.Logger().Error(err, "could not scan chunk",
+									"source_type", decoded.SourceType.String(),
+									"metadata", decoded.SourceMetadata,
+								)
+								continue
+							}
+						}
+						partialResults, err := e.pairPartialMatches(ctx, detector, verify, keywordMatched, chunk, decoded.Data)
 						if err != nil {
-							ctx.Logger().Error(err, "could not scan chunk",
+							ctx.Logger().Error(err, "could not pair partial matches",
 								"source_type", decoded.SourceType.String(),
 								"metadata", decoded.SourceMetadata,
 							)
-							continue
 						}
+						results = append(results, partialResults...)
 
 						if e.filterUnverified {
 							results = detectors.CleanResults(results)
@@ -351,7 +482,11 @@ func (e *Engine) detectorWorker(ctx context.Context) {
 							if ignoreLinePresent {
 								continue
 							}
+							e.remediateResult(ctx, detector, &result)
 							result.DecoderType = decoderType
+							if chunk.DecoderType != detectorspb.DecoderType_UNKNOWN {
+								result.DecoderType = chunk.DecoderType
+							}
 							chunkResults = append(chunkResults, detectors.CopyMetadata(resultChunk, result))
 
 						}
@@ -378,6 +513,160 @@ func (e *Engine) detectorWorker(ctx context.Context) {
 	}
 }
 
+// errDetectorTimeout is returned by fromData when a detector took longer
+// than the detector timeout.
+var errDetectorTimeout = errors.New("detector timed out")
+
+// fromData runs the detector on data with the detector timeout. The detector
+// runs in its own goroutine, so that one that ignores the cancellation of its
+// context can be abandoned once the timeout elapses, in which case
+// errDetectorTimeout is returned. Panics are recovered and reported.
+func (e *Engine) fromData(ctx context.Context, detector detectors.Detector, verify bool, data []byte) ([]detectors.Result, error) {
+	detectorCtx, cancel := context.WithTimeout(ctx, e.detectorTimeout)
+	defer cancel()
+
+	type fromDataRes struct {
+		results []detectors.Result
+		err     error
+	}
+	done := make(chan fromDataRes, 1)
+	go func() {
+		var res fromDataRes
+		// A detector that panicked returns nothing.
+		defer func() { done <- res }()
+		defer common.Recover(detectorCtx)
+		res.results, res.err = detector.FromData(detectors.WithEmitPolicy(detectorCtx, e.emitPolicy), verify, data)
+	}()
+
+	select {
+	case res := <-done:
+		return res.results, res.err
+	case <-detectorCtx.Done():
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w after %s", errDetectorTimeout, e.detectorTimeout)
+}
+
+// pairPartialMatches returns the results of pairing the parts of credentials
+// found in data with the ones pending from other chunks of the same file, for
+// detectors that implement detectors.MultiPartDetector. Parts are looked for
+// in every chunk, since the one containing the keywords may be scanned after
+// the others, but only paired in files where some chunk matched a keyword.
+func (e *Engine) pairPartialMatches(ctx context.Context, detector detectors.Detector, verify, keywordMatched bool, chunk *sources.Chunk, data []byte) ([]detectors.Result, error) {
+	multiPart, ok := detector.(detectors.MultiPartDetector)
+	if !ok {
+		return nil, nil
+	}
+	// Parts are only paired across the chunks of files.
+	if chunk.SourceMetadata.GetFilesystem().GetFile() == "" {
+		return nil, nil
+	}
+	current := multiPart.PartialMatches(data)
+	pending := e.pendingPartials.Swap(detector, chunk.SourceMetadata, keywordMatched, current)
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.detectorTimeout)
+	defer cancel()
+	defer common.Recover(ctx)
+	return multiPart.FromPartialMatches(detectors.WithEmitPolicy(ctx, e.emitPolicy), verify, current, pending)
+}
+
+// remediateResult revokes a verified secret if remediation is enabled and the
+// detector supports it. The outcome is recorded in the result's ExtraData.
+// Each secret is only revoked once per scan, and later findings of it report
+// the outcome of that first attempt.
+func (e *Engine) remediateResult(ctx context.Context, detector detectors.Detector, result *detectors.Result) {
+	remediator, ok := detector.(detectors.Remediator)
+	if !e.remediate || !ok || !result.Verified {
+		return
+	}
+	key := result.DetectorType.String() + "/" + string(result.Raw)
+	value, _ := e.remediations.LoadOrStore(key, &remediation{})
+	r := value.(*remediation)
+	r.once.Do(func() {
+		ctx.Logger().Info("REMEDIATION: revoking verified secret",
+			"detector_type", result.DetectorType.String(),
+			"redacted", result.Redacted,
+		)
+		r.err = remediator.Remediate(ctx, result.Raw)
+		if r.err != nil {
+			ctx.Logger().Error(r.err, "REMEDIATION: could not revoke verified secret",
+				"detector_type", result.DetectorType.String(),
+				"redacted", result.Redacted,
+			)
+		}
+	})
+	if result.ExtraData == nil {
+		result.ExtraData = map[string]string{}
+	}
+	result.ExtraData["remediated"] = strconv.FormatBool(r.err == nil)
+}
+
+// remediation is the outcome of revoking a verified secret.
+type remediation struct {
+	once sync.Once
+	err  error
+}
+
+// prioritizeDetectors returns detectorsSet with the detectors of the hinted
+// types first, keeping the order of the others. It returns detectorsSet
+// itself if there are no hints.
+func prioritizeDetectors(detectorsSet []detectors.Detector, hints []detectorspb.DetectorType) []detectors.Detector {
+	if len(hints) == 0 {
+		return detectorsSet
+	}
+	prioritized := make([]detectors.Detector, 0, len(detectorsSet))
+	var rest []detectors.Detector
+	for _, detector := range detectorsSet {
+		if slices.Contains(hints, detector.Type()) {
+			prioritized = append(prioritized, detector)
+		} else {
+			rest = append(rest, detector)
+		}
+	}
+	return append(prioritized, rest...)
+}
+
+// containsKeyword reports whether the prefilter matched one of the detector's
+// keywords. matched holds the lowercased matches and exact holds them as they
+// appear in the chunk, for keywords the detector declares case-sensitive.
+func containsKeyword(detector detectors.Detector, matched, exact map[string]struct{}) bool {
+	var caseSensitive []string
+	if d, ok := detector.(detectors.CaseSensitiveKeyworder); ok {
+		caseSensitive = d.CaseSensitiveKeywords()
+	}
+	for _, kw := range detector.Keywords() {
+		if slices.Contains(caseSensitive, kw) {
+			if _, ok := exact[kw]; ok {
+				return true
+			}
+			continue
+		}
+		if _, ok := matched[strings.ToLower(kw)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesKeywordPattern reports whether data matches one of the keyword
+// patterns of a detector that implements detectors.KeywordPatterner.
+func matchesKeywordPattern(detector detectors.Detector, data []byte) bool {
+	d, ok := detector.(detectors.KeywordPatterner)
+	if !ok {
+		return false
+	}
+	for _, pattern := range d.KeywordPatterns() {
+		if pattern.Match(data) {
+			return true
+		}
+	}
+	return false
+}
+
 // lineNumberSupportedSources is a list of sources that support line numbers.
 // It is stored this way because slice consts are not supported.
 func lineNumberSupportedSources() []sourcespb.SourceType {
@@ -2,6 +2,8 @@ package engine
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"runtime"
 
 	"github.com/go-errors/errors"
@@ -17,34 +19,303 @@ import (
 
 // ScanFileSystem scans a given file system.
 func (e *Engine) ScanFileSystem(ctx context.Context, c sources.FilesystemConfig) error {
-	connection := &sourcespb.Filesystem{
-		Paths: c.Paths,
+	fileSystemSource, err := newFileSystemSource(ctx, c)
+	if err != nil {
+		return err
 	}
-	var conn anypb.Any
-	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	ctx = fileSystemContext(ctx, fileSystemSource)
+	e.sourcesWg.Go(func() error {
+		defer common.RecoverWithExit(ctx)
+		err := fileSystemSource.Chunks(ctx, e.ChunksChan())
+		if err != nil {
+			return fmt.Errorf("error scanning filesystem: %w", err)
+		}
+		return nil
+	})
+	return nil
+}
+
+// ExportFileSystemChunks runs the filesystem source without scanning its
+// chunks, instead writing them to w as newline delimited JSON for use by
+// external detection pipelines. It blocks until all chunks are written.
+func ExportFileSystemChunks(ctx context.Context, c sources.FilesystemConfig, w io.Writer) error {
+	fileSystemSource, err := newFileSystemSource(ctx, c)
 	if err != nil {
-		ctx.Logger().Error(err, "failed to marshal filesystem connection")
 		return err
 	}
+	ctx = fileSystemContext(ctx, fileSystemSource)
 
-	fileSystemSource := filesystem.Source{}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chunksChan := make(chan *sources.Chunk)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(chunksChan)
+		errChan <- fileSystemSource.Chunks(ctx, chunksChan)
+	}()
 
-	ctx = context.WithValues(ctx,
-		"source_type", fileSystemSource.Type().String(),
-		"source_name", "filesystem",
-	)
-	err = fileSystemSource.Init(ctx, "trufflehog - filesystem", 0, int64(sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM), true, &conn, runtime.NumCPU())
+	writer := sources.NewNDJSONChunkWriter(w)
+	for chunk := range chunksChan {
+		if err := writer.Write(chunk); err != nil {
+			cancel()
+			// Drain the channel so the source can exit.
+			for range chunksChan {
+			}
+			return err
+		}
+	}
+	if err := <-errChan; err != nil {
+		return fmt.Errorf("error scanning filesystem: %w", err)
+	}
+	return nil
+}
+
+// ExportFileSystemUnits enumerates the filesystem source without chunking it,
+// instead writing its units to w as newline delimited JSON, so that they can
+// be chunked elsewhere with ScanFileSystemUnits. It blocks until all units are
+// written.
+func ExportFileSystemUnits(ctx context.Context, c sources.FilesystemConfig, w io.Writer) error {
+	fileSystemSource, err := newFileSystemSource(ctx, c)
 	if err != nil {
-		return errors.WrapPrefix(err, "could not init filesystem source", 0)
+		return err
 	}
-	fileSystemSource.WithFilter(c.Filter)
+	ctx = fileSystemContext(ctx, fileSystemSource)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	unitsChan := make(chan sources.EnumerationResult)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(unitsChan)
+		errChan <- fileSystemSource.Enumerate(ctx, unitsChan)
+	}()
+
+	for result := range unitsChan {
+		if result.Error != nil {
+			ctx.Logger().Error(result.Error, "error enumerating filesystem")
+			continue
+		}
+		if err := sources.WriteSourceUnit(w, result.Unit); err != nil {
+			cancel()
+			// Drain the channel so the source can exit.
+			for range unitsChan {
+			}
+			return err
+		}
+	}
+	if err := <-errChan; err != nil {
+		return fmt.Errorf("error enumerating filesystem: %w", err)
+	}
+	return nil
+}
+
+// ScanFileSystemUnits scans the units read from r, as written by
+// ExportFileSystemUnits, instead of enumerating the configured paths. This
+// lets enumeration and scanning run on separate machines.
+func (e *Engine) ScanFileSystemUnits(ctx context.Context, c sources.FilesystemConfig, r io.Reader) error {
+	fileSystemSource, err := newFileSystemSource(ctx, c)
+	if err != nil {
+		return err
+	}
+	units, err := sources.ReadSourceUnits(r, fileSystemSource)
+	if err != nil {
+		return err
+	}
+	// Without batching, each chunk is sent on its own, as by ChunkUnit.
+	batchSize := c.ChunkBatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	fileSystemSource.WithChunkBatchSize(batchSize)
+	ctx = fileSystemContext(ctx, fileSystemSource)
 	e.sourcesWg.Go(func() error {
 		defer common.RecoverWithExit(ctx)
-		err := fileSystemSource.Chunks(ctx, e.ChunksChan())
-		if err != nil {
-			return fmt.Errorf("error scanning filesystem: %w", err)
+		batches := make(chan []sources.ChunkResult)
+		errChan := make(chan error, 1)
+		go func() {
+			defer close(batches)
+			for _, unit := range units {
+				if err := fileSystemSource.ChunkUnitBatches(ctx, unit, batches); err != nil {
+					errChan <- err
+					return
+				}
+			}
+			errChan <- nil
+		}()
+		send := func(batch []sources.ChunkResult) error {
+			for _, result := range batch {
+				if result.Error != nil {
+					ctx.Logger().Error(result.Error, "error scanning filesystem unit")
+					continue
+				}
+				if err := common.CancellableWrite(ctx, e.ChunksChan(), result.Chunk); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for batch := range batches {
+			if err := send(batch); err != nil {
+				// Drain the channel so the source can exit.
+				for range batches {
+				}
+				break
+			}
+		}
+		if err := <-errChan; err != nil {
+			return fmt.Errorf("error scanning filesystem units: %w", err)
 		}
 		return nil
 	})
 	return nil
 }
+
+func fileSystemContext(ctx context.Context, s *filesystem.Source) context.Context {
+	return context.WithValues(ctx,
+		"source_type", s.Type().String(),
+		"source_name", "filesystem",
+	)
+}
+
+// newFileSystemSource initializes a filesystem source from the config.
+func newFileSystemSource(ctx context.Context, c sources.FilesystemConfig) (*filesystem.Source, error) {
+	connection := &sourcespb.Filesystem{
+		Paths: c.Paths,
+	}
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal filesystem connection")
+		return nil, err
+	}
+
+	fileSystemSource := &filesystem.Source{}
+	fileSystemSource.WithLifecycleHook(c.LifecycleHook)
+	err = fileSystemSource.Init(fileSystemContext(ctx, fileSystemSource), "trufflehog - filesystem", 0, int64(sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM), true, &conn, runtime.NumCPU())
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not init filesystem source", 0)
+	}
+	filter := c.Filter
+	if len(c.Filters) > 0 {
+		mode := common.FilterAll
+		if c.FiltersAny {
+			mode = common.FilterAny
+		}
+		filter = common.ComposeFilters(common.FilterAll, c.Filter, common.ComposeFilters(mode, c.Filters...))
+	}
+	fileSystemSource.WithFilter(filter)
+	excludeGlobs, err := common.NewGlobFilter(c.ExcludeGlobs)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not compile exclude globs", 0)
+	}
+	fileSystemSource.WithExcludeGlobs(excludeGlobs)
+	if c.ExcludeFalsePositivePaths {
+		falsePositivePaths, err := common.NewGlobFilter(append(filesystem.DefaultFalsePositivePaths(), c.FalsePositivePaths...))
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "could not compile false positive paths", 0)
+		}
+		fileSystemSource.WithFalsePositivePaths(falsePositivePaths)
+	}
+	if c.HighSignalOnly {
+		highSignalPaths, err := common.NewGlobFilter(append(filesystem.DefaultHighSignalPaths(), c.HighSignalPaths...))
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "could not compile high signal paths", 0)
+		}
+		fileSystemSource.WithHighSignalPaths(highSignalPaths)
+	}
+	archiveInclude, err := common.NewGlobFilter(c.ArchiveIncludeGlobs)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not compile archive include globs", 0)
+	}
+	archiveExclude, err := common.NewGlobFilter(c.ArchiveExcludeGlobs)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not compile archive exclude globs", 0)
+	}
+	fileSystemSource.WithArchiveEntryGlobs(archiveInclude, archiveExclude)
+	fileSystemSource.WithDecompressionLimits(c.ArchiveMaxRatio, c.ArchiveMaxExtractedSize)
+	fileSystemSource.WithLabels(c.Labels)
+	fileSystemSource.WithReferencedCredentialFiles(c.ScanReferencedCredentials)
+	fileSystemSource.WithFileSymlinks(c.FollowFileSymlinks)
+	fileSystemSource.WithSymlinkEscape(c.AllowSymlinkEscape)
+	fileSystemSource.WithWholeFileThreshold(c.WholeFileThreshold)
+	fileSystemSource.WithBase64Decoding(c.DecodeBase64)
+	fileSystemSource.WithEncodingDetection(c.DetectEncoding)
+	fileSystemSource.WithGitLFS(c.ResolveGitLFS)
+	fileSystemSource.WithContentTypeAllowlist(c.ContentTypeAllow)
+	fileSystemSource.WithImageTarballs(c.ScanImageTarballs)
+	fileSystemSource.WithFileAttributes(c.ScanFileAttributes)
+	fileSystemSource.WithEmptyFileChunks(c.EmitEmptyFiles)
+	skipDirs := c.SkipDirs
+	if !c.NoDefaultSkipDirs {
+		skipDirs = append(filesystem.DefaultSkipDirs(), skipDirs...)
+	}
+	fileSystemSource.WithSkipDirs(skipDirs)
+	hiddenFiles, err := filesystem.ParseHiddenFilesMode(c.HiddenFiles)
+	if err != nil {
+		return nil, err
+	}
+	fileSystemSource.WithHiddenFiles(hiddenFiles)
+	fileSystemSource.WithWarningReporter(c.WarningReporter)
+	fileSystemSource.WithCheckpointReporter(c.CheckpointReporter)
+	fileSystemSource.WithCompletedUnits(c.CompletedUnits)
+	fileSystemSource.WithPathSanitizer(c.PathSanitizer)
+	fileSystemSource.WithReadRateLimit(c.ReadBytesPerSecond)
+	fileSystemSource.WithMaxOpenFiles(c.MaxOpenFiles)
+	fileSystemSource.WithProgressInterval(c.ProgressInterval)
+	fileSystemSource.WithGitAnnotations(c.AnnotateGit)
+	if err := fileSystemSource.WithSampleRate(c.SampleRate); err != nil {
+		return nil, err
+	}
+	fileSystemSource.WithHeadBytes(c.HeadBytes)
+	fileSystemSource.WithSizePercentileCutoff(c.SizePercentileCutoff)
+	if c.ManifestPath != "" {
+		if err := withManifest(fileSystemSource, c.ManifestPath); err != nil {
+			return nil, errors.WrapPrefix(err, "could not load manifest", 0)
+		}
+	}
+	if c.DiffPath != "" {
+		if err := withDiff(fileSystemSource, c); err != nil {
+			return nil, errors.WrapPrefix(err, "could not load diff", 0)
+		}
+	}
+	if c.SkipCachePath != "" {
+		mode := filesystem.SkipCacheModeMetadata
+		if c.SkipCacheContentHash {
+			mode = filesystem.SkipCacheModeContent
+		}
+		if err := fileSystemSource.WithSkipCache(c.SkipCachePath, mode, c.ForceRescan); err != nil {
+			return nil, errors.WrapPrefix(err, "could not load filesystem skip cache", 0)
+		}
+	}
+	return fileSystemSource, nil
+}
+
+func withManifest(s *filesystem.Source, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	specs, err := filesystem.LoadManifest(f)
+	if err != nil {
+		return err
+	}
+	s.WithPathSpecs(specs)
+	return nil
+}
+
+func withDiff(s *filesystem.Source, c sources.FilesystemConfig) error {
+	if len(c.Paths) > 1 {
+		return fmt.Errorf("a diff can only be scanned relative to a single path")
+	}
+	var root string
+	if len(c.Paths) == 1 {
+		root = c.Paths[0]
+	}
+	f, err := os.Open(c.DiffPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.WithDiff(f, root)
+}
@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"bytes"
+	aCtx "context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/spotifykey"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/filesystem"
+)
+
+func TestExportFileSystemChunks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("token = abc123\n"), 0644))
+
+	var buf bytes.Buffer
+	err := ExportFileSystemChunks(context.Background(), sources.FilesystemConfig{Paths: []string{dir}}, &buf)
+	assert.NoError(t, err)
+
+	r := sources.NewNDJSONChunkReader(&buf)
+	var data []byte
+	for {
+		chunk, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, path, chunk.SourceMetadata.GetFilesystem().GetFile())
+		data = append(data, chunk.Data...)
+	}
+	assert.Equal(t, "token = abc123\n", string(data))
+}
+
+// secretDetector reports every occurrence of a test secret format.
+type secretDetector struct{}
+
+var secretPat = regexp.MustCompile(`\bTESTSECRET_[A-Z0-9]{16}\b`)
+
+func (secretDetector) FromData(_ aCtx.Context, _ bool, data []byte) ([]detectors.Result, error) {
+	var results []detectors.Result
+	for _, match := range secretPat.FindAll(data, -1) {
+		results = append(results, detectors.Result{DetectorType: detectorspb.DetectorType_CustomRegex, Raw: match})
+	}
+	return results, nil
+}
+
+func (secretDetector) Keywords() []string             { return []string{"TESTSECRET_"} }
+func (secretDetector) Type() detectorspb.DetectorType { return detectorspb.DetectorType_CustomRegex }
+
+func TestScanFileSystem_DedupesAcrossChunks(t *testing.T) {
+	const secret = "TESTSECRET_4Q7ZK2M9XW3V8R1T"
+	// The second occurrence starts right at the boundary of the first chunk,
+	// so it's in both that chunk's overlap and the next chunk.
+	content := secret + "\n" + strings.Repeat("x", filesystem.BufferSize-len(secret)-2) + "\n" + secret + "\n" + strings.Repeat("y", filesystem.BufferSize)
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.txt"), []byte(content), 0644))
+
+	ctx := context.Background()
+	e := Start(ctx, WithConcurrency(1), WithDetectors(false, secretDetector{}))
+	assert.NoError(t, e.ScanFileSystem(ctx, sources.FilesystemConfig{Paths: []string{dir}}))
+	go e.Finish(ctx, func(error, string, ...any) {})
+
+	var results []detectors.ResultWithMetadata
+	for result := range e.ResultsChan() {
+		results = append(results, result)
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, secret, string(results[0].Raw))
+	}
+}
+
+// keywordSecretDetector finds secrets that don't contain its keyword, so
+// chunks are only scanned if the keyword is somewhere else in them.
+type keywordSecretDetector struct{ secretDetector }
+
+func (keywordSecretDetector) Keywords() []string { return []string{"vaultconfig"} }
+
+func TestDetectorWorker_FindsSecretInOverlapWithoutKeyword(t *testing.T) {
+	const secret = "TESTSECRET_4Q7ZK2M9XW3V8R1T"
+	metadata := &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Filesystem{
+		Filesystem: &source_metadatapb.Filesystem{File: "config.txt"},
+	}}
+	// The secret is in the first chunk's overlap, along with the keyword,
+	// and in the next chunk, which doesn't contain the keyword.
+	overlap := secret + "\n"
+	chunks := []*sources.Chunk{
+		{Data: []byte("vaultconfig:\n" + strings.Repeat("x", 64) + "\n" + overlap), SourceMetadata: metadata},
+		{Data: []byte(overlap + strings.Repeat("y", 64)), SourceMetadata: metadata},
+	}
+
+	ctx := context.Background()
+	e := Start(ctx, WithConcurrency(1), WithDetectors(false, keywordSecretDetector{}))
+	go func() {
+		for _, chunk := range chunks {
+			e.ChunksChan() <- chunk
+		}
+		e.Finish(ctx, func(error, string, ...any) {})
+	}()
+
+	var results []detectors.ResultWithMetadata
+	for result := range e.ResultsChan() {
+		results = append(results, result)
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, secret, string(results[0].Raw))
+	}
+}
+
+func TestScanFileSystem_PairsPartialMatchesAcrossChunks(t *testing.T) {
+	const (
+		clientID     = "8d3f8e2a9b7c4d1e6f5a0b9c8d7e6f5a"
+		clientSecret = "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d"
+	)
+	// The ID and the secret are several chunks apart, and only the ID's chunk
+	// contains the detector's keyword.
+	padding := strings.Repeat("x\n", 2*filesystem.BufferSize)
+	content := "spotify:\n  client_id: " + clientID + "\n" + padding + "  secret: " + clientSecret + "\n" + padding
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644))
+
+	for _, concurrency := range []int{1, 4} {
+		ctx := context.Background()
+		e := Start(ctx, WithConcurrency(concurrency), WithDetectors(false, &spotifykey.Scanner{}))
+		assert.NoError(t, e.ScanFileSystem(ctx, sources.FilesystemConfig{Paths: []string{dir}}))
+		go e.Finish(ctx, func(error, string, ...any) {})
+
+		var results []detectors.ResultWithMetadata
+		for result := range e.ResultsChan() {
+			results = append(results, result)
+		}
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, clientSecret, string(results[0].Raw))
+			assert.Equal(t, detectorspb.DetectorType_SpotifyKey, results[0].DetectorType)
+		}
+	}
+}
+
+func TestScanFileSystem_DetectEncoding(t *testing.T) {
+	const (
+		clientID     = "8d3f8e2a9b7c4d1e6f5a0b9c8d7e6f5a"
+		clientSecret = "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d"
+	)
+	content := []byte{0xFF, 0xFE}
+	for _, r := range "spotify client_id: " + clientID + "\r\nspotify secret: " + clientSecret + "\r\n" {
+		content = append(content, byte(r), byte(r>>8))
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "settings.ini"), content, 0644))
+
+	ctx := context.Background()
+	e := Start(ctx, WithConcurrency(1), WithDetectors(false, &spotifykey.Scanner{}))
+	assert.NoError(t, e.ScanFileSystem(ctx, sources.FilesystemConfig{Paths: []string{dir}, DetectEncoding: true}))
+	go e.Finish(ctx, func(error, string, ...any) {})
+
+	var results []detectors.ResultWithMetadata
+	for result := range e.ResultsChan() {
+		results = append(results, result)
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, clientSecret, string(results[0].Raw))
+		assert.Equal(t, "utf-16le", results[0].SourceMetadata.GetFilesystem().GetEncoding())
+	}
+}
+
+func TestScanFileSystem_ResultsCarrySourceUnit(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml":     "spotify client_id: 8d3f8e2a9b7c4d1e6f5a0b9c8d7e6f5a\nspotify secret: 1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d\n",
+		"sub/b.yaml": "spotify client_id: 9e4f9f3b0c8d5e2f7a6b1c0d9e8f7a6b\nspotify secret: 2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	ctx := context.Background()
+	e := Start(ctx, WithConcurrency(1), WithDetectors(false, &spotifykey.Scanner{}))
+	assert.NoError(t, e.ScanFileSystem(ctx, sources.FilesystemConfig{Paths: []string{dir}}))
+	go e.Finish(ctx, func(error, string, ...any) {})
+
+	units := map[string]string{}
+	for result := range e.ResultsChan() {
+		units[string(result.Raw)] = result.SourceUnitID
+		assert.Equal(t, result.SourceMetadata.GetFilesystem().GetFile(), result.SourceUnitID)
+	}
+	assert.Equal(t, map[string]string{
+		"1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d": filepath.Join(dir, "a.yaml"),
+		"2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e": filepath.Join(dir, "sub", "b.yaml"),
+	}, units)
+}
+
+func TestScanFileSystemUnits(t *testing.T) {
+	const secret = "TESTSECRET_4Q7ZK2M9XW3V8R1T"
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("token: "+secret+"\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("nothing here\n"), 0644))
+	single := filepath.Join(t.TempDir(), "single.txt")
+	assert.NoError(t, os.WriteFile(single, []byte(secret+"\n"), 0644))
+
+	// Units are enumerated from the paths, then scanned by a source
+	// configured without them.
+	ctx := context.Background()
+	var units bytes.Buffer
+	assert.NoError(t, ExportFileSystemUnits(ctx, sources.FilesystemConfig{Paths: []string{dir, single}}, &units))
+	assert.Equal(t, 2, strings.Count(units.String(), "\n"))
+
+	e := Start(ctx, WithConcurrency(1), WithDetectors(false, secretDetector{}))
+	assert.NoError(t, e.ScanFileSystemUnits(ctx, sources.FilesystemConfig{}, &units))
+	go e.Finish(ctx, func(error, string, ...any) {})
+
+	var files []string
+	for result := range e.ResultsChan() {
+		files = append(files, result.SourceMetadata.GetFilesystem().GetFile())
+	}
+	assert.ElementsMatch(t, []string{filepath.Join(dir, "a.txt"), single}, files)
+}
+
+func TestScanFileSystem_DetectorTimeout(t *testing.T) {
+	const secret = "TESTSECRET_4Q7ZK2M9XW3V8R1T"
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "stuck.txt"), []byte("STUCK "+secret+"\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.txt"), []byte(secret+"\n"), 0644))
+
+	detector := stuckDetector{release: make(chan struct{})}
+	defer close(detector.release)
+	ctx := context.Background()
+	e := Start(ctx, WithConcurrency(1), WithDetectors(false, detector), WithDetectorTimeout(100*time.Millisecond))
+	assert.NoError(t, e.ScanFileSystem(ctx, sources.FilesystemConfig{Paths: []string{dir}}))
+	go e.Finish(ctx, func(error, string, ...any) {})
+
+	var files []string
+	for result := range e.ResultsChan() {
+		files = append(files, filepath.Base(result.SourceMetadata.GetFilesystem().GetFile()))
+	}
+	assert.Equal(t, []string{"config.txt"}, files)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
@@ -19,6 +20,12 @@ import (
 func (e *Engine) ScanGitHub(ctx context.Context, c sources.GithubConfig) error {
 	source := github.Source{}
 
+	if len(c.Endpoint) > 0 {
+		if err := e.useSelfHostedEndpoint(detectorspb.DetectorType_Github, c.Endpoint); err != nil {
+			return err
+		}
+	}
+
 	connection := sourcespb.GitHub{
 		Endpoint:      c.Endpoint,
 		Organizations: c.Orgs,
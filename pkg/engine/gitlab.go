@@ -11,6 +11,7 @@ import (
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
@@ -39,6 +40,9 @@ func (e *Engine) ScanGitLab(ctx context.Context, c sources.GitlabConfig) error {
 
 	if len(c.Endpoint) > 0 {
 		connection.Endpoint = c.Endpoint
+		if err := e.useSelfHostedEndpoint(detectorspb.DetectorType_Gitlab, c.Endpoint); err != nil {
+			return err
+		}
 	}
 
 	if len(c.Repos) > 0 {
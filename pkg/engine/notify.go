@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+// VerifiedSecret is the notification of a verified secret passed to a
+// VerifiedHook. It carries the redacted secret rather than the raw one, so
+// that it can be forwarded to third parties such as chat webhooks.
+type VerifiedSecret struct {
+	DetectorType detectorspb.DetectorType
+	DetectorName string
+	// Redacted is the redacted secret, or an identifier of it such as an
+	// ID, if the detector sets one.
+	Redacted       string
+	SourceType     sourcespb.SourceType
+	SourceName     string
+	SourceMetadata *source_metadatapb.MetaData
+	VerifiedAt     time.Time
+}
+
+// VerifiedHook is notified of each verified secret as soon as the chunk it
+// was found in is scanned, rather than once the scan finishes, for example
+// to alert on it in real time. Notifications are delivered in order from a
+// single goroutine, so implementations don't need to be safe for concurrent
+// use, but slow ones delay later notifications. See WithVerifiedHook.
+type VerifiedHook interface {
+	OnVerified(secret VerifiedSecret)
+}
+
+// verifiedQueueSize is the number of notifications queued for a slow
+// VerifiedHook before further ones are dropped.
+const verifiedQueueSize = 1024
+
+// WithVerifiedHook notifies hook of each verified secret as soon as it's
+// found. Notifications are queued so that a slow hook doesn't stall the
+// scan, and dropped if the queue is full. Finish waits for the queued ones
+// to be delivered.
+func WithVerifiedHook(hook VerifiedHook) EngineOption {
+	return func(e *Engine) {
+		e.verifiedNotifier = newVerifiedNotifier(hook)
+	}
+}
+
+// verifiedNotifier delivers notifications of verified secrets to a hook from
+// its own goroutine.
+type verifiedNotifier struct {
+	hook  VerifiedHook
+	queue chan VerifiedSecret
+	done  chan struct{}
+	// dropped is the number of notifications dropped because the queue was
+	// full.
+	dropped atomic.Int64
+}
+
+func newVerifiedNotifier(hook VerifiedHook) *verifiedNotifier {
+	n := &verifiedNotifier{
+		hook:  hook,
+		queue: make(chan VerifiedSecret, verifiedQueueSize),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		defer close(n.done)
+		for secret := range n.queue {
+			n.hook.OnVerified(secret)
+		}
+	}()
+	return n
+}
+
+// notify queues a notification of result if it's verified, without blocking.
+func (n *verifiedNotifier) notify(result detectors.ResultWithMetadata) {
+	if n == nil || !result.Verified {
+		return
+	}
+	secret := VerifiedSecret{
+		DetectorType:   result.DetectorType,
+		DetectorName:   result.DetectorName,
+		Redacted:       result.Redacted,
+		SourceType:     result.SourceType,
+		SourceName:     result.SourceName,
+		SourceMetadata: result.SourceMetadata,
+		VerifiedAt:     result.VerifiedAt,
+	}
+	select {
+	case n.queue <- secret:
+	default:
+		n.dropped.Add(1)
+	}
+}
+
+// close waits for the queued notifications to be delivered. No more may be
+// queued afterwards.
+func (n *verifiedNotifier) close(ctx context.Context) {
+	if n == nil {
+		return
+	}
+	close(n.queue)
+	<-n.done
+	if dropped := n.dropped.Load(); dropped > 0 {
+		ctx.Logger().Info("WARNING: dropped notifications of verified secrets because the hook was too slow", "dropped", dropped)
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/spotifykey"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// verifiedRecorder records the notifications of verified secrets, after
+// waiting for release if it's set.
+type verifiedRecorder struct {
+	mu       sync.Mutex
+	secrets  []VerifiedSecret
+	release  chan struct{}
+	received chan struct{}
+}
+
+func (r *verifiedRecorder) OnVerified(secret VerifiedSecret) {
+	if r.received != nil {
+		r.received <- struct{}{}
+	}
+	if r.release != nil {
+		<-r.release
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = append(r.secrets, secret)
+}
+
+func TestWithVerifiedHook(t *testing.T) {
+	const (
+		clientID       = "8d3f8e2a9b7c4d1e6f5a0b9c8d7e6f5a"
+		clientSecret   = "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d"
+		revokedID      = "0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c"
+		revokedSecret  = "6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a"
+		configContents = "spotify client_id: " + clientID + "\nspotify secret: " + clientSecret + "\n"
+		revokedContent = "spotify client_id: " + revokedID + "\nspotify secret: " + revokedSecret + "\n"
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, _, _ := r.BasicAuth(); id != clientID {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(configPath, []byte(configContents), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "revoked.yaml"), []byte(revokedContent), 0644))
+
+	detector := &spotifykey.Scanner{}
+	assert.NoError(t, detector.SetEndpoints(server.URL))
+	hook := &verifiedRecorder{}
+	ctx := context.Background()
+	e := Start(ctx, WithConcurrency(1), WithDetectors(true, detector), WithVerifiedHook(hook))
+	assert.NoError(t, e.ScanFileSystem(ctx, sources.FilesystemConfig{Paths: []string{dir}}))
+	go e.Finish(ctx, func(error, string, ...any) {})
+
+	var results []detectors.ResultWithMetadata
+	for result := range e.ResultsChan() {
+		results = append(results, result)
+	}
+	assert.Len(t, results, 2)
+
+	// Only the verified secret is notified, with its redacted form.
+	if assert.Len(t, hook.secrets, 1) {
+		secret := hook.secrets[0]
+		assert.Equal(t, detectorspb.DetectorType_SpotifyKey, secret.DetectorType)
+		assert.Equal(t, clientID, secret.Redacted)
+		assert.Equal(t, configPath, secret.SourceMetadata.GetFilesystem().GetFile())
+		assert.False(t, secret.VerifiedAt.IsZero())
+	}
+}
+
+func TestVerifiedNotifier_NonBlocking(t *testing.T) {
+	hook := &verifiedRecorder{release: make(chan struct{}), received: make(chan struct{}, verifiedQueueSize+2)}
+	n := newVerifiedNotifier(hook)
+	result := detectors.ResultWithMetadata{Result: detectors.Result{Verified: true, Redacted: "redacted"}}
+
+	// The hook is stuck on the first notification, and the queue fills up.
+	n.notify(result)
+	<-hook.received
+	start := time.Now()
+	for i := 0; i < verifiedQueueSize+10; i++ {
+		n.notify(result)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int64(10), n.dropped.Load())
+
+	// Unverified results aren't notified, even with room in the queue.
+	close(hook.release)
+	n.close(context.Background())
+	n = newVerifiedNotifier(hook)
+	n.notify(detectors.ResultWithMetadata{})
+	n.close(context.Background())
+	assert.Len(t, hook.secrets, verifiedQueueSize+1)
+}
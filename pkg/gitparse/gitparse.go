@@ -127,8 +127,9 @@ func (c *Parser) RepoPath(ctx context.Context, source string, head string, abbre
 	} else {
 		args = append(args, "--all")
 	}
-	for _, glob := range excludedGlobs {
-		args = append(args, "--", ".", fmt.Sprintf(":(exclude)%s", glob))
+	if len(excludedGlobs) > 0 {
+		args = append(args, "--", ".")
+		args = append(args, excludePathspecs(excludedGlobs)...)
 	}
 
 	cmd := exec.Command("git", args...)
@@ -140,6 +141,26 @@ func (c *Parser) RepoPath(ctx context.Context, source string, head string, abbre
 	return c.executeCommand(ctx, cmd)
 }
 
+// excludePathspecs converts globs into git pathspecs that exclude the same
+// paths as a common.GlobFilter. Unlike the filter, a glob pathspec that
+// matches a directory doesn't match its contents, so a second pathspec is
+// added for them.
+func excludePathspecs(globs []string) []string {
+	pathspecs := make([]string, 0, 2*len(globs))
+	for _, glob := range globs {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		glob = common.NormalizeGlob(glob)
+		pathspecs = append(pathspecs,
+			fmt.Sprintf(":(exclude,glob)%s", glob),
+			fmt.Sprintf(":(exclude,glob)%s/**", glob),
+		)
+	}
+	return pathspecs
+}
+
 // Staged parses the output of the `git diff` command for the `source` path.
 func (c *Parser) Staged(ctx context.Context, source string) (chan Commit, error) {
 	// Provide the --cached flag to diff to get the diff of the staged changes.
@@ -3,10 +3,15 @@ package gitparse
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 )
 
@@ -403,3 +408,68 @@ Secret Access Key: 6GKmgiS3EyIBJbeSp7sQ+0PoJrPZjPUg8SF6zYz7
 
 okay thank you bye
 `
+
+func TestRepoPath_ExcludeGlobs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	files := []string{
+		"a.txt", "dir/b.txt", "dir/a.go", "dir/sub/a.go", "other/dir/a.go",
+		"pkg/testdata/y/z", "pkg/testdatax/y", "vendor/a.go", "pkg/vendor/b.go",
+		"docs/sub/b.md", "pkg/docs/c.md", "file1.c", "file10.c", "b.env", "a.env",
+	}
+	repo := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(repo, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	// The git parser must exclude exactly the paths the shared glob filter
+	// matches, as the filesystem source uses the filter directly.
+	patterns := []string{"*.txt", "/*.txt", "dir/*.go", "dir/**/*.go", "**/testdata", "vendor/", "docs/**", "file?.[ch]", "[!a]*.env"}
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			filter, err := common.NewGlobFilter([]string{pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, file := range files {
+				if !filter.Match(file) {
+					want = append(want, file)
+				}
+			}
+
+			commitChan, err := NewParser().RepoPath(context.Background(), repo, "", false, []string{pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for commit := range commitChan {
+				for _, diff := range commit.Diffs {
+					got = append(got, diff.PathB)
+				}
+			}
+			sort.Strings(got)
+			sort.Strings(want)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("pattern %q:\n got: %v\nwant: %v", pattern, got, want)
+			}
+		})
+	}
+}
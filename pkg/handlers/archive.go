@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/mholt/archiver/v4"
@@ -18,6 +20,7 @@ type ctxKey int
 
 const (
 	depthKey ctxKey = iota
+	entryPathKey
 )
 
 var (
@@ -28,7 +31,10 @@ var (
 
 // Archive is a handler for extracting and decompressing archives.
 type Archive struct {
-	size int
+	size    int
+	include *common.GlobFilter
+	exclude *common.GlobFilter
+	guard   *decompressionGuard
 }
 
 // New sets a default maximum size and current size counter.
@@ -51,19 +57,46 @@ func SetArchiveMaxTimeout(timeout time.Duration) {
 	maxTimeout = timeout
 }
 
+// archiveEntryData is data extracted from an archive, along with the
+// sanitized path of the entry it was extracted from.
+type archiveEntryData struct {
+	entryPath string
+	data      []byte
+}
+
 // FromFile extracts the files from an archive.
 func (d *Archive) FromFile(originalCtx context.Context, data io.Reader) chan ([]byte) {
-	archiveChan := make(chan ([]byte), 512)
+	dataChan := make(chan ([]byte), 512)
+	go func() {
+		defer close(dataChan)
+		for entry := range d.fromFileEntries(originalCtx, data) {
+			dataChan <- entry.data
+		}
+	}()
+	return dataChan
+}
+
+// fromFileEntries extracts the files from an archive like FromFile, along
+// with the paths of the entries they were extracted from.
+func (d *Archive) fromFileEntries(originalCtx context.Context, data io.Reader) chan archiveEntryData {
+	archiveChan := make(chan archiveEntryData, 512)
 	go func() {
 		ctx, cancel := context.WithTimeout(originalCtx, maxTimeout)
 		logger := logContext.AddLogger(ctx).Logger()
 		defer cancel()
 		defer close(archiveChan)
+		if d.guard != nil {
+			data = d.guard.wrap(data)
+		}
 		err := d.openArchive(ctx, 0, data, archiveChan)
 		if err != nil {
 			if errors.Is(err, archiver.ErrNoMatch) {
 				return
 			}
+			if errors.Is(err, ErrDecompressionBomb) {
+				logger.Error(err, "Aborted unarchiving chunk.")
+				return
+			}
 			logger.V(2).Info("Error unarchiving chunk.")
 		}
 	}()
@@ -71,18 +104,23 @@ func (d *Archive) FromFile(originalCtx context.Context, data io.Reader) chan ([]
 }
 
 // openArchive takes a reader and extracts the contents up to the maximum depth.
-func (d *Archive) openArchive(ctx context.Context, depth int, reader io.Reader, archiveChan chan ([]byte)) error {
+func (d *Archive) openArchive(ctx context.Context, depth int, reader io.Reader, archiveChan chan archiveEntryData) error {
 	if depth >= maxDepth {
 		return fmt.Errorf("max archive depth reached")
 	}
 	format, reader, err := archiver.Identify("", reader)
 	if err != nil {
 		if errors.Is(err, archiver.ErrNoMatch) && depth > 0 {
+			// A decompressed entry is only known to be a plain file here.
+			entryPath, ok := ctx.Value(entryPathKey).(string)
+			if ok && !d.included(entryPath) {
+				return nil
+			}
 			chunkSize := 10 * 1024
 			for {
 				chunk := make([]byte, chunkSize)
 				n, _ := reader.Read(chunk)
-				archiveChan <- chunk
+				archiveChan <- archiveEntryData{entryPath: entryPath, data: chunk}
 				if n < chunkSize {
 					break
 				}
@@ -129,7 +167,7 @@ func (d *Archive) IsFiletype(ctx context.Context, reader io.Reader) (io.Reader,
 }
 
 // extractorHandler is applied to each file in an archiver.Extractor file.
-func (d *Archive) extractorHandler(archiveChan chan ([]byte)) func(context.Context, archiver.File) error {
+func (d *Archive) extractorHandler(archiveChan chan archiveEntryData) func(context.Context, archiver.File) error {
 	return func(ctx context.Context, f archiver.File) error {
 		logger := logContext.AddLogger(ctx).Logger()
 		logger.V(5).Info("Handling extracted file.", "filename", f.Name())
@@ -137,18 +175,35 @@ func (d *Archive) extractorHandler(archiveChan chan ([]byte)) func(context.Conte
 		if ctxDepth, ok := ctx.Value(depthKey).(int); ok {
 			depth = ctxDepth
 		}
+		entryPath := SanitizeEntryPath(f.NameInArchive)
+		if parent, ok := ctx.Value(entryPathKey).(string); ok {
+			entryPath = path.Join(parent, entryPath)
+		}
+		if f.IsDir() || d.exclude.Match(entryPath) {
+			return nil
+		}
 
 		fReader, err := f.Open()
 		if err != nil {
 			return err
 		}
-		fileBytes, err := d.ReadToMax(ctx, fReader)
+		defer fReader.Close()
+
+		var reader io.Reader = fReader
+		if !d.included(entryPath) {
+			var isArchive bool
+			if reader, isArchive = d.IsFiletype(ctx, reader); !isArchive {
+				logger.V(5).Info("Skipping archive entry not matching include globs.", "filename", entryPath)
+				return nil
+			}
+		}
+		fileBytes, err := d.ReadToMax(ctx, reader)
 		if err != nil {
 			return err
 		}
 		fileContent := bytes.NewReader(fileBytes)
 
-		err = d.openArchive(ctx, depth, fileContent, archiveChan)
+		err = d.openArchive(context.WithValue(ctx, entryPathKey, entryPath), depth, fileContent, archiveChan)
 		if err != nil {
 			return err
 		}
@@ -156,6 +211,24 @@ func (d *Archive) extractorHandler(archiveChan chan ([]byte)) func(context.Conte
 	}
 }
 
+// SanitizeEntryPath returns the path of an archive entry named name relative
+// to the root of the archive, so that it can't point outside of it. Backslash
+// separators and drive letters are normalized, absolute paths are made
+// relative, and ".." elements can't go above the root. The root itself is "".
+func SanitizeEntryPath(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if len(name) >= 2 && name[1] == ':' && ('a' <= name[0]|0x20 && name[0]|0x20 <= 'z') {
+		name = name[2:]
+	}
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// included returns whether the archive entry at entryPath matches the include
+// globs. Every entry is included if there are none.
+func (d *Archive) included(entryPath string) bool {
+	return len(d.include.Patterns()) == 0 || d.include.Match(entryPath)
+}
+
 // ReadToMax reads up to the max size.
 func (d *Archive) ReadToMax(ctx context.Context, reader io.Reader) (data []byte, err error) {
 	// Archiver v4 is in alpha and using an experimental version of
@@ -186,6 +259,9 @@ func (d *Archive) ReadToMax(ctx context.Context, reader io.Reader) (data []byte,
 			return []byte{}, err
 		}
 		d.size += bRead
+		if err := d.guard.check(int64(d.size)); err != nil {
+			return nil, err
+		}
 		if len(fileChunk) > 0 {
 			fileContent.Write(fileChunk[0:bRead])
 		}
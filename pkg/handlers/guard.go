@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDecompressionBomb is returned when extracting a file produces more data
+// than its decompression limits allow.
+var ErrDecompressionBomb = errors.New("decompression bomb detected")
+
+// minRatioCheckSize is the amount of extracted data below which the
+// compression ratio isn't enforced, since small inputs that compress well
+// would otherwise trip it.
+const minRatioCheckSize = 1024 * 1024 // 1MB
+
+// decompressionGuard bounds the data extracted from a single input. The
+// extracted size is compared to the number of bytes read from the input so
+// far, so a bomb is caught while it's being extracted rather than after.
+type decompressionGuard struct {
+	// maxRatio is the largest allowed multiple of the input size, and
+	// maxExtracted the largest allowed total. Zero disables a limit.
+	maxRatio     int
+	maxExtracted int64
+	input        *countingReader
+}
+
+// wrap returns a reader for r that counts the bytes read from it.
+func (g *decompressionGuard) wrap(r io.Reader) io.Reader {
+	g.input = &countingReader{reader: r}
+	return g.input
+}
+
+// check returns an error wrapping ErrDecompressionBomb if extracted exceeds
+// the limits.
+func (g *decompressionGuard) check(extracted int64) error {
+	if g == nil {
+		return nil
+	}
+	if g.maxExtracted > 0 && extracted > g.maxExtracted {
+		return fmt.Errorf("%w: extracted more than the limit of %d bytes", ErrDecompressionBomb, g.maxExtracted)
+	}
+	if g.maxRatio <= 0 || g.input == nil || extracted < minRatioCheckSize {
+		return nil
+	}
+	if read := g.input.n; extracted > int64(g.maxRatio)*read {
+		return fmt.Errorf("%w: extracted %d bytes from %d input bytes, more than %d times the input",
+			ErrDecompressionBomb, extracted, read, g.maxRatio)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 )
 
@@ -19,11 +20,45 @@ type Handler interface {
 	New()
 }
 
-func HandleFile(ctx context.Context, file io.Reader, chunkSkel *sources.Chunk, chunksChan chan (*sources.Chunk)) bool {
+// Option configures a Handler for a single HandleFile call.
+type Option func(Handler)
+
+// WithArchiveEntryGlobs restricts which archive entries are scanned. An
+// entry is scanned if its path within the archive matches include, or include
+// has no patterns, and it does not match exclude. Entries of nested archives
+// are matched by their path prefixed with the path of the containing entry.
+// Entries that don't match include are still searched for nested archives,
+// but are otherwise skipped without being read.
+func WithArchiveEntryGlobs(include, exclude *common.GlobFilter) Option {
+	return func(h Handler) {
+		if a, ok := h.(*Archive); ok {
+			a.include = include
+			a.exclude = exclude
+		}
+	}
+}
+
+// WithDecompressionLimits aborts extracting an archive with an error wrapping
+// ErrDecompressionBomb once the extracted data exceeds maxRatio times the
+// number of bytes read from the file, or maxExtracted bytes in total. The
+// ratio is only enforced past the first megabyte extracted. Zero disables a
+// limit.
+func WithDecompressionLimits(maxRatio int, maxExtracted int64) Option {
+	return func(h Handler) {
+		if a, ok := h.(*Archive); ok {
+			a.guard = &decompressionGuard{maxRatio: maxRatio, maxExtracted: maxExtracted}
+		}
+	}
+}
+
+func HandleFile(ctx context.Context, file io.Reader, chunkSkel *sources.Chunk, chunksChan chan (*sources.Chunk), opts ...Option) bool {
 	// Find a handler for this file.
 	var handler Handler
 	for _, h := range DefaultHandlers() {
 		h.New()
+		for _, opt := range opts {
+			opt(h)
+		}
 		var isType bool
 		if file, isType = h.IsFiletype(ctx, file); isType {
 			handler = h
@@ -34,17 +69,32 @@ func HandleFile(ctx context.Context, file io.Reader, chunkSkel *sources.Chunk, c
 		return false
 	}
 
-	// Process the file and read all []byte chunks from handlerChan.
-	handlerChan := handler.FromFile(ctx, file)
+	// Process the file and read all chunks from handlerChan. Archives also
+	// report the entry each chunk was extracted from.
+	var handlerChan chan archiveEntryData
+	if archive, ok := handler.(*Archive); ok {
+		handlerChan = archive.fromFileEntries(ctx, file)
+	} else {
+		handlerChan = make(chan archiveEntryData)
+		go func() {
+			defer close(handlerChan)
+			for data := range handler.FromFile(ctx, file) {
+				if err := common.CancellableWrite(ctx, handlerChan, archiveEntryData{data: data}); err != nil {
+					return
+				}
+			}
+		}()
+	}
 	for {
 		select {
-		case data, open := <-handlerChan:
+		case entry, open := <-handlerChan:
 			if !open {
 				// We finished reading everything from handlerChan.
 				return true
 			}
 			chunk := *chunkSkel
-			chunk.Data = data
+			chunk.Data = entry.data
+			chunk.ArchiveEntry = entry.entryPath
 			// Send data on chunksChan.
 			select {
 			case chunksChan <- &chunk:
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// tarArchive returns a tar archive containing files, in order.
+func tarArchive(t *testing.T, files ...[2][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, file := range files {
+		assert.NoError(t, w.WriteHeader(&tar.Header{Name: string(file[0]), Mode: 0600, Size: int64(len(file[1]))}))
+		_, err := w.Write(file[1])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func entry(name, content string) [2][]byte {
+	return [2][]byte{[]byte(name), []byte(content)}
+}
+
+func TestHandleFile_ArchiveEntryGlobs(t *testing.T) {
+	layer := tarArchive(t,
+		entry("srv/.env", "layer env"),
+		entry("srv/readme.txt", "layer readme"),
+	)
+	archive := tarArchive(t,
+		entry("app/.env", "app env"),
+		entry("app/tls/server.pem", "app pem"),
+		entry("app/main.go", "app source"),
+		entry("vendor/lib/.env", "vendored env"),
+		entry("logs/app.log.gz", string(gzipped(t, []byte("rotated log")))),
+		entry("layer.tar", string(layer)),
+	)
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "no globs",
+			want: []string{"app env", "app pem", "app source", "layer env", "layer readme", "rotated log", "vendored env"},
+		},
+		{
+			name:    "include",
+			include: []string{"**/.env", "*.pem"},
+			want:    []string{"app env", "app pem", "layer env", "vendored env"},
+		},
+		{
+			name:    "include and exclude",
+			include: []string{".env"},
+			exclude: []string{"vendor/"},
+			want:    []string{"app env", "layer env"},
+		},
+		{
+			name:    "exclude nested archive",
+			exclude: []string{"layer.tar", "*.gz"},
+			want:    []string{"app env", "app pem", "app source", "vendored env"},
+		},
+		{
+			name:    "include decompressed entry",
+			include: []string{"logs/"},
+			want:    []string{"rotated log"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			include, err := common.NewGlobFilter(tt.include)
+			assert.NoError(t, err)
+			exclude, err := common.NewGlobFilter(tt.exclude)
+			assert.NoError(t, err)
+
+			chunksChan := make(chan *sources.Chunk, 16)
+			handled := HandleFile(context.Background(), bytes.NewReader(archive), &sources.Chunk{}, chunksChan, WithArchiveEntryGlobs(include, exclude))
+			assert.True(t, handled)
+			close(chunksChan)
+
+			var got []string
+			for chunk := range chunksChan {
+				got = append(got, string(bytes.TrimRight(chunk.Data, "\x00")))
+			}
+			sort.Strings(got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestArchive_DecompressionLimits(t *testing.T) {
+	// 4MB of zeros compresses to a few KB, a ratio of about 1000.
+	bomb := gzipped(t, tarArchive(t, entry("zeros", string(make([]byte, 4*1024*1024)))))
+	small := gzipped(t, tarArchive(t, entry("config", "password=hunter2")))
+
+	tests := []struct {
+		name         string
+		data         []byte
+		maxRatio     int
+		maxExtracted int64
+		wantErr      bool
+	}{
+		{name: "ratio exceeded", data: bomb, maxRatio: 100, wantErr: true},
+		{name: "ratio within limit", data: bomb, maxRatio: 10000},
+		{name: "extracted size exceeded", data: bomb, maxExtracted: 1024 * 1024, wantErr: true},
+		{name: "small archive with a high ratio", data: small, maxRatio: 1, maxExtracted: 1024 * 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Archive{}
+			a.New()
+			WithDecompressionLimits(tt.maxRatio, tt.maxExtracted)(a)
+
+			archiveChan := make(chan archiveEntryData, 1024)
+			err := a.openArchive(context.Background(), 0, a.guard.wrap(bytes.NewReader(tt.data)), archiveChan)
+			close(archiveChan)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDecompressionBomb)
+				assert.Empty(t, archiveChan)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, archiveChan)
+		})
+	}
+}
+
+func TestHandleFile_ArchiveEntryPaths(t *testing.T) {
+	layer := tarArchive(t, entry("../../root/.ssh/id_rsa", "nested traversal"))
+	archive := tarArchive(t,
+		entry("../../etc/passwd", "traversal"),
+		entry("/etc/shadow", "absolute"),
+		entry(`..\..\windows\win.ini`, "backslashes"),
+		entry("app/./config/../.env", "unclean"),
+		entry("layer.tar", string(layer)),
+	)
+
+	chunksChan := make(chan *sources.Chunk, 16)
+	assert.True(t, HandleFile(context.Background(), bytes.NewReader(archive), &sources.Chunk{}, chunksChan))
+	close(chunksChan)
+
+	got := map[string]string{}
+	for chunk := range chunksChan {
+		got[string(bytes.TrimRight(chunk.Data, "\x00"))] = chunk.ArchiveEntry
+	}
+	assert.Equal(t, map[string]string{
+		"traversal":        "etc/passwd",
+		"absolute":         "etc/shadow",
+		"backslashes":      "windows/win.ini",
+		"unclean":          "app/.env",
+		"nested traversal": "layer.tar/root/.ssh/id_rsa",
+	}, got)
+}
+
+func TestSanitizeEntryPath(t *testing.T) {
+	tests := map[string]string{
+		"a/b.txt":            "a/b.txt",
+		"../../etc/passwd":   "etc/passwd",
+		"a/../../b":          "b",
+		"/abs/path":          "abs/path",
+		`C:\Windows\win.ini`: "Windows/win.ini",
+		`..\evil.sh`:         "evil.sh",
+		"./a//b/":            "a/b",
+		"..":                 "",
+		"":                   "",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, SanitizeEntryPath(name), name)
+	}
+}
@@ -20,6 +20,10 @@ func PrintJSON(r *detectors.ResultWithMetadata) error {
 		SourceType sourcespb.SourceType
 		// SourceName is the name of the Source.
 		SourceName string
+		// SourceUnitID identifies the unit the result was found in, such as a file.
+		SourceUnitID string `json:",omitempty"`
+		// ArchiveEntry is the path of the archive entry the result was found in, if any.
+		ArchiveEntry string `json:",omitempty"`
 		// DetectorType is the type of Detector.
 		DetectorType detectorspb.DetectorType
 		// DetectorName is the string name of the DetectorType.
@@ -27,6 +31,12 @@ func PrintJSON(r *detectors.ResultWithMetadata) error {
 		// DecoderName is the string name of the DecoderType.
 		DecoderName string
 		Verified    bool
+		// Expired is whether the secret had expired, if the detector knows when it expires.
+		Expired *bool `json:",omitempty"`
+		// Severity is the string name of the Severity, if the detector classified the result.
+		Severity string `json:",omitempty"`
+		// Confidence is the detector's 0-100 confidence score, if the detector scored the result.
+		Confidence int `json:",omitempty"`
 		// Raw contains the raw secret data.
 		Raw string
 		// RawV2 contains the raw secret identifier that is a combination of both the ID and the secret.
@@ -37,20 +47,33 @@ func PrintJSON(r *detectors.ResultWithMetadata) error {
 		Redacted       string
 		ExtraData      map[string]string
 		StructuredData *detectorspb.StructuredData
+		// VerificationError is set when verification could not determine whether the secret is valid.
+		VerificationError string `json:",omitempty"`
+		// Trace holds the detector's decisions for the result, if tracing is enabled.
+		Trace []detectors.TraceStep `json:",omitempty"`
 	}{
 		SourceMetadata: r.SourceMetadata,
 		SourceID:       r.SourceID,
 		SourceType:     r.SourceType,
 		SourceName:     r.SourceName,
+		SourceUnitID:   r.SourceUnitID,
+		ArchiveEntry:   r.ArchiveEntry,
 		DetectorType:   r.DetectorType,
 		DetectorName:   r.DetectorType.String(),
 		DecoderName:    r.DecoderType.String(),
 		Verified:       r.Verified,
+		Expired:        r.Expired,
+		Severity:       r.Severity.String(),
+		Confidence:     r.Confidence,
 		Raw:            string(r.Raw),
 		RawV2:          string(r.RawV2),
 		Redacted:       r.Redacted,
 		ExtraData:      r.ExtraData,
 		StructuredData: r.StructuredData,
+		Trace:          r.Trace,
+	}
+	if r.VerificationError != nil {
+		v.VerificationError = r.VerificationError.Error()
 	}
 	out, err := json.Marshal(v)
 	if err != nil {
@@ -44,7 +44,13 @@ func PrintPlainOutput(r *detectors.ResultWithMetadata) error {
 	}
 	printer.Printf("Detector Type: %s\n", out.DetectorType)
 	printer.Printf("Decoder Type: %s\n", out.DecoderType)
+	if r.Result.Severity != detectors.SeverityUnset {
+		printer.Printf("Severity: %s\n", r.Result.Severity)
+	}
 	printer.Printf("Raw result: %s\n", whitePrinter.Sprint(out.Raw))
+	if r.Result.VerificationError != nil {
+		printer.Printf("Verification issue: %s\n", r.Result.VerificationError)
+	}
 
 	for k, v := range r.Result.ExtraData {
 		printer.Printf(
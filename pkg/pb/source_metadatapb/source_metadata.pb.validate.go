@@ -849,6 +849,14 @@ func (m *Filesystem) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Label
+
+	// no validation rules for RelativePath
+
+	// no validation rules for ReferencedBy
+
+	// no validation rules for Encoding
+
 	if len(errors) > 0 {
 		return FilesystemMultiError(errors)
 	}
@@ -0,0 +1,52 @@
+package sources
+
+// Capability is an optional feature a Source can support.
+type Capability int
+
+const (
+	// CapabilityEnumerable indicates the source implements SourceUnitEnumerator.
+	CapabilityEnumerable Capability = iota
+	// CapabilityUnitChunkable indicates the source implements SourceUnitChunker.
+	CapabilityUnitChunkable
+	// CapabilityUnmarshallable indicates the source implements SourceUnitUnmarshaller.
+	CapabilityUnmarshallable
+)
+
+func (c Capability) String() string {
+	switch c {
+	case CapabilityEnumerable:
+		return "Enumerable"
+	case CapabilityUnitChunkable:
+		return "UnitChunkable"
+	case CapabilityUnmarshallable:
+		return "Unmarshallable"
+	default:
+		return "Unknown"
+	}
+}
+
+// Capabilities returns the optional capabilities supported by the source,
+// determined by which of the optional interfaces it implements.
+func Capabilities(s Source) []Capability {
+	var capabilities []Capability
+	if _, ok := s.(SourceUnitEnumerator); ok {
+		capabilities = append(capabilities, CapabilityEnumerable)
+	}
+	if _, ok := s.(SourceUnitChunker); ok {
+		capabilities = append(capabilities, CapabilityUnitChunkable)
+	}
+	if _, ok := s.(SourceUnitUnmarshaller); ok {
+		capabilities = append(capabilities, CapabilityUnmarshallable)
+	}
+	return capabilities
+}
+
+// HasCapability returns true if the source supports the provided capability.
+func HasCapability(s Source, capability Capability) bool {
+	for _, c := range Capabilities(s) {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+type baseSource struct{ Progress }
+
+func (*baseSource) Type() sourcespb.SourceType { return sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM }
+func (*baseSource) SourceID() int64            { return 0 }
+func (*baseSource) JobID() int64               { return 0 }
+func (*baseSource) Init(context.Context, string, int64, int64, bool, *anypb.Any, int) error {
+	return nil
+}
+func (*baseSource) Chunks(context.Context, chan *Chunk) error { return nil }
+
+type unmarshallableSource struct {
+	baseSource
+	CommonSourceUnitUnmarshaller
+}
+
+func TestCapabilities(t *testing.T) {
+	assert.Empty(t, Capabilities(&baseSource{}))
+	assert.Equal(t, []Capability{CapabilityUnmarshallable}, Capabilities(&unmarshallableSource{}))
+	assert.True(t, HasCapability(&unmarshallableSource{}, CapabilityUnmarshallable))
+	assert.False(t, HasCapability(&unmarshallableSource{}, CapabilityEnumerable))
+}
@@ -0,0 +1,14 @@
+package sources
+
+import "github.com/trufflesecurity/trufflehog/v3/pkg/context"
+
+// CheckpointReporter receives the IDs of the units a source finished
+// scanning, so that a coordinator can persist the progress of a scan outside
+// of the source, such as in a database, and skip the completed units when the
+// scan is restarted. Implementations must be safe for concurrent use.
+type CheckpointReporter interface {
+	// ReportCheckpoint is called once every chunk of the unit was sent.
+	// Units that failed, were skipped or whose scan was cancelled aren't
+	// reported. An error is logged by the source, which keeps scanning.
+	ReportCheckpoint(ctx context.Context, unitID string) error
+}
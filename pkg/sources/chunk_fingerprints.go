@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ChunkFingerprints is a set of fingerprints of previously scanned chunks,
+// persisted to disk so that identical chunks can be skipped by subsequent
+// runs. A chunk's fingerprint covers both its source metadata, which
+// identifies where it came from, and its data, so only the unchanged chunks of
+// a partially changed file are skipped.
+type ChunkFingerprints struct {
+	mu   sync.Mutex
+	path string
+	// previous contains the fingerprints recorded by the last run.
+	previous map[string]struct{}
+	// current contains the fingerprints of every chunk seen this run.
+	current map[string]struct{}
+}
+
+type chunkFingerprintsFile struct {
+	Fingerprints []string `json:"fingerprints"`
+}
+
+// LoadChunkFingerprints loads the fingerprints stored at path. A missing file
+// results in an empty set.
+func LoadChunkFingerprints(path string) (*ChunkFingerprints, error) {
+	f := &ChunkFingerprints{
+		path:     path,
+		previous: make(map[string]struct{}),
+		current:  make(map[string]struct{}),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("unable to read chunk fingerprints: %w", err)
+	}
+	var stored chunkFingerprintsFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("unable to parse chunk fingerprints: %w", err)
+	}
+	for _, fingerprint := range stored.Fingerprints {
+		f.previous[fingerprint] = struct{}{}
+	}
+	return f, nil
+}
+
+// Seen records the chunk's fingerprint and returns true if it was also
+// recorded by the previous run, meaning the chunk can be skipped.
+func (f *ChunkFingerprints) Seen(chunk *Chunk) bool {
+	fingerprint, err := chunkFingerprint(chunk)
+	if err != nil {
+		// Scan chunks that can't be fingerprinted.
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current[fingerprint] = struct{}{}
+	_, ok := f.previous[fingerprint]
+	return ok
+}
+
+// Save persists the fingerprints of the chunks seen this run, replacing those
+// of the previous run.
+func (f *ChunkFingerprints) Save() error {
+	f.mu.Lock()
+	fingerprints := make([]string, 0, len(f.current))
+	for fingerprint := range f.current {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	f.mu.Unlock()
+	sort.Strings(fingerprints)
+
+	data, err := json.Marshal(chunkFingerprintsFile{Fingerprints: fingerprints})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, data, 0600)
+}
+
+func chunkFingerprint(chunk *Chunk) (string, error) {
+	metadata, err := proto.MarshalOptions{Deterministic: true}.Marshal(chunk.SourceMetadata)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d:%d:", chunk.SourceType, len(metadata))
+	hash.Write(metadata)
+	hash.Write(chunk.Data)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
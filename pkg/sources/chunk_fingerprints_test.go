@@ -0,0 +1,63 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+func fileChunk(file, data string) *Chunk {
+	return &Chunk{
+		SourceType: sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM,
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: file},
+			},
+		},
+		Data: []byte(data),
+	}
+}
+
+func TestChunkFingerprints_TwoRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+
+	// First run: nothing has been seen before.
+	first, err := LoadChunkFingerprints(path)
+	assert.NoError(t, err)
+	for _, chunk := range []*Chunk{
+		fileChunk("a.txt", "first chunk"),
+		fileChunk("a.txt", "second chunk"),
+		fileChunk("b.txt", "other file"),
+	} {
+		assert.False(t, first.Seen(chunk))
+	}
+	assert.NoError(t, first.Save())
+
+	// Second run: only the changed chunk of a.txt is scanned.
+	second, err := LoadChunkFingerprints(path)
+	assert.NoError(t, err)
+	assert.True(t, second.Seen(fileChunk("a.txt", "first chunk")))
+	assert.False(t, second.Seen(fileChunk("a.txt", "second chunk, changed")))
+	assert.True(t, second.Seen(fileChunk("b.txt", "other file")))
+	// The same data from a different location is not skipped.
+	assert.False(t, second.Seen(fileChunk("c.txt", "other file")))
+	assert.NoError(t, second.Save())
+
+	// Fingerprints of chunks that weren't seen in the second run are dropped.
+	third, err := LoadChunkFingerprints(path)
+	assert.NoError(t, err)
+	assert.False(t, third.Seen(fileChunk("a.txt", "second chunk")))
+	assert.True(t, third.Seen(fileChunk("a.txt", "second chunk, changed")))
+}
+
+func TestLoadChunkFingerprints_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err := LoadChunkFingerprints(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package sources
+
+// ConfigFieldType is the kind of value a configuration field holds, which
+// determines how a UI lets users enter it.
+type ConfigFieldType string
+
+const (
+	// ConfigFieldString is a single line of text.
+	ConfigFieldString ConfigFieldType = "string"
+	// ConfigFieldStrings is a list of strings.
+	ConfigFieldStrings ConfigFieldType = "strings"
+	// ConfigFieldStringMap is a map of string keys to string values.
+	ConfigFieldStringMap ConfigFieldType = "string_map"
+	// ConfigFieldBool is a boolean toggle.
+	ConfigFieldBool ConfigFieldType = "bool"
+	// ConfigFieldInt is an integer.
+	ConfigFieldInt ConfigFieldType = "int"
+	// ConfigFieldFloat is a floating point number.
+	ConfigFieldFloat ConfigFieldType = "float"
+	// ConfigFieldDuration is a time.Duration, entered as a string such as
+	// "5s".
+	ConfigFieldDuration ConfigFieldType = "duration"
+	// ConfigFieldFilter is a common.Filter, built from files listing the
+	// path patterns to include and exclude.
+	ConfigFieldFilter ConfigFieldType = "filter"
+)
+
+// ConfigField describes a field of a source configuration struct.
+type ConfigField struct {
+	// Name is the name of the struct field.
+	Name string
+	Type ConfigFieldType
+	// Required is set for fields the source can't run without.
+	Required bool
+	// Secret is set for fields holding credentials, which should be masked
+	// when entered and never displayed.
+	Secret bool
+	// Description is a short help text for the field.
+	Description string
+}
+
+// ConfigSchemer is implemented by source configurations to describe the fields
+// users can set, so that UIs such as the TUI can render a form for any source
+// without knowing its configuration. Fields that can only be set in code, such
+// as hooks, aren't described.
+type ConfigSchemer interface {
+	ConfigSchema() []ConfigField
+}
+
+// Ensure the configurations satisfy the interface at compile time.
+var (
+	_ ConfigSchemer = GCSConfig{}
+	_ ConfigSchemer = GitConfig{}
+	_ ConfigSchemer = GithubConfig{}
+	_ ConfigSchemer = GitlabConfig{}
+	_ ConfigSchemer = FilesystemConfig{}
+	_ ConfigSchemer = S3Config{}
+	_ ConfigSchemer = SyslogConfig{}
+)
+
+// ConfigSchema describes the fields of a GCS source configuration.
+func (GCSConfig) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "ProjectID", Type: ConfigFieldString, Required: true, Description: "Project ID of the buckets to scan."},
+		{Name: "CloudCred", Type: ConfigFieldBool, Description: "Use Application Default Credentials to authenticate."},
+		{Name: "ServiceAccount", Type: ConfigFieldString, Description: "Path to a service account key file."},
+		{Name: "ApiKey", Type: ConfigFieldString, Secret: true, Description: "API key used to authenticate."},
+		{Name: "WithoutAuth", Type: ConfigFieldBool, Description: "Scan public buckets without authenticating."},
+		{Name: "IncludeBuckets", Type: ConfigFieldStrings, Description: "Buckets to scan. All buckets are scanned if empty."},
+		{Name: "ExcludeBuckets", Type: ConfigFieldStrings, Description: "Buckets to skip."},
+		{Name: "IncludeObjects", Type: ConfigFieldStrings, Description: "Objects to scan. All objects are scanned if empty."},
+		{Name: "ExcludeObjects", Type: ConfigFieldStrings, Description: "Objects to skip."},
+		{Name: "MaxObjectSize", Type: ConfigFieldInt, Description: "Maximum size in bytes of the objects scanned."},
+		{Name: "Concurrency", Type: ConfigFieldInt, Description: "Number of concurrent workers."},
+	}
+}
+
+// ConfigSchema describes the fields of a git source configuration.
+func (GitConfig) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "RepoPath", Type: ConfigFieldString, Required: true, Description: "Path or URL of the repository to scan."},
+		{Name: "BaseRef", Type: ConfigFieldString, Description: "Commit to stop scanning at."},
+		{Name: "HeadRef", Type: ConfigFieldString, Description: "Branch or commit to start scanning from."},
+		{Name: "MaxDepth", Type: ConfigFieldInt, Description: "Maximum number of commits to scan."},
+		{Name: "Filter", Type: ConfigFieldFilter, Description: "Paths to include in and exclude from the scan."},
+		{Name: "ExcludeGlobs", Type: ConfigFieldStrings, Description: "Globs of paths to exclude from git log."},
+	}
+}
+
+// ConfigSchema describes the fields of a GitHub source configuration.
+func (GithubConfig) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "Endpoint", Type: ConfigFieldString, Description: "GitHub API endpoint, for GitHub Enterprise."},
+		{Name: "Token", Type: ConfigFieldString, Secret: true, Description: "GitHub token used to authenticate."},
+		{Name: "Repos", Type: ConfigFieldStrings, Description: "Repositories to scan."},
+		{Name: "Orgs", Type: ConfigFieldStrings, Description: "Organizations whose repositories are scanned."},
+		{Name: "IncludeRepos", Type: ConfigFieldStrings, Description: "Repositories of the organizations to scan."},
+		{Name: "ExcludeRepos", Type: ConfigFieldStrings, Description: "Repositories of the organizations to skip."},
+		{Name: "IncludeForks", Type: ConfigFieldBool, Description: "Scan forks."},
+		{Name: "IncludeMembers", Type: ConfigFieldBool, Description: "Scan the repositories of organization members."},
+		{Name: "Concurrency", Type: ConfigFieldInt, Description: "Number of concurrent workers."},
+		{Name: "Filter", Type: ConfigFieldFilter, Description: "Paths to include in and exclude from the scan."},
+	}
+}
+
+// ConfigSchema describes the fields of a GitLab source configuration.
+func (GitlabConfig) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "Endpoint", Type: ConfigFieldString, Description: "GitLab endpoint, for self-hosted instances."},
+		{Name: "Token", Type: ConfigFieldString, Required: true, Secret: true, Description: "GitLab token used to authenticate."},
+		{Name: "Repos", Type: ConfigFieldStrings, Description: "Repositories to scan. All accessible repositories are scanned if empty."},
+		{Name: "Filter", Type: ConfigFieldFilter, Description: "Paths to include in and exclude from the scan."},
+	}
+}
+
+// ConfigSchema describes the fields of a filesystem source configuration.
+func (FilesystemConfig) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "Paths", Type: ConfigFieldStrings, Required: true, Description: "Files and directories to scan."},
+		{Name: "Filter", Type: ConfigFieldFilter, Description: "Paths to include in and exclude from the scan."},
+		{Name: "ExcludeGlobs", Type: ConfigFieldStrings, Description: "Globs of paths relative to each directory to skip."},
+		{Name: "ExcludeFalsePositivePaths", Type: ConfigFieldBool, Description: "Skip files known to produce false positives, such as minified assets."},
+		{Name: "FalsePositivePaths", Type: ConfigFieldStrings, Description: "Globs of further files known to produce false positives."},
+		{Name: "HighSignalOnly", Type: ConfigFieldBool, Description: "Only scan files in locations strongly associated with secrets, such as dotenv files."},
+		{Name: "HighSignalPaths", Type: ConfigFieldStrings, Description: "Globs of further files scanned with HighSignalOnly."},
+		{Name: "SkipDirs", Type: ConfigFieldStrings, Description: "Names of directories never descended into."},
+		{Name: "NoDefaultSkipDirs", Type: ConfigFieldBool, Description: "Descend into directories such as .git and node_modules."},
+		{Name: "HiddenFiles", Type: ConfigFieldString, Description: `Hidden files to scan: "include", "exclude" or "only".`},
+		{Name: "FollowFileSymlinks", Type: ConfigFieldBool, Description: "Scan the targets of symlinks to files once, and only within the paths unless AllowSymlinkEscape is set."},
+		{Name: "AllowSymlinkEscape", Type: ConfigFieldBool, Description: "Scan the targets of followed symlinks outside of the paths."},
+		{Name: "ScanReferencedCredentials", Type: ConfigFieldBool, Description: "Scan the credential files used by the package manager of lockfiles."},
+		{Name: "ContentTypeAllow", Type: ConfigFieldStrings, Description: `Content types of the files to scan, such as "text/*".`},
+		{Name: "ScanFileAttributes", Type: ConfigFieldBool, Description: "Scan extended attributes or alternate data streams of files."},
+		{Name: "ScanImageTarballs", Type: ConfigFieldBool, Description: "Scan the files of container image tarballs layer by layer."},
+		{Name: "ResolveGitLFS", Type: ConfigFieldBool, Description: "Scan the cached objects of Git LFS pointer files."},
+		{Name: "DecodeBase64", Type: ConfigFieldBool, Description: "Scan base64 encoded content found in files."},
+		{Name: "DetectEncoding", Type: ConfigFieldBool, Description: "Transcode UTF-16 and Latin-1 files to UTF-8."},
+		{Name: "EmitEmptyFiles", Type: ConfigFieldBool, Description: "Emit a chunk for each empty file."},
+		{Name: "AnnotateGit", Type: ConfigFieldBool, Description: "Record the git author of files in working trees."},
+		{Name: "Labels", Type: ConfigFieldStringMap, Description: "Labels attached to findings, by path prefix."},
+		{Name: "DiffPath", Type: ConfigFieldString, Description: "Unified diff whose added lines are the only ones scanned."},
+		{Name: "ManifestPath", Type: ConfigFieldString, Description: "JSON manifest of further paths to scan with per-path options."},
+		{Name: "SizePercentileCutoff", Type: ConfigFieldInt, Description: "Percentile of file sizes above which files are skipped. 0 means none."},
+		{Name: "HeadBytes", Type: ConfigFieldInt, Description: "Number of bytes scanned at the start of each file. 0 means all."},
+		{Name: "WholeFileThreshold", Type: ConfigFieldInt, Description: "Size in bytes below which files are scanned as a single chunk."},
+		{Name: "MaxOpenFiles", Type: ConfigFieldInt, Description: "Maximum number of files open at once."},
+		{Name: "ChunkBatchSize", Type: ConfigFieldInt, Description: "Number of chunks per batch when scanning units. 0 disables batching."},
+		{Name: "ReadBytesPerSecond", Type: ConfigFieldInt, Description: "Maximum read rate. 0 means unlimited."},
+		{Name: "SampleRate", Type: ConfigFieldFloat, Description: "Fraction of the files in directories to scan, for triage. 0 scans all."},
+		{Name: "ProgressInterval", Type: ConfigFieldDuration, Description: "Interval of progress updates during the scan. 0 updates per path."},
+		{Name: "SkipCachePath", Type: ConfigFieldString, Description: "File recording scanned files, to skip unchanged ones."},
+		{Name: "SkipCacheContentHash", Type: ConfigFieldBool, Description: "Detect changes by content hash rather than size and time."},
+		{Name: "ForceRescan", Type: ConfigFieldBool, Description: "Scan every file regardless of the skip cache."},
+		{Name: "ArchiveIncludeGlobs", Type: ConfigFieldStrings, Description: "Globs of archive entries to scan."},
+		{Name: "ArchiveExcludeGlobs", Type: ConfigFieldStrings, Description: "Globs of archive entries to skip."},
+		{Name: "ArchiveMaxRatio", Type: ConfigFieldInt, Description: "Maximum decompression ratio of archives. 0 means unlimited."},
+		{Name: "ArchiveMaxExtractedSize", Type: ConfigFieldInt, Description: "Maximum bytes extracted from an archive. 0 means unlimited."},
+	}
+}
+
+// ConfigSchema describes the fields of an S3 source configuration.
+func (S3Config) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "Buckets", Type: ConfigFieldStrings, Description: "Buckets to scan. All accessible buckets are scanned if empty."},
+		{Name: "CloudCred", Type: ConfigFieldBool, Description: "Use credentials from the environment to authenticate."},
+		{Name: "Key", Type: ConfigFieldString, Description: "Access key ID used to authenticate."},
+		{Name: "Secret", Type: ConfigFieldString, Secret: true, Description: "Secret access key used to authenticate."},
+		{Name: "SessionToken", Type: ConfigFieldString, Secret: true, Description: "Session token of temporary credentials."},
+		{Name: "MaxObjectSize", Type: ConfigFieldInt, Description: "Maximum size in bytes of the objects scanned."},
+	}
+}
+
+// ConfigSchema describes the fields of a syslog source configuration.
+func (SyslogConfig) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "Address", Type: ConfigFieldString, Required: true, Description: "Address to listen on, such as 0.0.0.0:514."},
+		{Name: "Protocol", Type: ConfigFieldString, Required: true, Description: `Protocol to listen with: "tcp" or "udp".`},
+		{Name: "Format", Type: ConfigFieldString, Required: true, Description: `Log format: "rfc3164" or "rfc5424".`},
+		{Name: "CertPath", Type: ConfigFieldString, Description: "Path to the TLS certificate."},
+		{Name: "KeyPath", Type: ConfigFieldString, Description: "Path to the TLS key."},
+		{Name: "Concurrency", Type: ConfigFieldInt, Description: "Number of concurrent workers."},
+	}
+}
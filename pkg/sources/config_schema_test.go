@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilesystemConfig_ConfigSchema(t *testing.T) {
+	fields := map[string]ConfigField{}
+	for _, field := range (FilesystemConfig{}).ConfigSchema() {
+		fields[field.Name] = field
+	}
+
+	assert.Equal(t, ConfigField{Name: "Paths", Type: ConfigFieldStrings, Required: true, Description: "Files and directories to scan."}, fields["Paths"])
+	assert.Equal(t, ConfigFieldFilter, fields["Filter"].Type)
+	assert.Equal(t, ConfigFieldStringMap, fields["Labels"].Type)
+	assert.Equal(t, ConfigFieldBool, fields["FollowFileSymlinks"].Type)
+	assert.Equal(t, ConfigFieldInt, fields["MaxOpenFiles"].Type)
+	// Hooks can only be set in code.
+	assert.NotContains(t, fields, "WarningReporter")
+	assert.NotContains(t, fields, "PathSanitizer")
+}
+
+func TestConfigSchema_MatchesStructs(t *testing.T) {
+	kinds := map[ConfigFieldType][]reflect.Kind{
+		ConfigFieldString:    {reflect.String},
+		ConfigFieldStrings:   {reflect.Slice},
+		ConfigFieldStringMap: {reflect.Map},
+		ConfigFieldBool:      {reflect.Bool},
+		ConfigFieldInt:       {reflect.Int, reflect.Int64},
+		ConfigFieldFloat:     {reflect.Float64},
+		ConfigFieldDuration:  {reflect.Int64},
+		ConfigFieldFilter:    {reflect.Pointer},
+	}
+	for _, config := range []ConfigSchemer{GCSConfig{}, GitConfig{}, GithubConfig{}, GitlabConfig{}, FilesystemConfig{}, S3Config{}, SyslogConfig{}} {
+		typ := reflect.TypeOf(config)
+		seen := map[string]bool{}
+		for _, field := range config.ConfigSchema() {
+			assert.False(t, seen[field.Name], "%s.%s is described twice", typ.Name(), field.Name)
+			seen[field.Name] = true
+			structField, ok := typ.FieldByName(field.Name)
+			if !assert.True(t, ok, "%s has no field %s", typ.Name(), field.Name) {
+				continue
+			}
+			assert.Contains(t, kinds[field.Type], structField.Type.Kind(), "%s.%s", typ.Name(), field.Name)
+			assert.NotEmpty(t, field.Description, "%s.%s", typ.Name(), field.Name)
+		}
+	}
+}
@@ -2,21 +2,31 @@ package filesystem
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	diskbufferreader "github.com/bill-rich/disk-buffer-reader"
 	"github.com/go-errors/errors"
 	"github.com/go-logr/logr"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
@@ -28,6 +38,37 @@ const (
 	// Having a peek size larger than that ensures that we have complete credential coverage in our chunks.
 	BufferSize = 10 * 1024 // 10KB
 	PeekSize   = 3 * 1024  // 3KB
+
+	// maxDecodeDepth is the maximum number of nested base64 encodings that
+	// will be decoded from a single chunk.
+	maxDecodeDepth = 3
+	// maxDecodeSize is the largest chunk that will be searched for base64
+	// encoded content.
+	maxDecodeSize = 1024 * 1024 // 1MB
+
+	// DefaultMaxOpenFiles is the number of files that are open at once while
+	// scanning when no limit is configured. It is well below the common soft
+	// limit of 1024 file descriptors, leaving room for the rest of the
+	// process such as archive extraction and verification requests.
+	DefaultMaxOpenFiles = 256
+
+	// DefaultChunkBatchSize is the number of results ChunkUnitBatches
+	// groups into each batch when no size is configured.
+	DefaultChunkBatchSize = 64
+)
+
+// Keys and values of the metadata attached to enumerated units.
+const (
+	UnitMetadataKind = "kind"
+	UnitMetadataSize = "size"
+
+	UnitKindFile = "file"
+	UnitKindDir  = "dir"
+)
+
+var (
+	errUnableToStat   = errors.New("unable to stat file")
+	errNotRegularFile = errors.New("not a regular file")
 )
 
 type Source struct {
@@ -36,16 +77,145 @@ type Source struct {
 	jobId    int64
 	verify   bool
 	paths    []string
-	log      logr.Logger
-	filter   *common.Filter
+	// concurrency is the number of paths enumerated at once. Values below
+	// two enumerate the paths serially, in order.
+	concurrency int
+	log         logr.Logger
+	filter      *common.Filter
+	// excludeGlobs matches paths, relative to the scanned directory, that
+	// are not scanned.
+	excludeGlobs *common.GlobFilter
+	// falsePositivePaths matches paths, relative to the scanned directory,
+	// of files known to produce false positives, which are not scanned.
+	falsePositivePaths *common.GlobFilter
+	// highSignalPaths, if set, matches paths, relative to the scanned
+	// directory, of the only files that are scanned, along with those of
+	// detector hints.
+	highSignalPaths *common.GlobFilter
+	// wholeFileThreshold is the file size below which the entire file is
+	// emitted as a single chunk.
+	wholeFileThreshold int64
+	decodeBase64       bool
+	// skipDirs is the set of directory base names that are not walked.
+	skipDirs map[string]struct{}
+	// transcode enables detecting the encoding of files and transcoding
+	// UTF-16 and Latin-1 content to UTF-8 before it's chunked.
+	transcode bool
+	// resolveGitLFS enables scanning the objects Git LFS pointer files point
+	// to in the local LFS cache instead of the pointer files.
+	resolveGitLFS bool
+	// contentTypes is the allowlist of the content types of files that are
+	// scanned. All files are scanned if it's empty.
+	contentTypes contentTypeAllowlist
+	// scanAttributes enables scanning the extended attributes or alternate
+	// data streams of each file.
+	scanAttributes bool
+	// imageTarballs enables scanning the files of the container images in
+	// image tarballs instead of the tarballs themselves.
+	imageTarballs bool
+	// emptyFileChunks enables emitting a chunk without data for each empty
+	// file, which otherwise produces no chunks.
+	emptyFileChunks bool
+	// hiddenFiles selects the files scanned when walking a directory based
+	// on whether they're hidden. The zero value scans all files.
+	hiddenFiles HiddenFilesMode
+	// warnings receives structured warnings for problems encountered while
+	// scanning, in addition to them being logged.
+	warnings sources.WarningReporter
+	// hook receives the lifecycle events of the source, if set.
+	hook sources.LifecycleHook
+	// checkpoints receives the ID of each file once it was scanned, if set.
+	checkpoints sources.CheckpointReporter
+	// completedUnits is the set of IDs of the files completed by a previous
+	// scan, which are skipped.
+	completedUnits map[string]struct{}
+	// skipCache persists fingerprints of scanned files across runs so that
+	// unchanged files can be skipped.
+	skipCache *skipCache
+	// readLimiter throttles file reads when a read rate limit is configured.
+	readLimiter *rate.Limiter
+	// headBytes is the maximum number of bytes read from each file, or zero
+	// to read the whole file.
+	headBytes int64
+	// sizePercentile is the percentile of the sizes of the files to scan
+	// above which files are skipped, or zero to scan files of any size.
+	// sizeCutoff is the corresponding size, found by surveySizeCutoff.
+	sizePercentile int
+	sizeCutoff     atomic.Int64
+	// diff restricts scanning to the lines added by a diff when set.
+	diff *diffScan
+	// archiveInclude and archiveExclude select the archive entries that are
+	// scanned.
+	archiveInclude *common.GlobFilter
+	archiveExclude *common.GlobFilter
+	// archiveMaxRatio and archiveMaxExtracted are the decompression limits
+	// for archives, see handlers.WithDecompressionLimits.
+	archiveMaxRatio     int
+	archiveMaxExtracted int64
+	// labels maps cleaned path prefixes to the label attached to the
+	// metadata of chunks from files under them.
+	labels map[string]string
+	// pathSpecs maps the cleaned paths added by WithPathSpecs to their
+	// options.
+	pathSpecs map[string]PathSpec
+	// scanReferenced enables scanning the credential files used by the
+	// package managers of lockfiles found during the scan.
+	scanReferenced bool
+	referenced     pathSet
+	// followFileSymlinks resolves the file symlinks found while walking
+	// directories, to scan each target once and only if it's in scope.
+	// Directory symlinks are never followed.
+	followFileSymlinks bool
+	symlinkTargets     pathSet
+	// allowSymlinkEscape enables following file symlinks whose target is
+	// outside of the scanned paths.
+	allowSymlinkEscape bool
+	// openFiles limits the number of files open at once. Each scanned file
+	// holds one permit while it's open.
+	openFiles *semaphore.Weighted
+	// chunkBatchSize is the number of results per batch sent by
+	// ChunkUnitBatches.
+	chunkBatchSize int
+	// annotateGit enables recording the author of files in git working
+	// trees in chunk metadata. gitAuthors caches the authors of files.
+	annotateGit bool
+	gitAuthors  gitAuthors
+	// sampleRate is the fraction of the files found while walking
+	// directories that are scanned, or zero to scan all of them.
+	sampleRate float64
+	// progressInterval is the interval at which Chunks updates the progress
+	// message with the file being scanned, or zero to only update it per
+	// path.
+	progressInterval time.Duration
+	// pauseGate halts scanning between files while the source is paused.
+	pauseGate pauseGate
+	// stats accumulates the ScanSummary of the latest call to Chunks.
+	stats scanStats
+	// pathSanitizer transforms the paths stored in chunk metadata, if set.
+	pathSanitizer func(path string) string
 	sources.Progress
 	sources.CommonSourceUnitUnmarshaller
 }
 
+// DefaultSkipDirs returns the directory names that are skipped by default when
+// walking a directory, as they rarely contain secrets and can be very large.
+func DefaultSkipDirs() []string {
+	return []string{
+		".git",
+		"node_modules",
+		"vendor",
+		".terraform",
+	}
+}
+
 // Ensure the Source satisfies the interfaces at compile time
 var _ sources.Source = (*Source)(nil)
 var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
 var _ sources.SourceUnitEnumerator = (*Source)(nil)
+var _ sources.SourceUnitChunker = (*Source)(nil)
+var _ sources.SourceUnitBatchChunker = (*Source)(nil)
+var _ sources.Pauser = (*Source)(nil)
+var _ sources.Resettable = (*Source)(nil)
 
 // Type returns the type of source.
 // It is used for matching source types in configuration and job input.
@@ -62,20 +232,41 @@ func (s *Source) JobID() int64 {
 }
 
 // Init returns an initialized Filesystem source.
-func (s *Source) Init(aCtx context.Context, name string, jobId, sourceId int64, verify bool, connection *anypb.Any, _ int) error {
+func (s *Source) Init(aCtx context.Context, name string, jobId, sourceId int64, verify bool, connection *anypb.Any, concurrency int) (err error) {
+	start := time.Now()
+	defer func() { s.emit(sources.EventInitialized, "", start, err) }()
 	s.log = aCtx.Logger()
 
 	s.name = name
 	s.sourceId = sourceId
 	s.jobId = jobId
 	s.verify = verify
+	s.concurrency = concurrency
+	if s.openFiles == nil {
+		s.WithMaxOpenFiles(0)
+	}
 
 	var conn sourcespb.Filesystem
 	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
 		return errors.WrapPrefix(err, "error unmarshalling connection", 0)
 	}
-	s.paths = append(conn.Paths, conn.Directories...)
+	s.paths = expandPaths(append(conn.Paths, conn.Directories...))
+
+	return nil
+}
 
+// Reset prepares the source for another scan of the same paths. It clears
+// the progress, the summary, the files already scanned as referenced
+// credential files or symlink targets, the size cutoff and any pause, and
+// keeps the configuration, the skip cache and the limiters.
+func (s *Source) Reset(ctx context.Context) error {
+	s.ClearProgress()
+	s.stats.reset(time.Time{})
+	s.referenced.reset()
+	s.symlinkTargets.reset()
+	s.sizeCutoff.Store(0)
+	s.gitAuthors.reset()
+	s.pauseGate.resume()
 	return nil
 }
 
@@ -83,8 +274,364 @@ func (s *Source) WithFilter(filter *common.Filter) {
 	s.filter = filter
 }
 
-// Chunks emits chunks of bytes over a channel.
-func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) error {
+// WithExcludeGlobs configures the source to skip files and directories whose
+// path relative to the scanned directory matches globs.
+func (s *Source) WithExcludeGlobs(globs *common.GlobFilter) {
+	s.excludeGlobs = globs
+}
+
+// WithArchiveEntryGlobs configures the source to only scan the archive
+// entries matching include, or all entries if it has no patterns, that don't
+// match exclude. See handlers.WithArchiveEntryGlobs.
+func (s *Source) WithArchiveEntryGlobs(include, exclude *common.GlobFilter) {
+	s.archiveInclude = include
+	s.archiveExclude = exclude
+}
+
+// WithDecompressionLimits configures the source to abort extracting archives
+// that expand to more than maxRatio times their size or maxExtracted bytes.
+// See handlers.WithDecompressionLimits.
+func (s *Source) WithDecompressionLimits(maxRatio int, maxExtracted int64) {
+	s.archiveMaxRatio = maxRatio
+	s.archiveMaxExtracted = maxExtracted
+}
+
+// WithReferencedCredentialFiles configures the source to also scan the
+// credential files read by the package manager of each lockfile it finds,
+// even if they are outside of the scanned paths. See scanReferencedFiles.
+func (s *Source) WithReferencedCredentialFiles(enabled bool) {
+	s.scanReferenced = enabled
+}
+
+// WithFileSymlinks configures the source to resolve the symlinks to files
+// found while walking directories, and scan each target at most once: not at
+// all if it's under a scanned path, where it's scanned anyway, and only if
+// allowed with WithSymlinkEscape if it's outside of them. Otherwise symlinks
+// to files are scanned like the files themselves, once per symlink. Symlinks
+// to directories are always skipped to avoid loops. See followSymlink.
+func (s *Source) WithFileSymlinks(enabled bool) {
+	s.followFileSymlinks = enabled
+}
+
+// WithSymlinkEscape configures the source to also scan the targets of file
+// symlinks that are outside of the scanned paths, when they're followed. They
+// are skipped otherwise, so that a crafted symlink can't make the scan read
+// arbitrary files such as ones under /etc. Either way, each such target is
+// logged.
+func (s *Source) WithSymlinkEscape(allowed bool) {
+	s.allowSymlinkEscape = allowed
+}
+
+// WithLabels configures the source to attach a label to the metadata of
+// chunks from files under each path prefix in labels. If several prefixes
+// match a file, the longest one wins.
+func (s *Source) WithLabels(labels map[string]string) {
+	s.labels = make(map[string]string, len(labels))
+	for prefix, label := range labels {
+		s.labels[normalizePath(prefix)] = label
+	}
+}
+
+// labelFor returns the label of the longest configured prefix or path spec
+// containing path, or an empty string if there is none.
+func (s *Source) labelFor(path string) string {
+	var label, longest string
+	for prefix, l := range s.labels {
+		if len(prefix) > len(longest) && hasPathPrefix(path, prefix) {
+			label, longest = l, prefix
+		}
+	}
+	for prefix, spec := range s.pathSpecs {
+		if spec.Label != "" && len(prefix) > len(longest) && hasPathPrefix(path, prefix) {
+			label, longest = spec.Label, prefix
+		}
+	}
+	return label
+}
+
+// WithWholeFileThreshold configures the source to emit files smaller than
+// threshold bytes as a single chunk rather than splitting them into
+// BufferSize chunks. A threshold of zero disables this behavior.
+func (s *Source) WithWholeFileThreshold(threshold int64) {
+	s.wholeFileThreshold = threshold
+}
+
+// WithSkipDirs configures the source to never descend into directories whose
+// base name matches one of dirs.
+func (s *Source) WithSkipDirs(dirs []string) {
+	s.skipDirs = make(map[string]struct{}, len(dirs))
+	for _, dir := range dirs {
+		s.skipDirs[dir] = struct{}{}
+	}
+}
+
+// WithHiddenFiles configures which files are scanned when walking a
+// directory based on whether they're hidden, see HiddenFilesMode. Paths
+// configured directly are scanned regardless.
+func (s *Source) WithHiddenFiles(mode HiddenFilesMode) {
+	s.hiddenFiles = mode
+}
+
+// WithEmptyFileChunks configures the source to emit a chunk with empty Data
+// for each zero-byte file, so that every scanned file appears in the output.
+func (s *Source) WithEmptyFileChunks(enabled bool) {
+	s.emptyFileChunks = enabled
+}
+
+// WithEncodingDetection configures the source to detect the encoding of each
+// file it reads and transcode UTF-16 and Latin-1 files to UTF-8, so that
+// secrets in them match detectors. The original encoding is recorded in the
+// Encoding of the chunks' metadata. Binary files are left alone.
+func (s *Source) WithEncodingDetection(enabled bool) {
+	s.transcode = enabled
+}
+
+// WithGitLFS configures the source to scan the object each Git LFS pointer
+// file points to, read from the local LFS cache of the repository the pointer
+// is in, in place of the pointer file. Pointers whose object isn't cached are
+// skipped.
+func (s *Source) WithGitLFS(enabled bool) {
+	s.resolveGitLFS = enabled
+}
+
+// WithContentTypeAllowlist configures the source to only scan files whose
+// content type, detected from their first bytes regardless of their name, is
+// one of types. Types are media types such as "application/json", or
+// wildcards such as "text/*". All files are scanned if types is empty.
+func (s *Source) WithContentTypeAllowlist(types []string) {
+	s.contentTypes = newContentTypeAllowlist(types)
+}
+
+// WithFileAttributes configures the source to also scan the extended
+// attributes of each file on Linux and macOS, or its alternate data streams on
+// Windows, which reading the file's content misses. Their chunks are reported
+// as the path of the file followed by a colon and the attribute's name. It
+// has no effect on other platforms.
+func (s *Source) WithFileAttributes(enabled bool) {
+	s.scanAttributes = enabled
+}
+
+// WithImageTarballs configures the source to scan the files of container
+// images saved as tarballs, such as by `docker save`, layer by layer rather
+// than the tarball itself. Files deleted or replaced by an upper layer are
+// skipped in the layers below, and chunks carry Docker metadata with the
+// digest of the layer and the path of the file in the image. Other tarballs
+// are scanned as usual.
+func (s *Source) WithImageTarballs(enabled bool) {
+	s.imageTarballs = enabled
+}
+
+// WithBase64Decoding configures the source to emit an additional chunk for
+// each level of base64 encoded content found in a chunk's data.
+func (s *Source) WithBase64Decoding(enabled bool) {
+	s.decodeBase64 = enabled
+}
+
+// WithWarningReporter configures the source to emit structured warnings to
+// reporter for paths that could not be scanned.
+func (s *Source) WithWarningReporter(reporter sources.WarningReporter) {
+	s.warnings = reporter
+}
+
+// WithLifecycleHook configures the source to emit its lifecycle events to
+// hook. It must be called before Init for the EventInitialized event to be
+// emitted.
+func (s *Source) WithLifecycleHook(hook sources.LifecycleHook) {
+	s.hook = hook
+}
+
+// WithCheckpointReporter sets the reporter that receives the ID of each file
+// once all of its chunks were sent, which is the path stored in the chunks'
+// metadata.
+func (s *Source) WithCheckpointReporter(reporter sources.CheckpointReporter) {
+	s.checkpoints = reporter
+}
+
+// WithCompletedUnits skips the files with the given IDs, as reported to a
+// CheckpointReporter by a previous scan, to resume it.
+func (s *Source) WithCompletedUnits(unitIDs []string) {
+	if len(unitIDs) == 0 {
+		s.completedUnits = nil
+		return
+	}
+	s.completedUnits = make(map[string]struct{}, len(unitIDs))
+	for _, id := range unitIDs {
+		s.completedUnits[id] = struct{}{}
+	}
+}
+
+// reportCheckpoint reports that the file with unitID was scanned.
+func (s *Source) reportCheckpoint(ctx context.Context, unitID string) {
+	if s.checkpoints == nil {
+		return
+	}
+	if err := s.checkpoints.ReportCheckpoint(ctx, unitID); err != nil {
+		ctx.Logger().Error(err, "unable to report checkpoint", "unit", unitID)
+	}
+}
+
+// completed reports whether the file with unitID was completed by a previous
+// scan.
+func (s *Source) completed(unitID string) bool {
+	_, ok := s.completedUnits[unitID]
+	return ok
+}
+
+// WithPathSanitizer configures the source to transform the paths it stores in
+// chunk metadata with fn, for example to mask user names or tenant IDs in
+// reports. fn is applied after paths are made valid UTF-8, and doesn't affect
+// which files are scanned or how they're labeled.
+func (s *Source) WithPathSanitizer(fn func(path string) string) {
+	s.pathSanitizer = fn
+}
+
+// WithMaxOpenFiles limits the number of files the source has open at once to
+// n, so that concurrent scans don't exhaust the process's file descriptors.
+// Zero means DefaultMaxOpenFiles.
+func (s *Source) WithMaxOpenFiles(n int) {
+	if n <= 0 {
+		n = DefaultMaxOpenFiles
+	}
+	s.openFiles = semaphore.NewWeighted(int64(n))
+}
+
+// WithChunkBatchSize sets the number of results ChunkUnitBatches groups into
+// each batch. Zero means DefaultChunkBatchSize.
+func (s *Source) WithChunkBatchSize(n int) {
+	s.chunkBatchSize = n
+}
+
+// WithProgressInterval updates the progress message with the file being
+// scanned and the counts so far every interval while Chunks runs, so that
+// scans of few large paths still report progress. Zero only updates it when
+// each path is started.
+func (s *Source) WithProgressInterval(interval time.Duration) {
+	s.progressInterval = interval
+}
+
+// WithReadRateLimit limits the rate at which file contents are read to
+// bytesPerSecond, shared across all files. Zero means unlimited.
+func (s *Source) WithReadRateLimit(bytesPerSecond int64) {
+	if bytesPerSecond <= 0 {
+		s.readLimiter = nil
+		return
+	}
+	s.readLimiter = newReadLimiter(bytesPerSecond)
+}
+
+// WithHeadBytes limits scanning to the first n bytes of each file. Zero means
+// the whole file is scanned.
+func (s *Source) WithHeadBytes(n int64) {
+	s.headBytes = n
+}
+
+// WithSizePercentileCutoff configures the source to skip files larger than the
+// given percentile of the sizes of the files to scan, such as the largest 10%
+// for a percentile of 90, so that outliers like data files don't slow down
+// the scan without having to pick a size limit for each tree. This requires a
+// pre-pass over the paths to survey file sizes before scanning starts. Zero
+// disables the cutoff.
+func (s *Source) WithSizePercentileCutoff(percentile int) {
+	s.sizePercentile = percentile
+	s.sizeCutoff.Store(0)
+}
+
+// WithDiff configures the source to scan only the lines added by the unified
+// diff read from r, instead of walking its paths. File paths in the diff are
+// resolved relative to root.
+func (s *Source) WithDiff(r io.Reader, root string) error {
+	files, err := parseUnifiedDiff(r)
+	if err != nil {
+		return err
+	}
+	s.diff = &diffScan{root: root, files: files}
+	return nil
+}
+
+// WithSkipCache configures the source to skip files that are unchanged since
+// they were last scanned, as recorded in the cache file at path. If
+// forceRescan is set, every file is scanned but the cache is still updated.
+func (s *Source) WithSkipCache(path string, mode SkipCacheMode, forceRescan bool) error {
+	cache, err := loadSkipCache(path, mode, forceRescan)
+	if err != nil {
+		return err
+	}
+	s.skipCache = cache
+	return nil
+}
+
+// reportWarning emits a structured warning for path if a WarningReporter is
+// configured.
+func (s *Source) reportWarning(code sources.WarningCode, path string, err error) {
+	if s.warnings == nil {
+		return
+	}
+	s.warnings.ReportWarning(sources.SourceWarning{
+		Code:    code,
+		Message: err.Error(),
+		Path:    path,
+	})
+}
+
+// emit sends a lifecycle event to the hook, if one is configured. The event's
+// Duration is the time since start, unless start is zero.
+func (s *Source) emit(kind sources.LifecycleEventKind, unit string, start time.Time, err error) {
+	if s.hook == nil {
+		return
+	}
+	now := time.Now()
+	event := sources.LifecycleEvent{
+		Kind:       kind,
+		SourceName: s.name,
+		Unit:       unit,
+		Time:       now,
+		Err:        err,
+	}
+	if !start.IsZero() {
+		event.Duration = now.Sub(start)
+	}
+	s.hook.OnLifecycleEvent(event)
+}
+
+// scanErrorCode returns the WarningCode that best describes an error returned
+// from scanFile.
+func scanErrorCode(err error) sources.WarningCode {
+	switch {
+	case errors.Is(err, errUnableToStat):
+		return sources.WarningUnableToStat
+	case errors.Is(err, errNotRegularFile):
+		return sources.WarningNotRegularFile
+	default:
+		return sources.WarningScanError
+	}
+}
+
+// Chunks emits chunks of bytes over a channel. Each chunk is sent as soon as
+// it is read, and reading blocks until it is received.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) (err error) {
+	start := time.Now()
+	s.emit(sources.EventChunkingStarted, "", time.Time{}, nil)
+	defer func() { s.emit(sources.EventChunkingFinished, "", start, err) }()
+
+	s.stats.reset(start)
+	defer s.stats.finish()
+	chunksChan, wait := s.stats.countChunks(ctx, chunksChan)
+	defer wait()
+	if s.progressInterval > 0 {
+		defer s.reportProgress(ctx, s.progressInterval)()
+	}
+
+	if s.diff != nil {
+		if err := s.scanDiff(ctx, chunksChan); err != nil && !common.IsDone(ctx) {
+			return err
+		}
+		return nil
+	}
+
+	s.surveySizeCutoff(ctx)
+	if s.sampleRate > 0 && s.sampleRate < 1 {
+		ctx.Logger().Info("scanning a sample of the files in directories, the scan is not complete", "sample_rate", s.sampleRate)
+	}
 	for i, path := range s.paths {
 		logger := ctx.Logger().WithValues("path", path)
 		if common.IsDone(ctx) {
@@ -92,21 +639,37 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 		}
 		s.SetProgressComplete(i, len(s.paths), fmt.Sprintf("Path: %s", path), "")
 
-		cleanPath := filepath.Clean(path)
+		cleanPath := normalizePath(path)
 		fileInfo, err := os.Stat(cleanPath)
 		if err != nil {
 			logger.Error(err, "unable to get file info")
+			s.reportWarning(sources.WarningUnableToStat, cleanPath, err)
+			s.emit(sources.EventError, cleanPath, time.Time{}, err)
+			s.stats.filesFailed.Add(1)
 			continue
 		}
 
 		if fileInfo.IsDir() {
 			err = s.scanDir(ctx, cleanPath, chunksChan)
+		} else if !s.excludeGlobs.Match(fileInfo.Name()) {
+			err = s.scanFile(ctx, cleanPath, filepath.Base(cleanPath), chunksChan)
 		} else {
-			err = s.scanFile(ctx, cleanPath, chunksChan)
+			s.stats.filesSkipped.Add(1)
 		}
 
+		if common.IsDone(ctx) {
+			break
+		}
 		if err != nil && err != io.EOF {
 			logger.Info("error scanning filesystem", "error", err)
+			s.reportWarning(scanErrorCode(err), cleanPath, err)
+			s.emit(sources.EventError, cleanPath, time.Time{}, err)
+		}
+	}
+
+	if s.skipCache != nil {
+		if err := s.skipCache.save(); err != nil {
+			ctx.Logger().Error(err, "unable to save skip cache")
 		}
 	}
 	return nil
@@ -117,7 +680,46 @@ func (s *Source) scanDir(ctx context.Context, path string, chunksChan chan *sour
 		if err != nil {
 			return nil
 		}
-		fullPath := filepath.Join(path, relativePath)
+		if relativePath != "." && s.excludeGlobs.Match(relativePath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
+		if relativePath != "." && s.falsePositivePaths.Match(relativePath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			ctx.Logger().V(4).Info("skipping file known to produce false positives", "path", relativePath)
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
+		if d.IsDir() && relativePath != "." {
+			if _, ok := s.skipDirs[d.Name()]; ok {
+				return fs.SkipDir
+			}
+		}
+		if s.hiddenFiles == HiddenFilesExclude && isHidden(relativePath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
+		if s.hiddenFiles == HiddenFilesOnly && !d.IsDir() && !isHidden(relativePath) {
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
+		if !d.IsDir() && !s.highSignal(relativePath) {
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
+		fullPath := joinPath(path, relativePath)
+		if d.Type()&fs.ModeSymlink != 0 && s.followFileSymlinks && !s.followSymlink(ctx, fullPath) {
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
 
 		// Skip over non-regular files. We do this check here to suppress noisy
 		// logs for trying to scan directories and other non-regular files in
@@ -125,59 +727,240 @@ func (s *Source) scanDir(ctx context.Context, path string, chunksChan chan *sour
 		fileStat, err := os.Stat(fullPath)
 		if err != nil {
 			ctx.Logger().Info("unable to stat file", "path", fullPath, "error", err)
+			s.reportWarning(sources.WarningUnableToStat, fullPath, err)
+			s.stats.filesFailed.Add(1)
 			return nil
 		}
 		if !fileStat.Mode().IsRegular() {
 			return nil
 		}
 		if s.filter != nil && !s.filter.Pass(fullPath) {
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
+		if !s.specFor(fullPath).Filter.Pass(fullPath) {
+			s.stats.filesSkipped.Add(1)
+			return nil
+		}
+		if !s.sampled(relativePath) {
+			s.stats.filesSkipped.Add(1)
 			return nil
 		}
 
-		if err = s.scanFile(ctx, fullPath, chunksChan); err != nil {
+		if err = s.scanFile(ctx, fullPath, filepath.FromSlash(relativePath), chunksChan); err != nil {
+			// Stop walking once the scan has been cancelled.
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
 			ctx.Logger().Info("error scanning file", "path", fullPath, "error", err)
+			s.reportWarning(scanErrorCode(err), fullPath, err)
 		}
 		return nil
 	})
 }
 
-func (s *Source) scanFile(ctx context.Context, path string, chunksChan chan *sources.Chunk) error {
+// scanFile emits the chunks of the file at path. relativePath is the path of
+// the file relative to the scanned path it was found under, or its base name
+// if it was scanned directly.
+func (s *Source) scanFile(ctx context.Context, path, relativePath string, chunksChan chan *sources.Chunk) (err error) {
 	logger := ctx.Logger().WithValues("path", path)
+	skipped := false
+	defer func() { s.stats.fileDone(ctx, skipped, err) }()
+	s.stats.currentFile.Store(&path)
+
+	unitID := s.sanitizePath(path)
+	if s.completed(unitID) {
+		logger.V(3).Info("skipping file completed by a previous scan")
+		skipped = true
+		return nil
+	}
+	// Deferred first so that it runs last, once the chunks of referenced
+	// files and attributes were sent too.
+	defer func() {
+		if err == nil && !skipped {
+			s.reportCheckpoint(ctx, unitID)
+		}
+	}()
+
 	fileStat, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("unable to stat file: %w", err)
+		return fmt.Errorf("%w: %v", errUnableToStat, err)
 	}
 	if !fileStat.Mode().IsRegular() {
-		return fmt.Errorf("not a regular file")
+		return errNotRegularFile
+	}
+	if cutoff := s.sizeCutoff.Load(); cutoff > 0 && fileStat.Size() > cutoff {
+		logger.V(3).Info("skipping file above the size cutoff", "size", fileStat.Size(), "cutoff", cutoff)
+		skipped = true
+		return nil
+	}
+	if maxSize := s.specFor(path).MaxSize; maxSize > 0 && fileStat.Size() > maxSize {
+		logger.V(3).Info("skipping file above the max size of its path", "size", fileStat.Size(), "max_size", maxSize)
+		skipped = true
+		return nil
+	}
+	if s.scanReferenced {
+		defer func() {
+			if err == nil {
+				err = s.scanReferencedFiles(ctx, path, chunksChan)
+			}
+		}()
+	}
+
+	if s.scanAttributes {
+		defer func() {
+			if err == nil && !skipped {
+				err = s.scanFileAttributes(ctx, path, relativePath, chunksChan)
+			}
+		}()
+	}
+
+	if err := s.pauseGate.wait(ctx); err != nil {
+		return err
+	}
+	if s.openFiles != nil {
+		if err := s.openFiles.Acquire(ctx, 1); err != nil {
+			return err
+		}
+		// Released before referenced files are scanned, which acquire
+		// their own permit.
+		defer s.openFiles.Release(1)
+	}
+
+	contentPath := path
+	if s.resolveGitLFS && fileStat.Size() <= lfsPointerMaxSize {
+		objectPath, err := lfsObjectPath(path)
+		switch {
+		case err == nil:
+			objectStat, err := os.Stat(objectPath)
+			if err != nil {
+				logger.V(2).Info("skipping git lfs pointer, object is not cached", "object", objectPath, "error", err)
+				skipped = true
+				return nil
+			}
+			contentPath, fileStat = objectPath, objectStat
+		case errors.Is(err, os.ErrNotExist):
+			logger.V(2).Info("skipping git lfs pointer outside of a git repository")
+			skipped = true
+			return nil
+		case !errors.Is(err, errNotLFSPointer):
+			return fmt.Errorf("unable to read git lfs pointer: %w", err)
+		}
+	}
+
+	if len(s.contentTypes) > 0 {
+		contentType, err := sniffContentType(contentPath)
+		if err != nil {
+			return fmt.Errorf("unable to detect content type: %w", err)
+		}
+		if !s.contentTypes.allows(contentType) {
+			logger.V(3).Info("skipping file with content type not in allowlist", "content_type", contentType)
+			skipped = true
+			return nil
+		}
+	}
+
+	var fingerprint string
+	if s.skipCache != nil {
+		if fingerprint, err = s.skipCache.fingerprint(path, fileStat); err != nil {
+			return fmt.Errorf("unable to fingerprint file: %w", err)
+		}
+		if s.skipCache.unchanged(path, fingerprint) {
+			logger.V(3).Info("skipping unchanged file")
+			skipped = true
+			return nil
+		}
+		defer func() {
+			if err == nil {
+				s.skipCache.update(path, fingerprint)
+			}
+		}()
+	}
+
+	if s.imageTarballs {
+		if isImage, err := s.scanImageTarball(ctx, path, contentPath, chunksChan); isImage || err != nil {
+			return err
+		}
+	}
+
+	if fileStat.Size() == 0 && s.emptyFileChunks {
+		metadata := s.fileMetadata(ctx, path, relativePath, 0)
+		chunk := &sources.Chunk{
+			SourceType:     s.Type(),
+			SourceName:     s.name,
+			SourceID:       s.SourceID(),
+			SourceMetadata: metadata,
+			SourceUnitID:   metadata.GetFilesystem().GetFile(),
+			Verify:         s.verify,
+			DetectorHints:  detectorHints(path),
+		}
+		return common.CancellableWrite(ctx, chunksChan, chunk)
 	}
 
-	inputFile, err := os.Open(path)
+	inputFile, err := os.Open(contentPath)
 	if err != nil {
 		return fmt.Errorf("unable to open file: %w", err)
 	}
 	defer inputFile.Close()
 	logger.V(3).Info("scanning file")
 
-	reReader, err := diskbufferreader.New(inputFile)
+	var input io.Reader = &countingReader{reader: inputFile, count: &s.stats.bytesRead}
+	if s.headBytes > 0 {
+		input = io.LimitReader(input, s.headBytes)
+	}
+	if s.readLimiter != nil {
+		input = &throttledReader{ctx: ctx, reader: input, limiter: s.readLimiter}
+	}
+	metadata := s.fileMetadata(ctx, path, relativePath, 0)
+	if s.transcode {
+		var encoding string
+		input, encoding = transcodeReader(input)
+		metadata.GetFilesystem().Encoding = encoding
+	}
+	wholeFile := fileStat.Size() < s.wholeFileThreshold
+	return s.chunkReader(ctx, input, metadata, wholeFile, chunksChan)
+}
+
+// ChunkReader emits the chunks of the content read from r, as if it was the
+// content of a file scanned by the source, so that content that isn't on disk
+// can reuse the source's chunking. Archives are extracted and base64 decoding
+// is applied according to the source's configuration. Every chunk carries
+// metadata, which must not be modified afterwards. name identifies the
+// content in logs.
+func (s *Source) ChunkReader(ctx context.Context, name string, r io.Reader, metadata *source_metadatapb.MetaData, chunksChan chan *sources.Chunk) error {
+	ctx.Logger().V(3).Info("scanning reader", "name", name)
+	return s.chunkReader(ctx, r, metadata, false, chunksChan)
+}
+
+// chunkReader implements ChunkReader. If wholeFile is set, the content is
+// emitted as a single chunk rather than split into BufferSize chunks.
+func (s *Source) chunkReader(ctx context.Context, r io.Reader, metadata *source_metadatapb.MetaData, wholeFile bool, chunksChan chan *sources.Chunk) error {
+	hints := detectorHints(metadata.GetFilesystem().GetFile())
+	reReader, err := diskbufferreader.New(r)
 	if err != nil {
-		return fmt.Errorf("could not create re-readable reader: %w", err)
+		// The content can't be read twice without the buffer, which is only
+		// needed to extract archives, so it's scanned as is.
+		path := metadataUnitID(metadata)
+		ctx.Logger().Info("unable to buffer file on disk, scanning it without extracting archives", "path", path, "error", err)
+		s.reportWarning(sources.WarningNoDiskBuffer, path, err)
+		return s.chunkContent(ctx, r, metadata, hints, wholeFile, chunksChan)
 	}
 	defer reReader.Close()
 
 	chunkSkel := &sources.Chunk{
-		SourceType: s.Type(),
-		SourceName: s.name,
-		SourceID:   s.SourceID(),
-		SourceMetadata: &source_metadatapb.MetaData{
-			Data: &source_metadatapb.MetaData_Filesystem{
-				Filesystem: &source_metadatapb.Filesystem{
-					File: sanitizer.UTF8(path),
-				},
-			},
-		},
-		Verify: s.verify,
+		SourceType:     s.Type(),
+		SourceName:     s.name,
+		SourceID:       s.SourceID(),
+		SourceMetadata: metadata,
+		SourceUnitID:   metadataUnitID(metadata),
+		Verify:         s.verify,
+		DetectorHints:  hints,
+	}
+	handlerOpts := []handlers.Option{handlers.WithArchiveEntryGlobs(s.archiveInclude, s.archiveExclude)}
+	if s.archiveMaxRatio > 0 || s.archiveMaxExtracted > 0 {
+		handlerOpts = append(handlerOpts, handlers.WithDecompressionLimits(s.archiveMaxRatio, s.archiveMaxExtracted))
 	}
-	if handlers.HandleFile(ctx, reReader, chunkSkel, chunksChan) {
+	if handlers.HandleFile(ctx, reReader, chunkSkel, chunksChan, handlerOpts...) {
 		return nil
 	}
 
@@ -185,29 +968,56 @@ func (s *Source) scanFile(ctx context.Context, path string, chunksChan chan *sou
 		return err
 	}
 	reReader.Stop()
+	return s.chunkContent(ctx, reReader, metadata, hints, wholeFile, chunksChan)
+}
+
+// chunkContent emits the chunks of the content read from r, without handling
+// archives. If wholeFile is set, the content is emitted as a single chunk
+// rather than split into BufferSize chunks.
+func (s *Source) chunkContent(ctx context.Context, r io.Reader, metadata *source_metadatapb.MetaData, hints []detectorspb.DetectorType, wholeFile bool, chunksChan chan *sources.Chunk) error {
+	if wholeFile {
+		return s.scanWholeFile(ctx, r, metadata, hints, chunksChan)
+	}
 
+	reader := bufio.NewReaderSize(r, BufferSize)
+	// carry holds the bytes of a rune split by the end of the previous chunk,
+	// which start the next one.
+	var carry []byte
 	for {
 		chunkBytes := make([]byte, BufferSize)
-		reader := bufio.NewReaderSize(reReader, BufferSize)
-		n, err := reader.Read(chunkBytes)
+		copied := copy(chunkBytes, carry)
+		n, err := reader.Read(chunkBytes[copied:])
 		if err != nil && !errors.Is(err, io.EOF) {
 			break
 		}
+		n += copied
 		peekData, _ := reader.Peek(PeekSize)
-		if n > 0 {
-			chunksChan <- &sources.Chunk{
-				SourceType: s.Type(),
-				SourceName: s.name,
-				SourceID:   s.SourceID(),
-				Data:       append(chunkBytes[:n], peekData...),
-				SourceMetadata: &source_metadatapb.MetaData{
-					Data: &source_metadatapb.MetaData_Filesystem{
-						Filesystem: &source_metadatapb.Filesystem{
-							File: sanitizer.UTF8(path),
-						},
-					},
-				},
-				Verify: s.verify,
+		// Unless it's the end of the content, end the chunk at the last
+		// complete rune so that detectors don't see a corrupted one at the
+		// seam. The rest is part of the overlap.
+		end := n
+		carry = nil
+		if len(peekData) > 0 {
+			end -= incompleteRuneLen(chunkBytes[:n])
+			carry = append(carry, chunkBytes[end:n]...)
+			peekData = peekData[:len(peekData)-incompleteRuneLen(peekData)]
+		}
+		if end > 0 {
+			chunk := &sources.Chunk{
+				SourceType:     s.Type(),
+				SourceName:     s.name,
+				SourceID:       s.SourceID(),
+				Data:           append(chunkBytes[:n], peekData...),
+				SourceMetadata: metadata,
+				SourceUnitID:   metadataUnitID(metadata),
+				Verify:         s.verify,
+				DetectorHints:  hints,
+			}
+			if err := common.CancellableWrite(ctx, chunksChan, chunk); err != nil {
+				return err
+			}
+			if err := s.scanDecodedChunks(ctx, chunk, chunksChan); err != nil {
+				return err
 			}
 		}
 		if errors.Is(err, io.EOF) {
@@ -217,15 +1027,298 @@ func (s *Source) scanFile(ctx context.Context, path string, chunksChan chan *sou
 	return nil
 }
 
+// incompleteRuneLen returns the number of bytes at the end of b that start a
+// UTF-8 encoded rune without completing it.
+func incompleteRuneLen(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return 0
+			}
+			return len(b) - i
+		}
+	}
+	return 0
+}
+
+// fileMetadata returns the metadata of a chunk from the file at path, which
+// is relativePath relative to its scan root. line is the line the chunk
+// starts at, or zero if unknown.
+func (s *Source) fileMetadata(ctx context.Context, path, relativePath string, line int64) *source_metadatapb.MetaData {
+	referencedBy, _ := ctx.Value(referencedByKey).(string)
+	var email string
+	if s.annotateGit {
+		email = s.gitAuthorEmail(ctx, path, line)
+	}
+	return &source_metadatapb.MetaData{
+		Data: &source_metadatapb.MetaData_Filesystem{
+			Filesystem: &source_metadatapb.Filesystem{
+				File:         s.sanitizePath(path),
+				RelativePath: s.sanitizePath(relativePath),
+				Label:        s.labelFor(path),
+				Line:         line,
+				ReferencedBy: s.sanitizePath(referencedBy),
+				Email:        email,
+			},
+		},
+	}
+}
+
+// metadataUnitID returns the source unit of chunks with metadata, which is the
+// file they were read from.
+func metadataUnitID(metadata *source_metadatapb.MetaData) string {
+	if docker := metadata.GetDocker(); docker != nil {
+		return docker.GetImage()
+	}
+	return metadata.GetFilesystem().GetFile()
+}
+
+// sanitizePath returns path as it's stored in chunk metadata.
+func (s *Source) sanitizePath(path string) string {
+	path = sanitizer.UTF8(path)
+	if s.pathSanitizer == nil || path == "" {
+		return path
+	}
+	return s.pathSanitizer(path)
+}
+
+// scanWholeFile emits the entire content of the reader as a single chunk.
+func (s *Source) scanWholeFile(ctx context.Context, reader io.Reader, metadata *source_metadatapb.MetaData, hints []detectorspb.DetectorType, chunksChan chan *sources.Chunk) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	chunk := &sources.Chunk{
+		SourceType:     s.Type(),
+		SourceName:     s.name,
+		SourceID:       s.SourceID(),
+		Data:           data,
+		SourceMetadata: metadata,
+		SourceUnitID:   metadataUnitID(metadata),
+		Verify:         s.verify,
+		DetectorHints:  hints,
+	}
+	if err := common.CancellableWrite(ctx, chunksChan, chunk); err != nil {
+		return err
+	}
+	return s.scanDecodedChunks(ctx, chunk, chunksChan)
+}
+
+// diffScan holds the parsed diff for a source configured with WithDiff.
+type diffScan struct {
+	root  string
+	files []diffFile
+}
+
+// scanDiff emits a chunk for each region of lines added by the configured
+// diff. The chunk metadata points at the first added line of the region.
+func (s *Source) scanDiff(ctx context.Context, chunksChan chan *sources.Chunk) error {
+	for i, file := range s.diff.files {
+		if err := s.pauseGate.wait(ctx); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := joinPath(s.diff.root, file.Path)
+		s.SetProgressComplete(i, len(s.diff.files), fmt.Sprintf("Path: %s", path), "")
+		s.stats.currentFile.Store(&path)
+		if file.Binary {
+			ctx.Logger().V(2).Info("skipping binary file in diff", "path", path)
+			s.stats.filesSkipped.Add(1)
+			continue
+		}
+		if (s.filter != nil && !s.filter.Pass(path)) || s.completed(s.sanitizePath(path)) {
+			s.stats.filesSkipped.Add(1)
+			continue
+		}
+		s.stats.filesScanned.Add(1)
+		for _, region := range file.Regions {
+			s.stats.bytesRead.Add(int64(len(region.Data)))
+			chunk := &sources.Chunk{
+				SourceType:     s.Type(),
+				SourceName:     s.name,
+				SourceID:       s.SourceID(),
+				Data:           region.Data,
+				SourceMetadata: s.fileMetadata(ctx, path, filepath.FromSlash(file.Path), region.Line),
+				SourceUnitID:   s.sanitizePath(path),
+				Verify:         s.verify,
+				DetectorHints:  detectorHints(path),
+			}
+			if err := common.CancellableWrite(ctx, chunksChan, chunk); err != nil {
+				return err
+			}
+			if err := s.scanDecodedChunks(ctx, chunk, chunksChan); err != nil {
+				return err
+			}
+		}
+		s.reportCheckpoint(ctx, s.sanitizePath(path))
+	}
+	return nil
+}
+
+// scanDecodedChunks emits a chunk for each level of base64 encoded content
+// found in the provided chunk. Decoding stops once nothing more can be
+// decoded, the decoded data is not valid UTF-8, or maxDecodeDepth is reached.
+// An error is only returned if ctx is done before a chunk could be sent.
+func (s *Source) scanDecodedChunks(ctx context.Context, chunk *sources.Chunk, chunksChan chan *sources.Chunk) error {
+	if !s.decodeBase64 {
+		return nil
+	}
+	decoder := &decoders.Base64{}
+	data := chunk.Data
+	for depth := 0; depth < maxDecodeDepth && len(data) <= maxDecodeSize; depth++ {
+		decoded := decoder.FromChunk(&sources.Chunk{Data: data})
+		if decoded == nil || !utf8.Valid(decoded.Data) || bytes.Equal(decoded.Data, data) {
+			return nil
+		}
+		decodedChunk := *chunk
+		decodedChunk.Data = decoded.Data
+		decodedChunk.DecoderType = detectorspb.DecoderType_BASE64
+		if err := common.CancellableWrite(ctx, chunksChan, &decodedChunk); err != nil {
+			return err
+		}
+		data = decoded.Data
+	}
+	return nil
+}
+
 // Enumerate implements SourceUnitEnumerator interface. This implementation simply
 // passes the configured paths as the source unit, whether it be a single
-// filepath or a directory.
-func (s *Source) Enumerate(ctx context.Context, units chan<- sources.EnumerationResult) error {
+// filepath or a directory. Up to the source's concurrency paths are stat'd at
+// once, so units may be sent in any order unless it is below two. File sizes
+// are surveyed first if a size percentile cutoff is configured.
+func (s *Source) Enumerate(ctx context.Context, units chan<- sources.EnumerationResult) (err error) {
+	start := time.Now()
+	defer func() { s.emit(sources.EventEnumerationFinished, "", start, err) }()
+	s.surveySizeCutoff(ctx)
+
+	var wg errgroup.Group
+	if s.concurrency > 1 {
+		wg.SetLimit(s.concurrency)
+	} else {
+		wg.SetLimit(1)
+	}
 	for _, path := range s.paths {
-		item := sources.CommonEnumerationOk(path)
-		if err := common.CancellableWrite(ctx, units, item); err != nil {
+		if ctx.Err() != nil {
+			break
+		}
+		path := path
+		wg.Go(func() error {
+			item := sources.CommonEnumerationOkWithMetadata(path, unitMetadata(path))
+			return common.CancellableWrite(ctx, units, item)
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// unitMetadata returns the metadata attached to an enumerated unit. It is nil
+// if the path can't be stat'd, in which case the error is reported when the
+// unit is chunked.
+func unitMetadata(path string) map[string]string {
+	fileInfo, err := os.Stat(normalizePath(path))
+	if err != nil {
+		return nil
+	}
+	kind := UnitKindFile
+	if fileInfo.IsDir() {
+		kind = UnitKindDir
+	}
+	return map[string]string{
+		UnitMetadataKind: kind,
+		UnitMetadataSize: strconv.FormatInt(fileInfo.Size(), 10),
+	}
+}
+
+// ChunkUnit implements SourceUnitChunker interface.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, results chan<- sources.ChunkResult) error {
+	return s.chunkUnit(ctx, unit, func(result sources.ChunkResult) error {
+		return common.CancellableWrite(ctx, results, result)
+	})
+}
+
+// ChunkUnitBatches implements SourceUnitBatchChunker interface. Results are
+// sent in batches of the size set by WithChunkBatchSize, and the last,
+// smaller batch is sent once the unit is finished.
+func (s *Source) ChunkUnitBatches(ctx context.Context, unit sources.SourceUnit, batches chan<- []sources.ChunkResult) error {
+	size := s.chunkBatchSize
+	if size <= 0 {
+		size = DefaultChunkBatchSize
+	}
+	batch := make([]sources.ChunkResult, 0, size)
+	err := s.chunkUnit(ctx, unit, func(result sources.ChunkResult) error {
+		batch = append(batch, result)
+		if len(batch) < size {
+			return nil
+		}
+		full := batch
+		batch = make([]sources.ChunkResult, 0, size)
+		return common.CancellableWrite(ctx, batches, full)
+	})
+	if err != nil {
+		return err
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return common.CancellableWrite(ctx, batches, batch)
+}
+
+// chunkUnit chunks unit, passing each result to send. It returns the first
+// error send returns.
+func (s *Source) chunkUnit(ctx context.Context, unit sources.SourceUnit, send func(sources.ChunkResult) error) (err error) {
+	path := unit.SourceUnitID()
+	logger := ctx.Logger().WithValues("path", path)
+
+	// unitErr is the error the unit failed with, whether it's returned or
+	// sent as a ChunkErr.
+	var unitErr error
+	start := time.Now()
+	s.emit(sources.EventUnitStarted, path, time.Time{}, nil)
+	defer func() {
+		if err != nil {
+			unitErr = err
+		}
+		s.emit(sources.EventUnitFinished, path, start, unitErr)
+	}()
+
+	cleanPath := normalizePath(path)
+	fileInfo, err := os.Stat(cleanPath)
+	if err != nil {
+		unitErr = fmt.Errorf("unable to get file info: %w", err)
+		return send(sources.ChunkErr(unitErr))
+	}
+
+	ch := make(chan *sources.Chunk)
+	var scanErr error
+	go func() {
+		defer close(ch)
+		if fileInfo.IsDir() {
+			scanErr = s.scanDir(ctx, cleanPath, ch)
+		} else {
+			scanErr = s.scanFile(ctx, cleanPath, filepath.Base(cleanPath), ch)
+		}
+	}()
+
+	for chunk := range ch {
+		if err := send(sources.ChunkOk(chunk)); err != nil {
+			// Drain the channel so the scanning goroutine can exit.
+			for range ch {
+			}
 			return err
 		}
 	}
+
+	if scanErr != nil && scanErr != io.EOF {
+		logger.Info("error scanning filesystem", "error", scanErr)
+		unitErr = scanErr
+		return send(sources.ChunkErr(scanErr))
+	}
 	return nil
 }
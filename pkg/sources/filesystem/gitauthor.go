@@ -0,0 +1,108 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// notCommittedEmail is the author email git blame reports for lines that
+// aren't committed yet.
+const notCommittedEmail = "not.committed.yet"
+
+// gitAuthors looks up the authors of files in git working trees with the git
+// CLI, which honors .mailmap. The author of the last commit touching each
+// file is cached. It is safe for concurrent use.
+type gitAuthors struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+// WithGitAnnotations enables recording in the metadata of chunks from files in
+// git working trees the email of the author who last committed to the file,
+// or, for chunks of a specific line, the author of that line, so findings can
+// be routed to them. Emails are normalized with the repository's .mailmap.
+func (s *Source) WithGitAnnotations(enabled bool) {
+	s.annotateGit = enabled
+}
+
+// gitAuthorEmail returns the email of the author of line of the file at path,
+// or of the last commit to the file if line is zero or not committed. It is
+// empty if the file isn't committed to a git repository.
+func (s *Source) gitAuthorEmail(ctx context.Context, path string, line int64) string {
+	if _, err := findGitDir(filepath.Dir(path)); err != nil {
+		return ""
+	}
+	if line > 0 {
+		if email := blameLineEmail(ctx, path, line); email != "" {
+			return email
+		}
+	}
+	return s.gitAuthors.lastCommitEmail(ctx, path)
+}
+
+// lastCommitEmail returns the email of the author of the last commit touching
+// the file at path.
+func (a *gitAuthors) lastCommitEmail(ctx context.Context, path string) string {
+	a.mu.Lock()
+	email, ok := a.files[path]
+	a.mu.Unlock()
+	if ok {
+		return email
+	}
+
+	// %aE is the author email after applying the .mailmap.
+	out, err := gitOutput(ctx, filepath.Dir(path), "log", "-1", "--format=%aE", "--", filepath.Base(path))
+	if err != nil {
+		ctx.Logger().V(3).Info("unable to find the last commit of file", "path", path, "error", err)
+	}
+	email = strings.TrimSpace(string(out))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.files == nil {
+		a.files = make(map[string]string)
+	}
+	a.files[path] = email
+	return email
+}
+
+func (a *gitAuthors) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files = nil
+}
+
+// blameLineEmail returns the email of the author of line of the file at path,
+// or an empty string if the line isn't committed.
+func blameLineEmail(ctx context.Context, path string, line int64) string {
+	lines := strconv.FormatInt(line, 10) + "," + strconv.FormatInt(line, 10)
+	out, err := gitOutput(ctx, filepath.Dir(path), "blame", "--porcelain", "-L", lines, "--", filepath.Base(path))
+	if err != nil {
+		ctx.Logger().V(3).Info("unable to blame line", "path", path, "line", line, "error", err)
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if mail, ok := strings.CutPrefix(scanner.Text(), "author-mail "); ok {
+			email := strings.TrimSuffix(strings.TrimPrefix(mail, "<"), ">")
+			if email == notCommittedEmail {
+				return ""
+			}
+			return email
+		}
+	}
+	return ""
+}
+
+// gitOutput runs git with args in dir and returns its output.
+func gitOutput(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	return cmd.Output()
+}
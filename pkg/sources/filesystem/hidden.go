@@ -0,0 +1,47 @@
+package filesystem
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HiddenFilesMode determines which files are scanned when walking a directory
+// based on whether they're hidden. A file is hidden if its base name, or that
+// of a directory it's in below the scanned directory, starts with a dot.
+type HiddenFilesMode string
+
+const (
+	// HiddenFilesInclude scans hidden and visible files alike.
+	HiddenFilesInclude HiddenFilesMode = "include"
+	// HiddenFilesExclude skips hidden files and doesn't descend into hidden
+	// directories.
+	HiddenFilesExclude HiddenFilesMode = "exclude"
+	// HiddenFilesOnly scans only hidden files, such as .env and everything
+	// under .aws. Visible directories are still walked to find them.
+	HiddenFilesOnly HiddenFilesMode = "only"
+)
+
+// ParseHiddenFilesMode returns the HiddenFilesMode named s. An empty string is
+// HiddenFilesInclude.
+func ParseHiddenFilesMode(s string) (HiddenFilesMode, error) {
+	switch mode := HiddenFilesMode(s); mode {
+	case "":
+		return HiddenFilesInclude, nil
+	case HiddenFilesInclude, HiddenFilesExclude, HiddenFilesOnly:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid hidden files mode %q, expected one of %q, %q or %q",
+			s, HiddenFilesInclude, HiddenFilesExclude, HiddenFilesOnly)
+	}
+}
+
+// isHidden reports whether any element of the slash-separated relativePath,
+// as returned by fs.WalkDir, starts with a dot.
+func isHidden(relativePath string) bool {
+	for _, elem := range strings.Split(relativePath, "/") {
+		if strings.HasPrefix(elem, ".") && elem != "." && elem != ".." {
+			return true
+		}
+	}
+	return false
+}
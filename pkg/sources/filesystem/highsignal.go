@@ -0,0 +1,54 @@
+package filesystem
+
+import "github.com/trufflesecurity/trufflehog/v3/pkg/common"
+
+// DefaultHighSignalPaths returns globs, as matched by common.GlobFilter, of
+// files strongly associated with secrets: dotenv files, the credential files
+// of cloud providers and package managers, private keys and certificates,
+// and configuration and secrets directories. The locations of the detector
+// hints of files, such as .aws/credentials for AWS keys, are high signal as
+// well, whether or not they're listed.
+func DefaultHighSignalPaths() []string {
+	return []string{
+		// Dotenv files.
+		"**/.env",
+		"**/.env.*",
+		"**/*.env",
+		// Credential files of cloud providers, tools and package managers.
+		"**/credentials",
+		"**/credentials.json",
+		"**/.netrc",
+		"**/.pypirc",
+		"**/.pgpass",
+		"**/*.tfvars",
+		// Private keys, keystores and certificates.
+		"**/*.pem",
+		"**/*.key",
+		"**/*.p12",
+		"**/*.pfx",
+		"**/*.jks",
+		// Configuration and secrets directories.
+		"**/config/**",
+		"**/.config/**",
+		"**/secrets/**",
+	}
+}
+
+// WithHighSignalPaths restricts the files scanned while walking directories
+// to those matching globs, relative to the scanned directory, or one of the
+// locations of detector hints, for a fast pass over the files most likely
+// to contain secrets, such as a GlobFilter of DefaultHighSignalPaths
+// extended with patterns of the user's own. Every other file is skipped,
+// however likely it is to contain secrets. Nil scans every file.
+func (s *Source) WithHighSignalPaths(globs *common.GlobFilter) {
+	s.highSignalPaths = globs
+}
+
+// highSignal reports whether the file at relativePath, relative to the
+// scanned directory, is scanned with the high signal paths.
+func (s *Source) highSignal(relativePath string) bool {
+	if s.highSignalPaths == nil {
+		return true
+	}
+	return s.highSignalPaths.Match(relativePath) || detectorHints(relativePath) != nil
+}
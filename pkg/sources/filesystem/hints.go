@@ -0,0 +1,62 @@
+package filesystem
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// fileHint is a heuristic for the secrets a file likely contains, based on
+// its path.
+type fileHint struct {
+	// suffix is matched against the end of the slash separated path, on a
+	// path element boundary, such as ".aws/credentials".
+	suffix string
+	// ext is matched against the file extension instead, such as ".pem".
+	ext   string
+	hints []detectorspb.DetectorType
+}
+
+var fileHints = []fileHint{
+	{suffix: ".aws/credentials", hints: []detectorspb.DetectorType{detectorspb.DetectorType_AWS}},
+	{suffix: ".aws/config", hints: []detectorspb.DetectorType{detectorspb.DetectorType_AWS}},
+	{suffix: ".npmrc", hints: []detectorspb.DetectorType{detectorspb.DetectorType_NpmToken}},
+	{suffix: ".git-credentials", hints: []detectorspb.DetectorType{detectorspb.DetectorType_URI, detectorspb.DetectorType_Github}},
+	{suffix: ".docker/config.json", hints: []detectorspb.DetectorType{detectorspb.DetectorType_Docker}},
+	{suffix: ".kube/config", hints: []detectorspb.DetectorType{detectorspb.DetectorType_KubeConfig}},
+	{suffix: "application_default_credentials.json", hints: []detectorspb.DetectorType{detectorspb.DetectorType_GCP}},
+	{suffix: ".terraformrc", hints: []detectorspb.DetectorType{detectorspb.DetectorType_TerraformCloudPersonalToken}},
+	{suffix: "id_rsa", hints: []detectorspb.DetectorType{detectorspb.DetectorType_PrivateKey}},
+	{suffix: "id_dsa", hints: []detectorspb.DetectorType{detectorspb.DetectorType_PrivateKey}},
+	{suffix: "id_ecdsa", hints: []detectorspb.DetectorType{detectorspb.DetectorType_PrivateKey}},
+	{suffix: "id_ed25519", hints: []detectorspb.DetectorType{detectorspb.DetectorType_PrivateKey}},
+	{ext: ".pem", hints: []detectorspb.DetectorType{detectorspb.DetectorType_PrivateKey}},
+	{ext: ".key", hints: []detectorspb.DetectorType{detectorspb.DetectorType_PrivateKey}},
+}
+
+// envFileHints are the hints for dotenv files, which hold all kinds of
+// secrets, often as connection strings.
+var envFileHints = []detectorspb.DetectorType{detectorspb.DetectorType_Generic, detectorspb.DetectorType_URI}
+
+// detectorHints returns the detectors likely to find secrets in the file at
+// filePath, or nil if there's no heuristic for it.
+func detectorHints(filePath string) []detectorspb.DetectorType {
+	slashPath := filepath.ToSlash(filePath)
+	name := path.Base(slashPath)
+	if name == ".env" || strings.HasPrefix(name, ".env.") || path.Ext(name) == ".env" {
+		return envFileHints
+	}
+	for _, hint := range fileHints {
+		switch {
+		case hint.ext != "":
+			if strings.EqualFold(path.Ext(name), hint.ext) {
+				return hint.hints
+			}
+		case slashPath == hint.suffix || strings.HasSuffix(slashPath, "/"+hint.suffix):
+			return hint.hints
+		}
+	}
+	return nil
+}
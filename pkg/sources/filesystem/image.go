@@ -0,0 +1,209 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Prefixes of the names of the entries of image layers that delete paths of
+// the layers below them, see
+// https://github.com/opencontainers/image-spec/blob/main/layer.md#whiteouts.
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// isTarball returns true if the file at path starts with a valid tar header.
+func isTarball(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	_, err = tar.NewReader(f).Next()
+	return err == nil
+}
+
+// scanImageTarball emits the chunks of the files of each image in the tarball
+// at contentPath, as written by `docker save`, if it is one. It returns false
+// if the file isn't an image tarball, in which case nothing was scanned.
+func (s *Source) scanImageTarball(ctx context.Context, path, contentPath string, chunksChan chan *sources.Chunk) (bool, error) {
+	if !isTarball(contentPath) {
+		return false, nil
+	}
+	opener := func() (io.ReadCloser, error) { return os.Open(contentPath) }
+	manifest, err := tarball.LoadManifest(opener)
+	if err != nil || len(manifest) == 0 {
+		return false, nil
+	}
+
+	for _, descriptor := range manifest {
+		// Images can only be told apart by their tag when there are several.
+		var tag *name.Tag
+		var tagName string
+		if len(descriptor.RepoTags) > 0 {
+			tagName = descriptor.RepoTags[0]
+		}
+		if len(manifest) > 1 {
+			if tagName == "" {
+				ctx.Logger().V(2).Info("skipping untagged image of multi-image tarball", "config", descriptor.Config)
+				continue
+			}
+			t, err := name.NewTag(tagName)
+			if err != nil {
+				return true, fmt.Errorf("invalid image tag %q: %w", tagName, err)
+			}
+			tag = &t
+		}
+		img, err := tarball.Image(opener, tag)
+		if err != nil {
+			return true, fmt.Errorf("unable to load image: %w", err)
+		}
+		if err := s.scanImage(ctx, path, tagName, img, chunksChan); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// scanImage emits the chunks of the files of img as seen in the container
+// filesystem. Layers are scanned from the top down, and files deleted or
+// replaced by an upper layer are skipped in the layers below it.
+func (s *Source) scanImage(ctx context.Context, path, tag string, img v1.Image, chunksChan chan *sources.Chunk) error {
+	layers, err := img.Layers()
+	if err != nil {
+		return fmt.Errorf("unable to read image layers: %w", err)
+	}
+	overlay := newLayerOverlay()
+	for i := len(layers) - 1; i >= 0; i-- {
+		diffID, err := layers[i].DiffID()
+		if err != nil {
+			return fmt.Errorf("unable to get layer digest: %w", err)
+		}
+		ctx.Logger().V(3).Info("scanning image layer", "layer", diffID.String())
+		rc, err := layers[i].Uncompressed()
+		if err != nil {
+			return fmt.Errorf("unable to read layer %s: %w", diffID, err)
+		}
+		err = s.scanLayer(ctx, rc, overlay, func(file string) *source_metadatapb.MetaData {
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Docker{
+					Docker: &source_metadatapb.Docker{
+						File:  file,
+						Image: s.sanitizePath(path),
+						Tag:   tag,
+						Layer: diffID.String(),
+					},
+				},
+			}
+		}, chunksChan)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("unable to scan layer %s: %w", diffID, err)
+		}
+	}
+	return nil
+}
+
+// scanLayer emits the chunks of the regular files of the layer read from r
+// that aren't hidden by the layers above it, and records the paths the layer
+// hides from the layers below in overlay.
+func (s *Source) scanLayer(ctx context.Context, r io.Reader, overlay *layerOverlay, metadata func(file string) *source_metadatapb.MetaData, chunksChan chan *sources.Chunk) error {
+	tr := tar.NewReader(r)
+	var hidden, opaque []string
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		file := path.Clean("/" + header.Name)
+		dir, base := path.Split(file)
+		switch {
+		case base == whiteoutOpaque:
+			opaque = append(opaque, path.Clean(dir))
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			hidden = append(hidden, path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			continue
+		case overlay.hides(file):
+			continue
+		}
+		if header.Typeflag != tar.TypeDir {
+			hidden = append(hidden, file)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		wholeFile := header.Size < s.wholeFileThreshold
+		if err := s.chunkReader(ctx, tr, metadata(file), wholeFile, chunksChan); err != nil {
+			return err
+		}
+	}
+	// The layer's own entries aren't hidden by its whiteouts, so they only
+	// apply to the layers below.
+	overlay.add(hidden, opaque)
+	return nil
+}
+
+// layerOverlay tracks the paths of an image's filesystem that are hidden from
+// a layer by the layers above it.
+type layerOverlay struct {
+	// hidden holds paths deleted or replaced by an upper layer, along with
+	// everything under them.
+	hidden map[string]struct{}
+	// opaque holds directories whose content in lower layers was deleted by
+	// an upper layer.
+	opaque map[string]struct{}
+}
+
+func newLayerOverlay() *layerOverlay {
+	return &layerOverlay{hidden: make(map[string]struct{}), opaque: make(map[string]struct{})}
+}
+
+// hides returns true if file, an absolute path, is hidden by an upper layer.
+func (o *layerOverlay) hides(file string) bool {
+	if _, ok := o.hidden[file]; ok {
+		return true
+	}
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		if _, ok := o.hidden[dir]; ok {
+			return true
+		}
+		if _, ok := o.opaque[dir]; ok {
+			return true
+		}
+		if dir == "/" {
+			return false
+		}
+	}
+}
+
+// add records the paths hidden and the directories made opaque by a layer.
+func (o *layerOverlay) add(hidden, opaque []string) {
+	for _, p := range hidden {
+		o.hidden[p] = struct{}{}
+	}
+	for _, dir := range opaque {
+		o.opaque[dir] = struct{}{}
+	}
+}
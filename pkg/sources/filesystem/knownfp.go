@@ -0,0 +1,40 @@
+package filesystem
+
+import "github.com/trufflesecurity/trufflehog/v3/pkg/common"
+
+// DefaultFalsePositivePaths returns globs, as matched by common.GlobFilter, of
+// files that reliably produce false positives: minified and generated
+// assets, lockfiles full of hashes, and test fixtures full of fake keys.
+func DefaultFalsePositivePaths() []string {
+	return []string{
+		// Minified and generated assets.
+		"**/*.min.js",
+		"**/*.min.css",
+		"**/*.js.map",
+		"**/*.css.map",
+		// Lockfiles, whose integrity hashes look like keys.
+		"**/package-lock.json",
+		"**/yarn.lock",
+		"**/pnpm-lock.yaml",
+		"**/go.sum",
+		"**/Cargo.lock",
+		"**/poetry.lock",
+		"**/Gemfile.lock",
+		"**/composer.lock",
+		// Test fixtures and snapshots.
+		"**/testdata",
+		"**/__fixtures__",
+		"**/__snapshots__",
+		"**/*.snap",
+	}
+}
+
+// WithFalsePositivePaths skips the files matching globs, relative to the
+// scanned directory, while walking directories, such as a GlobFilter of
+// DefaultFalsePositivePaths extended with patterns of the user's own. Unlike
+// exclude globs, they're meant for files known to produce false positives
+// rather than files out of scope. Lockfiles that are skipped aren't used to
+// find referenced credential files either. Nil scans every file.
+func (s *Source) WithFalsePositivePaths(globs *common.GlobFilter) {
+	s.falsePositivePaths = globs
+}
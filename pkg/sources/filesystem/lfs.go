@@ -0,0 +1,116 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// lfsPointerMaxSize is the size above which files aren't read to check
+// whether they're Git LFS pointers, since pointer files are a few lines long.
+const lfsPointerMaxSize = 1024
+
+var (
+	lfsOidPat = regexp.MustCompile(`^oid sha256:([0-9a-f]{64})$`)
+
+	errNotLFSPointer = errors.New("not a git lfs pointer")
+)
+
+// parseLFSPointer returns the SHA-256 object ID of the Git LFS pointer file
+// content, as described in https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md.
+// It returns errNotLFSPointer if the content isn't a pointer.
+func parseLFSPointer(content []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), "version https://git-lfs") {
+		return "", errNotLFSPointer
+	}
+	for scanner.Scan() {
+		if match := lfsOidPat.FindStringSubmatch(scanner.Text()); match != nil {
+			return match[1], nil
+		}
+	}
+	return "", errNotLFSPointer
+}
+
+// lfsObjectPath returns the path of the object the Git LFS pointer file at
+// path points to in the local LFS cache of the repository the file is in. It
+// returns errNotLFSPointer if the file isn't a pointer, and os.ErrNotExist if
+// the file isn't in a repository.
+func lfsObjectPath(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, lfsPointerMaxSize))
+	if err != nil {
+		return "", err
+	}
+	oid, err := parseLFSPointer(content)
+	if err != nil {
+		return "", err
+	}
+
+	gitDir, err := findGitDir(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, "lfs", "objects", oid[0:2], oid[2:4], oid), nil
+}
+
+// findGitDir returns the git directory of the repository dir is in. For
+// worktrees and submodules, whose .git is a file pointing to their git
+// directory, the directory shared with the main repository is returned, since
+// that's where LFS objects are stored.
+func findGitDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		switch {
+		case err == nil && info.IsDir():
+			return dotGit, nil
+		case err == nil:
+			return readGitDirFile(dotGit)
+		case !errors.Is(err, os.ErrNotExist):
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
+
+// readGitDirFile returns the git directory a .git file points to with its
+// "gitdir:" line, or the common directory of that git directory if it has one.
+func readGitDirFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+
+	commonDir, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir, nil
+	}
+	dir := strings.TrimSpace(string(commonDir))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return dir, nil
+}
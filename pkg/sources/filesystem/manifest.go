@@ -0,0 +1,117 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+// manifest is the JSON document read by LoadManifest, for example:
+//
+//	{"paths": [
+//	  {"path": "/srv/app", "label": "production", "max_size": 1048576},
+//	  {"path": "/home/ci/build", "exclude_paths": ["\\.log$"]}
+//	]}
+type manifest struct {
+	Paths []manifestPath `json:"paths"`
+}
+
+type manifestPath struct {
+	Path string `json:"path"`
+	// Label is attached to the metadata of findings in files under Path.
+	Label string `json:"label"`
+	// MaxSize skips the files under Path larger than this many bytes. Zero
+	// means unlimited.
+	MaxSize int64 `json:"max_size"`
+	// IncludePaths and ExcludePaths are regular expressions filtering the
+	// files found in Path, like the lines of the --include-paths and
+	// --exclude-paths files.
+	IncludePaths []string `json:"include_paths"`
+	ExcludePaths []string `json:"exclude_paths"`
+}
+
+// PathSpec is a path to scan along with the options applied to the files
+// found under it, in addition to the options of the source.
+type PathSpec struct {
+	Path string
+	// Label is attached to the metadata of findings in files under Path.
+	// It takes precedence over labels of shorter prefixes set by WithLabels.
+	Label string
+	// MaxSize skips files larger than this many bytes. Zero means
+	// unlimited.
+	MaxSize int64
+	// Filter filters the files found while walking Path. Nil passes every
+	// file.
+	Filter *common.Filter
+}
+
+// LoadManifest reads a JSON manifest of the paths to scan from r, and returns
+// a PathSpec for each path. The manifest is validated as a whole: unknown
+// fields, missing or duplicate paths, negative sizes and invalid regular
+// expressions are errors.
+func LoadManifest(r io.Reader) ([]PathSpec, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var m manifest
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if len(m.Paths) == 0 {
+		return nil, errors.New("invalid manifest: no paths")
+	}
+
+	specs := make([]PathSpec, 0, len(m.Paths))
+	seen := make(map[string]struct{}, len(m.Paths))
+	for i, p := range m.Paths {
+		if p.Path == "" {
+			return nil, fmt.Errorf("invalid manifest: path %d is empty", i)
+		}
+		path := normalizePath(p.Path)
+		if _, ok := seen[path]; ok {
+			return nil, fmt.Errorf("invalid manifest: path %q is listed more than once", p.Path)
+		}
+		seen[path] = struct{}{}
+		if p.MaxSize < 0 {
+			return nil, fmt.Errorf("invalid manifest: max_size of path %q is negative", p.Path)
+		}
+
+		spec := PathSpec{Path: p.Path, Label: p.Label, MaxSize: p.MaxSize}
+		if len(p.IncludePaths) > 0 || len(p.ExcludePaths) > 0 {
+			filter, err := common.FilterFromRules(p.IncludePaths, p.ExcludePaths)
+			if err != nil {
+				return nil, fmt.Errorf("invalid manifest: filter of path %q: %w", p.Path, err)
+			}
+			spec.Filter = filter
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// WithPathSpecs adds the paths of specs to the paths to scan, each with its
+// own options. It must be called after Init, which sets the other paths. If
+// the paths of several specs contain a file, the longest one applies.
+func (s *Source) WithPathSpecs(specs []PathSpec) {
+	s.pathSpecs = make(map[string]PathSpec, len(specs))
+	for _, spec := range specs {
+		path := normalizePath(spec.Path)
+		s.pathSpecs[path] = spec
+		s.paths = append(s.paths, path)
+	}
+}
+
+// specFor returns the spec of the longest path containing path, or a zero
+// PathSpec, which applies no options, if there is none.
+func (s *Source) specFor(path string) PathSpec {
+	var spec PathSpec
+	var longest string
+	for prefix, sp := range s.pathSpecs {
+		if len(prefix) > len(longest) && hasPathPrefix(path, prefix) {
+			spec, longest = sp, prefix
+		}
+	}
+	return spec
+}
@@ -0,0 +1,129 @@
+package filesystem
+
+import (
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// normalizePath returns the shortest equivalent form of a user provided path.
+// On Windows, the volume of UNC (\\server\share) and drive-letter (C:)
+// paths is preserved so that network shares can be walked and reported
+// in their original form.
+func normalizePath(p string) string {
+	if runtime.GOOS == "windows" {
+		return cleanWindowsPath(p)
+	}
+	return filepath.Clean(p)
+}
+
+// joinPath joins a root path with a slash-separated path relative to it, as
+// returned by fs.WalkDir.
+func joinPath(root, relativePath string) string {
+	if runtime.GOOS == "windows" {
+		return joinWindowsPath(root, relativePath)
+	}
+	return filepath.Join(root, relativePath)
+}
+
+// hasPathPrefix reports whether p is prefix or a path under it. Both are
+// normalized first, so prefix "/app" contains "/app/config" but not
+// "/application".
+func hasPathPrefix(p, prefix string) bool {
+	p, prefix = normalizePath(p), normalizePath(prefix)
+	if p == prefix {
+		return true
+	}
+	sep := string(filepath.Separator)
+	return strings.HasPrefix(p, strings.TrimSuffix(prefix, sep)+sep)
+}
+
+// cleanWindowsPath cleans p using Windows path semantics regardless of the
+// host OS. Extended-length paths (\\?\...) are returned unchanged because
+// Windows does not normalize them either.
+func cleanWindowsPath(p string) string {
+	if strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	p = strings.ReplaceAll(p, "/", `\`)
+	vol := windowsVolumeName(p)
+	rest := p[len(vol):]
+	if rest == "" {
+		if isUNC(vol) {
+			return vol
+		}
+		return vol + "."
+	}
+	cleaned := path.Clean(strings.ReplaceAll(rest, `\`, "/"))
+	return vol + strings.ReplaceAll(cleaned, "/", `\`)
+}
+
+// joinWindowsPath is the Windows equivalent of filepath.Join for a root path
+// and a slash-separated relative path.
+func joinWindowsPath(root, relativePath string) string {
+	if relativePath == "" || relativePath == "." {
+		return cleanWindowsPath(root)
+	}
+	return cleanWindowsPath(strings.TrimRight(root, `\/`) + `\` + relativePath)
+}
+
+// windowsVolumeName returns the leading volume of a backslash-separated
+// Windows path: either a drive letter ("C:") or a UNC share
+// ("\\server\share").
+func windowsVolumeName(p string) string {
+	if len(p) >= 2 && p[1] == ':' && isLetter(p[0]) {
+		return p[:2]
+	}
+	if !strings.HasPrefix(p, `\\`) || len(p) < 3 || p[2] == '\\' {
+		return ""
+	}
+	// Skip over the server and share components.
+	server := strings.IndexByte(p[2:], '\\')
+	if server < 0 {
+		return p
+	}
+	shareStart := 2 + server + 1
+	share := strings.IndexByte(p[shareStart:], '\\')
+	if share < 0 {
+		return p
+	}
+	return p[:shareStart+share]
+}
+
+func isUNC(vol string) bool {
+	return strings.HasPrefix(vol, `\\`)
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// pathSet is a set of paths, such as the files already scanned by a step that
+// must not scan them twice. It is safe for concurrent use.
+type pathSet struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// add records path and reports whether it wasn't recorded before.
+func (r *pathSet) add(path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.seen[path]; ok {
+		return false
+	}
+	if r.seen == nil {
+		r.seen = make(map[string]struct{})
+	}
+	r.seen[path] = struct{}{}
+	return true
+}
+
+// reset forgets all recorded paths.
+func (r *pathSet) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = nil
+}
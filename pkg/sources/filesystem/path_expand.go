@@ -0,0 +1,167 @@
+package filesystem
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// expandPaths expands shell-style braces and environment variables in the
+// user provided paths. It runs before the paths are cleaned, in the same
+// order as a POSIX shell:
+//
+//  1. Brace expansion: "/app/{config,secrets}" becomes "/app/config" and
+//     "/app/secrets". Groups may be nested and alternatives may be empty. A
+//     group without a top-level comma, such as "{a}", is left as written.
+//  2. Environment expansion: "$VAR" and "${VAR}" are replaced with the
+//     variable's value, and "$$" with a literal "$". Undefined variables are
+//     left as written rather than replaced with an empty string, so a typo
+//     can't silently widen "$ROOT/src" into "/src". Values are not brace
+//     expanded again.
+//
+// Except on Windows, where it is the path separator, a backslash escapes the
+// next character, so "\{", "\}", "\,", "\$" and "\\" are taken literally.
+func expandPaths(paths []string) []string {
+	var expanded []string
+	for _, p := range paths {
+		for _, braced := range expandBraces(p) {
+			expanded = append(expanded, expandEnv(braced))
+		}
+	}
+	return expanded
+}
+
+// escapesEnabled reports whether backslash escapes are recognized.
+func escapesEnabled() bool {
+	return runtime.GOOS != "windows"
+}
+
+// expandBraces returns every expansion of the brace groups in p. Escape
+// sequences are kept for expandEnv to resolve.
+func expandBraces(p string) []string {
+	for open := 0; open < len(p); open++ {
+		if escapesEnabled() && p[open] == '\\' {
+			open++
+			continue
+		}
+		// "${" starts a variable reference, not a brace group.
+		if p[open] != '{' || (open > 0 && p[open-1] == '$') {
+			continue
+		}
+		close, commas := matchBrace(p, open)
+		if close < 0 || len(commas) == 0 {
+			continue
+		}
+
+		prefix, suffix := p[:open], p[close+1:]
+		var expanded []string
+		start := open + 1
+		for _, end := range append(commas, close) {
+			expanded = append(expanded, expandBraces(prefix+p[start:end]+suffix)...)
+			start = end + 1
+		}
+		return expanded
+	}
+	return []string{p}
+}
+
+// matchBrace returns the index of the brace closing the group opened at
+// p[open] and the indexes of the commas directly inside it. The close index
+// is -1 if the group is never closed.
+func matchBrace(p string, open int) (int, []int) {
+	var commas []int
+	depth := 0
+	for i := open; i < len(p); i++ {
+		switch {
+		case escapesEnabled() && p[i] == '\\':
+			i++
+		case p[i] == '{':
+			depth++
+		case p[i] == '}':
+			depth--
+			if depth == 0 {
+				return i, commas
+			}
+		case p[i] == ',' && depth == 1:
+			commas = append(commas, i)
+		}
+	}
+	return -1, nil
+}
+
+// expandEnv replaces environment variables in p and resolves escape
+// sequences.
+func expandEnv(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if escapesEnabled() && c == '\\' && i+1 < len(p) && strings.IndexByte(`{},$\`, p[i+1]) >= 0 {
+			b.WriteByte(p[i+1])
+			i++
+			continue
+		}
+		if c != '$' || i+1 == len(p) {
+			b.WriteByte(c)
+			continue
+		}
+
+		if p[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		name, width := envName(p[i+1:])
+		if name == "" {
+			b.WriteByte(c)
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(p[i : i+1+width])
+		}
+		i += width
+	}
+	return b.String()
+}
+
+// envName returns the variable name at the start of s, which follows a "$",
+// and the number of bytes it spans including any braces. The name is empty if
+// s does not start with a valid reference.
+func envName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !isEnvName(s[1:end]) {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+	n := 0
+	for n < len(s) && isEnvNameChar(s[n], n == 0) {
+		n++
+	}
+	return s[:n], n
+}
+
+func isEnvName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isEnvNameChar(s[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isEnvNameChar(c byte, first bool) bool {
+	switch {
+	case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		return true
+	case '0' <= c && c <= '9':
+		return !first
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package filesystem
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{name: "no braces", path: "/app/config", want: []string{"/app/config"}},
+		{name: "simple group", path: "/app/{config,secrets}/", want: []string{"/app/config/", "/app/secrets/"}},
+		{name: "multiple groups", path: "/{a,b}/{c,d}", want: []string{"/a/c", "/a/d", "/b/c", "/b/d"}},
+		{name: "nested groups", path: "/app/{config,{x,y}data}", want: []string{"/app/config", "/app/xdata", "/app/ydata"}},
+		{name: "empty alternative", path: "/etc/app.conf{,.bak}", want: []string{"/etc/app.conf", "/etc/app.conf.bak"}},
+		{name: "group without comma", path: "/app/{config}", want: []string{"/app/{config}"}},
+		{name: "unclosed group", path: "/app/{config,secrets", want: []string{"/app/{config,secrets"}},
+		{name: "literal before group", path: "/{x}/{a,b}", want: []string{"/{x}/a", "/{x}/b"}},
+		{name: "variable reference", path: "${HOME}/{a,b}", want: []string{"${HOME}/a", "${HOME}/b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandBraces(tt.path))
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("TH_TEST_ROOT", "/srv/app")
+	t.Setenv("TH_TEST_EMPTY", "")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "bare variable", path: "$TH_TEST_ROOT/config", want: "/srv/app/config"},
+		{name: "braced variable", path: "${TH_TEST_ROOT}config", want: "/srv/appconfig"},
+		{name: "empty variable", path: "/a$TH_TEST_EMPTY/b", want: "/a/b"},
+		{name: "undefined variable", path: "$TH_TEST_UNDEFINED/src", want: "$TH_TEST_UNDEFINED/src"},
+		{name: "undefined braced variable", path: "${TH_TEST_UNDEFINED}/src", want: "${TH_TEST_UNDEFINED}/src"},
+		{name: "dollar dollar", path: "/cost$$/x", want: "/cost$/x"},
+		{name: "lone dollar", path: "/a/$/b$", want: "/a/$/b$"},
+		{name: "invalid braced name", path: "${1X}/a", want: "${1X}/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandEnv(tt.path))
+		})
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	t.Setenv("TH_TEST_ROOT", "/srv/app")
+	t.Setenv("TH_TEST_GROUP", "{x,y}")
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{
+			name:  "braces and variables",
+			paths: []string{"$TH_TEST_ROOT/{config,secrets}/", "/tmp"},
+			want:  []string{"/srv/app/config/", "/srv/app/secrets/", "/tmp"},
+		},
+		{
+			name:  "variables inside group",
+			paths: []string{"{$TH_TEST_ROOT,${TH_TEST_UNDEFINED}}/etc"},
+			want:  []string{"/srv/app/etc", "${TH_TEST_UNDEFINED}/etc"},
+		},
+		{
+			name:  "values are not brace expanded",
+			paths: []string{"/data/$TH_TEST_GROUP"},
+			want:  []string{"/data/{x,y}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandPaths(tt.paths))
+		})
+	}
+}
+
+func TestExpandPaths_Escapes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("backslash is the path separator on Windows")
+	}
+	t.Setenv("TH_TEST_ROOT", "/srv/app")
+
+	assert.Equal(t, []string{"/app/{a,b}"}, expandPaths([]string{`/app/\{a,b\}`}))
+	assert.Equal(t, []string{"/app/a,b", "/app/c"}, expandPaths([]string{`/app/{a\,b,c}`}))
+	assert.Equal(t, []string{"$TH_TEST_ROOT/x"}, expandPaths([]string{`\$TH_TEST_ROOT/x`}))
+	assert.Equal(t, []string{`/a\b`}, expandPaths([]string{`/a\\b`}))
+}
@@ -0,0 +1,52 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanWindowsPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "unc share", path: `\\server\share`, want: `\\server\share`},
+		{name: "unc dir", path: `\\server\share\dir\`, want: `\\server\share\dir`},
+		{name: "unc dot segments", path: `\\server\share\a\..\b\.\c`, want: `\\server\share\b\c`},
+		{name: "unc parent does not escape share", path: `\\server\share\..\..\dir`, want: `\\server\share\dir`},
+		{name: "unc forward slashes", path: `//server/share/dir`, want: `\\server\share\dir`},
+		{name: "unc server only", path: `\\server`, want: `\\server`},
+		{name: "drive root", path: `C:\`, want: `C:\`},
+		{name: "drive dir", path: `C:\Users\me\..\you\`, want: `C:\Users\you`},
+		{name: "drive forward slashes", path: `c:/repo/src`, want: `c:\repo\src`},
+		{name: "drive relative", path: `C:`, want: `C:.`},
+		{name: "extended length", path: `\\?\C:\very\..\long`, want: `\\?\C:\very\..\long`},
+		{name: "relative", path: `dir\sub\..\file.txt`, want: `dir\file.txt`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cleanWindowsPath(tt.path))
+		})
+	}
+}
+
+func TestJoinWindowsPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		root         string
+		relativePath string
+		want         string
+	}{
+		{name: "unc root", root: `\\server\share`, relativePath: ".", want: `\\server\share`},
+		{name: "unc file", root: `\\server\share\dir`, relativePath: "sub/file.txt", want: `\\server\share\dir\sub\file.txt`},
+		{name: "unc trailing separator", root: `\\server\share\`, relativePath: "file.txt", want: `\\server\share\file.txt`},
+		{name: "drive file", root: `C:\repo`, relativePath: "src/main.go", want: `C:\repo\src\main.go`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, joinWindowsPath(tt.root, tt.relativePath))
+		})
+	}
+}
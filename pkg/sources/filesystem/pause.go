@@ -0,0 +1,76 @@
+package filesystem
+
+import (
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// pauseGate blocks scanning while paused. The zero value isn't paused. It is
+// safe for concurrent use.
+type pauseGate struct {
+	mu sync.Mutex
+	// resumed is closed when the gate is resumed. It is nil when the gate
+	// isn't paused.
+	resumed chan struct{}
+}
+
+func (g *pauseGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+}
+
+func (g *pauseGate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
+
+func (g *pauseGate) paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumed != nil
+}
+
+// wait blocks while the gate is paused. It returns the context's error if it
+// is done first.
+func (g *pauseGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	resumed := g.resumed
+	g.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause halts the scan before the next file is opened, so that it uses no
+// more resources until Resume is called. Files being read when it's called
+// are finished first. Pausing isn't cancelling: a paused scan still returns
+// as soon as its context is done, and pausing a source that isn't scanning
+// makes its next scan wait.
+func (s *Source) Pause() {
+	s.pauseGate.pause()
+}
+
+// Resume continues a scan halted by Pause from the file it stopped at. It
+// does nothing if the source isn't paused.
+func (s *Source) Resume() {
+	s.pauseGate.resume()
+}
+
+// Paused reports whether Pause was called without a following Resume.
+func (s *Source) Paused() bool {
+	return s.pauseGate.paused()
+}
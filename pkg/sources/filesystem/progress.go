@@ -0,0 +1,47 @@
+package filesystem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// reportProgress updates the progress message with the file being scanned
+// and the counts so far every interval, until ctx is done or the returned
+// function is called. The function returns once updates have stopped.
+func (s *Source) reportProgress(ctx context.Context, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				s.SetProgressMessage(s.progressMessage())
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// progressMessage describes the file being scanned and the counts so far.
+func (s *Source) progressMessage() string {
+	summary := s.stats.summary()
+	counts := fmt.Sprintf("%d files scanned, %d skipped, %d failed, %d bytes read",
+		summary.FilesScanned, summary.FilesSkipped, summary.FilesFailed, summary.BytesRead)
+	current := s.stats.currentFile.Load()
+	if current == nil {
+		return fmt.Sprintf("Scanning (%s)", counts)
+	}
+	return fmt.Sprintf("File: %s (%s)", s.sanitizePath(*current), counts)
+}
@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+type ctxKey int
+
+// referencedByKey is the context key of the lockfile that caused a
+// referenced credential file to be scanned.
+const referencedByKey ctxKey = iota
+
+// lockfileCredentials maps the base names of lockfiles to the credential
+// files read by their package manager, such as for registry authentication.
+// Each of them is looked up next to the lockfile and in the home directory.
+var lockfileCredentials = map[string][]string{
+	"package-lock.json":   {".npmrc"},
+	"npm-shrinkwrap.json": {".npmrc"},
+	"Pipfile.lock":        {".netrc"},
+}
+
+// scanReferencedFiles scans the credential files used by the package manager
+// of lockfile, if it is one. Only the fixed locations in lockfileCredentials
+// are ever scanned, each at most once, and not if they're under a scanned
+// path. The chunks of a referenced file carry the lockfile's path in their
+// ReferencedBy metadata.
+func (s *Source) scanReferencedFiles(ctx context.Context, lockfile string, chunksChan chan *sources.Chunk) error {
+	names, ok := lockfileCredentials[filepath.Base(lockfile)]
+	if !ok {
+		return nil
+	}
+	dirs := []string{filepath.Dir(lockfile)}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+
+	refCtx := context.WithValue(ctx, referencedByKey, lockfile)
+	for _, name := range names {
+		for _, dir := range dirs {
+			path := normalizePath(filepath.Join(dir, name))
+			if s.inScanPaths(path) || !s.referenced.add(path) {
+				continue
+			}
+			if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			err := s.scanFile(refCtx, path, name, chunksChan)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				ctx.Logger().Info("error scanning referenced file", "path", path, "lockfile", lockfile, "error", err)
+				s.reportWarning(scanErrorCode(err), path, err)
+				continue
+			}
+			ctx.Logger().V(2).Info("scanned referenced credential file", "path", path, "lockfile", lockfile)
+		}
+	}
+	return nil
+}
+
+// inScanPaths reports whether path is one of the configured paths or under
+// one, in which case it's scanned regardless of being referenced.
+func (s *Source) inScanPaths(path string) bool {
+	for _, p := range s.paths {
+		if hasPathPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
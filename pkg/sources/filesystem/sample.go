@@ -0,0 +1,33 @@
+package filesystem
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"path/filepath"
+)
+
+// WithSampleRate only scans the fraction rate of the files found while
+// walking directories, for a quick triage of whether a large tree likely
+// contains secrets. It is not a complete scan: files outside of the sample
+// are skipped however likely they are to contain secrets. Files are selected
+// by a hash of their path relative to the scanned directory, so the same
+// files are sampled every time. Zero or one scans every file.
+func (s *Source) WithSampleRate(rate float64) error {
+	if rate < 0 || rate > 1 || math.IsNaN(rate) {
+		return fmt.Errorf("sample rate must be between 0 and 1, got %v", rate)
+	}
+	s.sampleRate = rate
+	return nil
+}
+
+// sampled reports whether the file at relativePath, relative to the scanned
+// directory, is in the sample selected by the sample rate.
+func (s *Source) sampled(relativePath string) bool {
+	if s.sampleRate <= 0 || s.sampleRate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(filepath.ToSlash(relativePath)))
+	return float64(h.Sum64())/math.MaxUint64 < s.sampleRate
+}
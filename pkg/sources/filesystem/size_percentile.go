@@ -0,0 +1,80 @@
+package filesystem
+
+import (
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// surveySizeCutoff walks the configured paths to record the size of every
+// regular file that would be scanned, and sets the size above which files are
+// skipped to the size at the configured percentile of them. It does nothing
+// unless a percentile is configured.
+func (s *Source) surveySizeCutoff(ctx context.Context) {
+	if s.sizePercentile <= 0 {
+		return
+	}
+	var sizes []int64
+	for _, path := range s.paths {
+		if ctx.Err() != nil {
+			return
+		}
+		cleanPath := normalizePath(path)
+		info, err := os.Stat(cleanPath)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			if info.Mode().IsRegular() {
+				sizes = append(sizes, info.Size())
+			}
+			continue
+		}
+		_ = fs.WalkDir(os.DirFS(cleanPath), ".", func(relativePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if relativePath != "." && s.excludeGlobs.Match(relativePath) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if _, ok := s.skipDirs[d.Name()]; ok && relativePath != "." {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				sizes = append(sizes, info.Size())
+			}
+			return nil
+		})
+	}
+	cutoff := sizePercentile(sizes, s.sizePercentile)
+	s.sizeCutoff.Store(cutoff)
+	ctx.Logger().V(2).Info("computed file size cutoff", "percentile", s.sizePercentile, "files", len(sizes), "cutoff", cutoff)
+}
+
+// sizePercentile returns the smallest of sizes that at least percentile
+// percent of sizes are less than or equal to, or zero if sizes is empty.
+func sizePercentile(sizes []int64, percentile int) int64 {
+	if len(sizes) == 0 {
+		return 0
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	rank := (percentile*len(sizes) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sizes) {
+		rank = len(sizes)
+	}
+	return sizes[rank-1]
+}
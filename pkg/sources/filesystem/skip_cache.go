@@ -0,0 +1,112 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// SkipCacheMode determines how files are compared against the skip cache to
+// decide whether they have changed since the previous scan.
+type SkipCacheMode int
+
+const (
+	// SkipCacheModeMetadata compares files by their size and modification
+	// time. This is cheap but can miss changes that preserve both.
+	SkipCacheModeMetadata SkipCacheMode = iota
+	// SkipCacheModeContent compares files by a hash of their content. This
+	// requires reading every file but reliably detects changes.
+	SkipCacheModeContent
+)
+
+// skipCache records a fingerprint of every file scanned so that unchanged
+// files can be skipped by subsequent scans. It is persisted to disk as JSON.
+type skipCache struct {
+	mu          sync.Mutex
+	path        string
+	mode        SkipCacheMode
+	forceRescan bool
+	files       map[string]string
+}
+
+type skipCacheFile struct {
+	Files map[string]string `json:"files"`
+}
+
+// loadSkipCache loads the skip cache stored at path. A missing file results in
+// an empty cache.
+func loadSkipCache(path string, mode SkipCacheMode, forceRescan bool) (*skipCache, error) {
+	cache := &skipCache{
+		path:        path,
+		mode:        mode,
+		forceRescan: forceRescan,
+		files:       make(map[string]string),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+		return nil, fmt.Errorf("unable to read skip cache: %w", err)
+	}
+	var stored skipCacheFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("unable to parse skip cache: %w", err)
+	}
+	if stored.Files != nil {
+		cache.files = stored.Files
+	}
+	return cache, nil
+}
+
+// fingerprint computes the value used to detect whether the file at path has
+// changed since it was last scanned.
+func (c *skipCache) fingerprint(path string, info fs.FileInfo) (string, error) {
+	if c.mode == SkipCacheModeMetadata {
+		return fmt.Sprintf("meta:%d:%d", info.Size(), info.ModTime().UnixNano()), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// unchanged returns true if the file at path was previously scanned with the
+// same fingerprint and a rescan is not being forced.
+func (c *skipCache) unchanged(path, fingerprint string) bool {
+	if c.forceRescan {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.files[path] == fingerprint
+}
+
+// update records the fingerprint of a scanned file.
+func (c *skipCache) update(path, fingerprint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[path] = fingerprint
+}
+
+// save persists the cache to disk.
+func (c *skipCache) save() error {
+	c.mu.Lock()
+	data, err := json.Marshal(skipCacheFile{Files: c.files})
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0600)
+}
@@ -0,0 +1,97 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// scanWithSkipCache runs a full scan of root using the skip cache at
+// cachePath and returns the scanned file names relative to root.
+func scanWithSkipCache(t *testing.T, root, cachePath string, mode SkipCacheMode, forceRescan bool) []string {
+	t.Helper()
+	ctx := context.Background()
+
+	conn, err := anypb.New(&sourcespb.Filesystem{Paths: []string{root}})
+	assert.NoError(t, err)
+	s := Source{}
+	assert.NoError(t, s.Init(ctx, "test", 0, 0, false, conn, 1))
+	assert.NoError(t, s.WithSkipCache(cachePath, mode, forceRescan))
+
+	chunksCh := make(chan *sources.Chunk, 1)
+	go func() {
+		defer close(chunksCh)
+		assert.NoError(t, s.Chunks(ctx, chunksCh))
+	}()
+	var chunks []*sources.Chunk
+	for chunk := range chunksCh {
+		chunks = append(chunks, chunk)
+	}
+	return chunkFiles(t, root, chunks)
+}
+
+func TestSource_SkipCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        SkipCacheMode
+		forceRescan bool
+		wantSecond  []string
+	}{
+		{name: "metadata", mode: SkipCacheModeMetadata, wantSecond: []string{"changed.txt"}},
+		{name: "content", mode: SkipCacheModeContent, wantSecond: []string{"changed.txt"}},
+		{name: "force rescan", mode: SkipCacheModeMetadata, forceRescan: true, wantSecond: []string{"changed.txt", "unchanged.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			cachePath := filepath.Join(t.TempDir(), "cache.json")
+			writeFiles(t, root, map[string]string{
+				"changed.txt":   "first version",
+				"unchanged.txt": "never changes",
+			})
+
+			first := scanWithSkipCache(t, root, cachePath, tt.mode, false)
+			assert.ElementsMatch(t, []string{"changed.txt", "unchanged.txt"}, first)
+
+			// Change the content and modification time of a single file.
+			changed := filepath.Join(root, "changed.txt")
+			assert.NoError(t, os.WriteFile(changed, []byte("second version, now longer"), 0644))
+			later := time.Now().Add(time.Minute)
+			assert.NoError(t, os.Chtimes(changed, later, later))
+
+			second := scanWithSkipCache(t, root, cachePath, tt.mode, tt.forceRescan)
+			assert.ElementsMatch(t, tt.wantSecond, second)
+		})
+	}
+}
+
+func TestSkipCache_ContentDetectsSameSizeChange(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "file.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("aaaa"), 0644))
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	cache, err := loadSkipCache(filepath.Join(root, "cache.json"), SkipCacheModeContent, false)
+	assert.NoError(t, err)
+	fingerprint, err := cache.fingerprint(path, info)
+	assert.NoError(t, err)
+	cache.update(path, fingerprint)
+
+	// Same size and modification time, different content.
+	assert.NoError(t, os.WriteFile(path, []byte("bbbb"), 0644))
+	assert.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	info, err = os.Stat(path)
+	assert.NoError(t, err)
+	fingerprint, err = cache.fingerprint(path, info)
+	assert.NoError(t, err)
+	assert.False(t, cache.unchanged(path, fingerprint))
+}
@@ -0,0 +1,78 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffSize is the number of bytes at the start of a file its content type is
+// detected from, which is all http.DetectContentType considers.
+const sniffSize = 512
+
+// contentTypeAllowlist is a list of media types, such as "application/json",
+// or wildcards of them, such as "text/*".
+type contentTypeAllowlist []string
+
+// newContentTypeAllowlist returns the allowlist of types, normalized, or nil
+// if types is empty.
+func newContentTypeAllowlist(types []string) contentTypeAllowlist {
+	var allowlist contentTypeAllowlist
+	for _, t := range types {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			allowlist = append(allowlist, t)
+		}
+	}
+	return allowlist
+}
+
+// allows returns true if the media type contentType, without parameters,
+// matches an entry of the allowlist.
+func (a contentTypeAllowlist) allows(contentType string) bool {
+	for _, pattern := range a {
+		switch {
+		case pattern == "*" || pattern == "*/*":
+			return true
+		case strings.HasSuffix(pattern, "/*"):
+			if strings.HasPrefix(contentType, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		case pattern == contentType:
+			return true
+		}
+	}
+	return false
+}
+
+// sniffContentType returns the media type of the file at path, detected from
+// its first bytes regardless of its extension.
+func sniffContentType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sample := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return detectContentType(sample[:n]), nil
+}
+
+// detectContentType returns the media type of content starting with sample,
+// without parameters. It's the type detected by http.DetectContentType, except
+// that text starting like a JSON object or array is application/json, as the
+// standard algorithm doesn't recognize JSON.
+func detectContentType(sample []byte) string {
+	contentType, _, _ := strings.Cut(http.DetectContentType(sample), ";")
+	if contentType == "text/plain" {
+		if trimmed := bytes.TrimLeft(sample, " \t\r\n"); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return "application/json"
+		}
+	}
+	return contentType
+}
@@ -0,0 +1,149 @@
+package filesystem
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// ScanSummary describes what a call to Chunks scanned, for reporting and for
+// callers that decide on an outcome, such as a CI exit code, without parsing
+// logs.
+type ScanSummary struct {
+	// FilesScanned is the number of files whose content was read, including
+	// archives and referenced credential files.
+	FilesScanned int64
+	// FilesSkipped is the number of files not read because of the source's
+	// configuration, such as exclude globs, the hidden files mode, the skip
+	// cache or symlinks that aren't followed. Files in skipped directories
+	// aren't counted, since they're never walked.
+	FilesSkipped int64
+	// FilesFailed is the number of files that could not be scanned.
+	FilesFailed int64
+	// BytesRead is the number of bytes read from files. Content extracted
+	// from archives isn't counted.
+	BytesRead int64
+	// ChunksEmitted is the number of chunks sent to the chunks channel,
+	// including decoded and extracted ones.
+	ChunksEmitted int64
+	// StartTime is when the scan started.
+	StartTime time.Time
+	// Duration is how long the scan took, or has taken so far if it hasn't
+	// finished.
+	Duration time.Duration
+}
+
+// Summary returns the ScanSummary of the latest call to Chunks. It may be
+// called while Chunks runs to get the counts so far.
+func (s *Source) Summary() ScanSummary {
+	return s.stats.summary()
+}
+
+// scanStats accumulates a ScanSummary. It is safe for concurrent use.
+type scanStats struct {
+	filesScanned  atomic.Int64
+	filesSkipped  atomic.Int64
+	filesFailed   atomic.Int64
+	bytesRead     atomic.Int64
+	chunksEmitted atomic.Int64
+	// currentFile is the path of the file being scanned.
+	currentFile atomic.Pointer[string]
+
+	mu       sync.Mutex
+	start    time.Time
+	duration time.Duration
+}
+
+// reset clears the counts for a scan starting at start.
+func (st *scanStats) reset(start time.Time) {
+	st.filesScanned.Store(0)
+	st.filesSkipped.Store(0)
+	st.filesFailed.Store(0)
+	st.bytesRead.Store(0)
+	st.chunksEmitted.Store(0)
+	st.currentFile.Store(nil)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.start = start
+	st.duration = 0
+}
+
+// finish records the duration of the scan.
+func (st *scanStats) finish() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.duration = time.Since(st.start)
+}
+
+// fileDone counts a file scanFile returned err for. Files that fail because
+// the scan was cancelled aren't counted.
+func (st *scanStats) fileDone(ctx context.Context, skipped bool, err error) {
+	switch {
+	case skipped:
+		st.filesSkipped.Add(1)
+	case err == nil:
+		st.filesScanned.Add(1)
+	case ctx.Err() == nil:
+		st.filesFailed.Add(1)
+	}
+}
+
+func (st *scanStats) summary() ScanSummary {
+	st.mu.Lock()
+	start, duration := st.start, st.duration
+	st.mu.Unlock()
+	if duration == 0 && !start.IsZero() {
+		duration = time.Since(start)
+	}
+	return ScanSummary{
+		FilesScanned:  st.filesScanned.Load(),
+		FilesSkipped:  st.filesSkipped.Load(),
+		FilesFailed:   st.filesFailed.Load(),
+		BytesRead:     st.bytesRead.Load(),
+		ChunksEmitted: st.chunksEmitted.Load(),
+		StartTime:     start,
+		Duration:      duration,
+	}
+}
+
+// countChunks returns a channel that forwards chunks to out, counting them,
+// since chunks of archives are sent by the handlers rather than the source.
+// wait must be called once nothing is sent to the returned channel anymore,
+// and returns once every chunk was forwarded or the context is done.
+func (st *scanStats) countChunks(ctx context.Context, out chan *sources.Chunk) (chan *sources.Chunk, func()) {
+	in := make(chan *sources.Chunk)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for chunk := range in {
+			if err := common.CancellableWrite(ctx, out, chunk); err != nil {
+				// Keep receiving so that senders, which stop once the
+				// context is done, don't block.
+				continue
+			}
+			st.chunksEmitted.Add(1)
+		}
+	}()
+	return in, func() {
+		close(in)
+		<-done
+	}
+}
+
+// countingReader counts the bytes read from reader.
+type countingReader struct {
+	reader io.Reader
+	count  *atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// followSymlink reports whether the symlink at path, found while walking a
+// directory with file symlinks followed, should be scanned. Only symlinks to
+// regular files are, and each target at most once. Targets under a scanned
+// path are skipped too, since they're scanned when that path is walked, and
+// targets outside of them are skipped unless escaping the scanned paths is
+// allowed.
+func (s *Source) followSymlink(ctx context.Context, path string) bool {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		ctx.Logger().V(2).Info("unable to resolve symlink", "path", path, "error", err)
+		return false
+	}
+	if target, err = filepath.Abs(target); err != nil {
+		return false
+	}
+	if info, err := os.Stat(target); err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if s.underScanPath(target) {
+		ctx.Logger().V(5).Info("skipping symlink to a file that is already scanned", "path", path, "target", target)
+		return false
+	}
+	if !s.allowSymlinkEscape {
+		ctx.Logger().Info("skipping symlink to a file outside of the scanned paths", "path", path, "target", target)
+		return false
+	}
+	if !s.symlinkTargets.add(target) {
+		ctx.Logger().V(5).Info("skipping symlink to a file that is already scanned", "path", path, "target", target)
+		return false
+	}
+	ctx.Logger().Info("following symlink to a file outside of the scanned paths", "path", path, "target", target)
+	return true
+}
+
+// underScanPath reports whether the resolved path target is under one of the
+// configured paths, once those are resolved too.
+func (s *Source) underScanPath(target string) bool {
+	for _, p := range s.paths {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			p = resolved
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		if hasPathPrefix(target, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package filesystem
+
+import (
+	"io"
+
+	"golang.org/x/time/rate"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// newReadLimiter creates a token bucket limiting reads to bytesPerSecond. The
+// burst is capped at BufferSize so throughput stays close to the limit.
+func newReadLimiter(bytesPerSecond int64) *rate.Limiter {
+	burst := bytesPerSecond
+	if burst > BufferSize {
+		burst = BufferSize
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(burst))
+}
+
+// throttledReader is an io.Reader that waits on a shared limiter before
+// returning data, so that all files read by a source share one bandwidth
+// budget.
+type throttledReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
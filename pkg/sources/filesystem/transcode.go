@@ -0,0 +1,113 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encodings detected by detectEncoding, as recorded in chunk metadata.
+const (
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingLatin1  = "iso-8859-1"
+)
+
+// encodingSampleSize is the number of bytes at the start of a file that its
+// encoding is detected from.
+const encodingSampleSize = 4096
+
+// transcodeReader detects the encoding of the content read from r and, if it's
+// UTF-16 or Latin-1, returns a reader of the content transcoded to UTF-8 along
+// with the encoding's name. Otherwise, including for binary content, the
+// content is returned unchanged with an empty name.
+func transcodeReader(r io.Reader) (io.Reader, string) {
+	buffered := bufio.NewReaderSize(r, encodingSampleSize)
+	sample, _ := buffered.Peek(encodingSampleSize)
+
+	switch detectEncoding(sample) {
+	case encodingUTF16LE:
+		decoder := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+		return transform.NewReader(buffered, decoder), encodingUTF16LE
+	case encodingUTF16BE:
+		decoder := unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+		return transform.NewReader(buffered, decoder), encodingUTF16BE
+	case encodingLatin1:
+		return transform.NewReader(buffered, charmap.ISO8859_1.NewDecoder()), encodingLatin1
+	default:
+		return buffered, ""
+	}
+}
+
+// detectEncoding returns the encoding of text starting with sample, or an
+// empty string if it's UTF-8 or doesn't look like text.
+//
+// UTF-16 is detected by its byte order mark or, without one, by nearly every
+// code unit being an ASCII character, which has a zero high byte. Latin-1 is
+// only assumed for content that isn't valid UTF-8 and has no control
+// characters other than whitespace, since any byte sequence is valid Latin-1.
+func detectEncoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return encodingUTF16LE
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return encodingUTF16BE
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return ""
+	}
+
+	units := len(sample) / 2
+	if units == 0 {
+		return ""
+	}
+	var le, be int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i+1] == 0 && isTextByte(sample[i]) {
+			le++
+		}
+		if sample[i] == 0 && isTextByte(sample[i+1]) {
+			be++
+		}
+	}
+	// Tolerate some non-ASCII characters, but not as many as binary content
+	// with runs of zeros would have.
+	switch {
+	case le*10 >= units*9:
+		return encodingUTF16LE
+	case be*10 >= units*9:
+		return encodingUTF16BE
+	}
+
+	if validUTF8Prefix(sample) {
+		return ""
+	}
+	for _, b := range sample {
+		if !isTextByte(b) && b < 0xA0 {
+			return ""
+		}
+	}
+	return encodingLatin1
+}
+
+// isTextByte reports whether b is a printable ASCII character or whitespace.
+func isTextByte(b byte) bool {
+	return (b >= 0x20 && b < 0x7F) || b == '\t' || b == '\n' || b == '\r'
+}
+
+// validUTF8Prefix reports whether sample is valid UTF-8, except for a rune
+// that may be cut off at its end.
+func validUTF8Prefix(sample []byte) bool {
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size == 1 {
+			return len(sample) < utf8.UTFMax && !utf8.FullRune(sample)
+		}
+		sample = sample[size:]
+	}
+	return true
+}
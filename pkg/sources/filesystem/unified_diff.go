@@ -0,0 +1,186 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// addedRegion is a run of consecutive lines added by a diff.
+type addedRegion struct {
+	// Line is the 1-based line number of the first added line in the new
+	// version of the file.
+	Line int64
+	Data []byte
+}
+
+// diffFile contains the added regions of a single file in a unified diff.
+type diffFile struct {
+	// Path is the path of the file after the change, relative to the root
+	// of the diff.
+	Path    string
+	Binary  bool
+	Regions []addedRegion
+}
+
+// hunk tracks the position within a hunk of a unified diff.
+type hunk struct {
+	// newLine is the line number in the new file of the next line.
+	newLine int64
+	// oldLeft and newLeft are the number of lines remaining in the hunk for
+	// the old and new versions of the file.
+	oldLeft, newLeft int64
+}
+
+func (h *hunk) done() bool {
+	return h.oldLeft <= 0 && h.newLeft <= 0
+}
+
+// parseUnifiedDiff parses the output of `git diff` (or any unified diff) and
+// returns the lines added to each file. Deleted files are omitted, and binary
+// files are returned without regions.
+func parseUnifiedDiff(r io.Reader) ([]diffFile, error) {
+	var (
+		files   []diffFile
+		current *diffFile
+		region  *addedRegion
+		h       *hunk
+	)
+	flushRegion := func() {
+		if region != nil {
+			current.Regions = append(current.Regions, *region)
+		}
+		region = nil
+	}
+	flushFile := func() {
+		if current == nil {
+			return
+		}
+		flushRegion()
+		if current.Path != "" {
+			files = append(files, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if h != nil {
+			switch {
+			case len(line) > 0 && line[0] == '+':
+				if region == nil {
+					region = &addedRegion{Line: h.newLine}
+				}
+				region.Data = append(region.Data, line[1:]...)
+				region.Data = append(region.Data, '\n')
+				h.newLine++
+				h.newLeft--
+			case len(line) > 0 && line[0] == '-':
+				flushRegion()
+				h.oldLeft--
+			case len(line) > 0 && line[0] == '\\':
+				// "\ No newline at end of file"
+			default:
+				// Context line. Some tools strip the leading space from
+				// empty context lines.
+				flushRegion()
+				h.newLine++
+				h.oldLeft--
+				h.newLeft--
+			}
+			if h.done() {
+				flushRegion()
+				h = nil
+			}
+			continue
+		}
+
+		switch {
+		case bytes.HasPrefix(line, []byte("diff --git ")):
+			flushFile()
+			current = &diffFile{Path: pathFromDiffGitLine(string(line))}
+		case bytes.HasPrefix(line, []byte("--- ")):
+			// Diffs that aren't from git start each file with this line.
+			if current == nil || len(current.Regions) > 0 {
+				flushFile()
+				current = &diffFile{}
+			}
+		case current == nil:
+			// Preamble before the first file.
+		case bytes.HasPrefix(line, []byte("@@ ")):
+			parsed, err := parseHunkHeader(string(line))
+			if err != nil {
+				return nil, err
+			}
+			h = parsed
+		case bytes.HasPrefix(line, []byte("rename to ")):
+			current.Path = string(line[len("rename to "):])
+		case bytes.HasPrefix(line, []byte("+++ ")):
+			path := string(line[len("+++ "):])
+			// Strip any timestamp added by diff -u.
+			path, _, _ = strings.Cut(path, "\t")
+			if path == "/dev/null" {
+				// The file was deleted, so nothing was added.
+				current.Path = ""
+				continue
+			}
+			current.Path = strings.TrimPrefix(path, "b/")
+		case bytes.HasPrefix(line, []byte("Binary files ")), bytes.Equal(line, []byte("GIT binary patch")):
+			current.Binary = true
+		case bytes.HasPrefix(line, []byte("deleted file mode")):
+			current.Path = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read diff: %w", err)
+	}
+	flushFile()
+	return files, nil
+}
+
+// pathFromDiffGitLine returns the new path from a "diff --git a/x b/y" line.
+// It is overridden by later "rename to" and "+++" lines when present.
+func pathFromDiffGitLine(line string) string {
+	if idx := strings.LastIndex(line, " b/"); idx >= 0 {
+		return line[idx+len(" b/"):]
+	}
+	return ""
+}
+
+// parseHunkHeader parses a hunk header like "@@ -1,3 +4,5 @@".
+func parseHunkHeader(header string) (*hunk, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return nil, fmt.Errorf("invalid hunk header %q", header)
+	}
+	_, oldCount, err := parseHunkRange(fields[1][1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hunk header %q: %w", header, err)
+	}
+	newStart, newCount, err := parseHunkRange(fields[2][1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hunk header %q: %w", header, err)
+	}
+	return &hunk{newLine: newStart, oldLeft: oldCount, newLeft: newCount}, nil
+}
+
+// parseHunkRange parses a "start,count" range. The count defaults to 1 when
+// omitted.
+func parseHunkRange(r string) (start, count int64, err error) {
+	startStr, countStr, hasCount := strings.Cut(r, ",")
+	if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if hasCount {
+		if count, err = strconv.ParseInt(countStr, 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
@@ -0,0 +1,107 @@
+package filesystem
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleDiff = `diff --git a/config.yaml b/config.yaml
+index 83db48f..bf269f4 100644
+--- a/config.yaml
++++ b/config.yaml
+@@ -1,4 +1,5 @@
+ name: app
+-token: old
++token: new
++secret: added
+ region: us-east-1
+ debug: false
+@@ -10,2 +11,3 @@ settings:
+ timeout: 30
++password: hunter2
+ retries: 3
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3b18e51
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+\ No newline at end of file
+diff --git a/old/name.env b/new/name.env
+similarity index 90%
+rename from old/name.env
+rename to new/name.env
+index 1111111..2222222 100644
+--- a/old/name.env
++++ b/new/name.env
+@@ -1 +1 @@
+-KEY=1
++KEY=2
+diff --git a/moved.txt b/renamed.txt
+similarity index 100%
+rename from moved.txt
+rename to renamed.txt
+diff --git a/image.png b/image.png
+index 3333333..4444444 100644
+Binary files a/image.png and b/image.png differ
+diff --git a/removed.txt b/removed.txt
+deleted file mode 100644
+index 5555555..0000000
+--- a/removed.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-gone
+-forever
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	files, err := parseUnifiedDiff(strings.NewReader(sampleDiff))
+	assert.NoError(t, err)
+	assert.Equal(t, []diffFile{
+		{
+			Path: "config.yaml",
+			Regions: []addedRegion{
+				{Line: 2, Data: []byte("token: new\nsecret: added\n")},
+				{Line: 12, Data: []byte("password: hunter2\n")},
+			},
+		},
+		{
+			Path:    "new.txt",
+			Regions: []addedRegion{{Line: 1, Data: []byte("hello\nworld\n")}},
+		},
+		{
+			Path:    "new/name.env",
+			Regions: []addedRegion{{Line: 1, Data: []byte("KEY=2\n")}},
+		},
+		{Path: "renamed.txt"},
+		{Path: "image.png", Binary: true},
+	}, files)
+}
+
+func TestParseUnifiedDiff_NonGit(t *testing.T) {
+	diff := "--- a.txt\t2023-01-01 00:00:00\n" +
+		"+++ a.txt\t2023-01-02 00:00:00\n" +
+		"@@ -1 +1,2 @@\n" +
+		" one\n" +
+		"+two\n" +
+		"--- b.txt\n" +
+		"+++ b.txt\n" +
+		"@@ -3,0 +4 @@\n" +
+		"+four\n"
+	files, err := parseUnifiedDiff(strings.NewReader(diff))
+	assert.NoError(t, err)
+	assert.Equal(t, []diffFile{
+		{Path: "a.txt", Regions: []addedRegion{{Line: 2, Data: []byte("two\n")}}},
+		{Path: "b.txt", Regions: []addedRegion{{Line: 4, Data: []byte("four\n")}}},
+	}, files)
+}
+
+func TestParseUnifiedDiff_InvalidHunk(t *testing.T) {
+	diff := "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -x +1 @@\n+one\n"
+	_, err := parseUnifiedDiff(strings.NewReader(diff))
+	assert.Error(t, err)
+}
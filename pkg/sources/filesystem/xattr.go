@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// errAttributesUnsupported is returned by listAttributes on platforms, or
+// filesystems, without extended attributes or alternate data streams.
+var errAttributesUnsupported = errors.New("file attributes are not supported")
+
+// attributeSeparator separates the path of a file from the name of one of its
+// attributes in chunk metadata, as in the Windows syntax for alternate data
+// streams.
+const attributeSeparator = ":"
+
+// scanFileAttributes emits the chunks of the extended attributes (on Linux and
+// macOS) or the alternate data streams (on Windows) of the file at path. The
+// file of their metadata is path followed by attributeSeparator and the name
+// of the attribute.
+func (s *Source) scanFileAttributes(ctx context.Context, path, relativePath string, chunksChan chan *sources.Chunk) error {
+	names, err := listAttributes(path)
+	if errors.Is(err, errAttributesUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to list file attributes: %w", err)
+	}
+	for _, name := range names {
+		r, err := openAttribute(path, name)
+		if err != nil {
+			ctx.Logger().V(2).Info("unable to read file attribute", "path", path, "attribute", name, "error", err)
+			continue
+		}
+		metadata := s.fileMetadata(ctx, path, relativePath, 0)
+		fsMetadata := metadata.GetFilesystem()
+		fsMetadata.File = s.sanitizePath(path + attributeSeparator + name)
+		if relativePath != "" {
+			fsMetadata.RelativePath = s.sanitizePath(relativePath + attributeSeparator + name)
+		}
+		err = s.chunkReader(ctx, r, metadata, false, chunksChan)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("unable to scan file attribute %q: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !windows
+
+package filesystem
+
+import "io"
+
+// listAttributes isn't supported on this platform.
+func listAttributes(string) ([]string, error) {
+	return nil, errAttributesUnsupported
+}
+
+// openAttribute isn't supported on this platform.
+func openAttribute(string, string) (io.ReadCloser, error) {
+	return nil, errAttributesUnsupported
+}
@@ -0,0 +1,64 @@
+//go:build linux || darwin
+
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// listAttributes returns the names of the extended attributes of the file at
+// path.
+func listAttributes(path string) ([]string, error) {
+	data, err := readXattr(func(dest []byte) (int, error) { return unix.Listxattr(path, dest) })
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil, errAttributesUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(data), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// openAttribute returns a reader of the value of the extended attribute name
+// of the file at path.
+func openAttribute(path, name string) (io.ReadCloser, error) {
+	data, err := readXattr(func(dest []byte) (int, error) { return unix.Getxattr(path, name, dest) })
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// readXattr calls read with a buffer large enough for the list or value it
+// reads, retrying if it grew in between.
+func readXattr(read func(dest []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := read(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		dest := make([]byte, size)
+		n, err := read(dest)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return dest[:n], nil
+	}
+}
@@ -0,0 +1,45 @@
+//go:build linux || darwin
+
+package filesystem
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestSource_FileAttributes(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.txt": "content", "b.txt": "other content"})
+	// Unprivileged processes can only set attributes in the user namespace
+	// on Linux.
+	err := unix.Setxattr(filepath.Join(root, "a.txt"), "user.note", []byte("password=hunter2"), 0)
+	if errors.Is(err, unix.ENOTSUP) {
+		t.Skip("the filesystem of the temporary directory doesn't support extended attributes")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scan := func(enabled bool) map[string]string {
+		s := Source{}
+		s.WithFileAttributes(enabled)
+		chunks := scanDirChunks(t, &s, root)
+		got := map[string]string{}
+		for i, file := range chunkFiles(t, root, chunks) {
+			got[file] = strings.TrimRight(string(chunks[i].Data), "\x00")
+		}
+		return got
+	}
+
+	assert.Equal(t, map[string]string{"a.txt": "content", "b.txt": "other content"}, scan(false))
+	assert.Equal(t, map[string]string{
+		"a.txt":           "content",
+		"a.txt:user.note": "password=hunter2",
+		"b.txt":           "other content",
+	}, scan(true))
+}
@@ -0,0 +1,72 @@
+//go:build windows
+
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32             = windows.NewLazySystemDLL("kernel32.dll")
+	procFindFirstStreamW = kernel32.NewProc("FindFirstStreamW")
+	procFindNextStreamW  = kernel32.NewProc("FindNextStreamW")
+)
+
+// win32FindStreamData is the WIN32_FIND_STREAM_DATA structure.
+type win32FindStreamData struct {
+	StreamSize int64
+	StreamName [windows.MAX_PATH + 36]uint16
+}
+
+// defaultStream is the name of the unnamed stream holding a file's content.
+const defaultStream = "::$DATA"
+
+// listAttributes returns the names of the alternate data streams of the file
+// at path, without their ":$DATA" type suffix.
+func listAttributes(path string) ([]string, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	var data win32FindStreamData
+	// FindStreamInfoStandard is the only info level, 0.
+	handle, _, err := procFindFirstStreamW.Call(uintptr(unsafe.Pointer(pathPtr)), 0, uintptr(unsafe.Pointer(&data)), 0)
+	if windows.Handle(handle) == windows.InvalidHandle {
+		switch {
+		case errors.Is(err, windows.ERROR_HANDLE_EOF):
+			return nil, nil
+		case errors.Is(err, windows.ERROR_INVALID_PARAMETER), errors.Is(err, windows.ERROR_NOT_SUPPORTED):
+			// The filesystem doesn't support streams, such as FAT.
+			return nil, errAttributesUnsupported
+		}
+		return nil, err
+	}
+	defer windows.FindClose(windows.Handle(handle))
+
+	var names []string
+	for {
+		name := windows.UTF16ToString(data.StreamName[:])
+		if name != defaultStream {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(name, ":"), ":$DATA"))
+		}
+		ok, _, err := procFindNextStreamW.Call(handle, uintptr(unsafe.Pointer(&data)))
+		if ok == 0 {
+			if errors.Is(err, windows.ERROR_HANDLE_EOF) {
+				return names, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// openAttribute returns a reader of the alternate data stream name of the
+// file at path.
+func openAttribute(path, name string) (io.ReadCloser, error) {
+	return os.Open(path + ":" + name)
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource_FileAttributes(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.txt": "content", "b.txt": "other content"})
+	if err := os.WriteFile(filepath.Join(root, "a.txt")+":note", []byte("password=hunter2"), 0644); err != nil {
+		t.Skipf("the filesystem of the temporary directory doesn't support alternate data streams: %v", err)
+	}
+
+	scan := func(enabled bool) map[string]string {
+		s := Source{}
+		s.WithFileAttributes(enabled)
+		chunks := scanDirChunks(t, &s, root)
+		got := map[string]string{}
+		for i, file := range chunkFiles(t, root, chunks) {
+			got[file] = strings.TrimRight(string(chunks[i].Data), "\x00")
+		}
+		return got
+	}
+
+	assert.Equal(t, map[string]string{"a.txt": "content", "b.txt": "other content"}, scan(false))
+	assert.Equal(t, map[string]string{
+		"a.txt":      "content",
+		"a.txt:note": "password=hunter2",
+		"b.txt":      "other content",
+	}, scan(true))
+}
@@ -0,0 +1,49 @@
+package sources
+
+import (
+	"time"
+)
+
+// LifecycleEventKind identifies the point in a source's lifecycle an event was
+// emitted at.
+type LifecycleEventKind string
+
+const (
+	// EventInitialized is emitted when Init returns.
+	EventInitialized LifecycleEventKind = "initialized"
+	// EventChunkingStarted is emitted when Chunks starts.
+	EventChunkingStarted LifecycleEventKind = "chunking_started"
+	// EventChunkingFinished is emitted when Chunks returns.
+	EventChunkingFinished LifecycleEventKind = "chunking_finished"
+	// EventEnumerationFinished is emitted when Enumerate returns.
+	EventEnumerationFinished LifecycleEventKind = "enumeration_finished"
+	// EventUnitStarted is emitted when ChunkUnit starts chunking a unit.
+	EventUnitStarted LifecycleEventKind = "unit_started"
+	// EventUnitFinished is emitted when ChunkUnit returns.
+	EventUnitFinished LifecycleEventKind = "unit_finished"
+	// EventError is emitted for an error that doesn't stop the source, such
+	// as a path that could not be scanned.
+	EventError LifecycleEventKind = "error"
+)
+
+// LifecycleEvent describes a point in the lifecycle of a source.
+type LifecycleEvent struct {
+	Kind       LifecycleEventKind
+	SourceName string
+	// Unit is the ID of the unit or the path the event is about, if any.
+	Unit string
+	// Time is when the event was emitted.
+	Time time.Time
+	// Duration is how long the step that finished took. It is zero for
+	// events that don't finish a step.
+	Duration time.Duration
+	// Err is the error the step failed with, if any.
+	Err error
+}
+
+// LifecycleHook receives the lifecycle events of sources, for example to
+// record tracing spans or structured logs. Implementations must be safe for
+// concurrent use.
+type LifecycleHook interface {
+	OnLifecycleEvent(event LifecycleEvent)
+}
@@ -0,0 +1,99 @@
+package sources
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+// ndjsonChunk is the serialized form of a Chunk. Data is base64 encoded by
+// encoding/json and SourceMetadata uses the protobuf JSON mapping.
+type ndjsonChunk struct {
+	SourceName     string                  `json:"source_name"`
+	SourceID       int64                   `json:"source_id"`
+	SourceType     sourcespb.SourceType    `json:"source_type"`
+	SourceMetadata json.RawMessage         `json:"source_metadata,omitempty"`
+	SourceUnitID   string                  `json:"source_unit_id,omitempty"`
+	Data           []byte                  `json:"data"`
+	Verify         bool                    `json:"verify"`
+	DecoderType    detectorspb.DecoderType `json:"decoder_type,omitempty"`
+}
+
+// NDJSONChunkWriter serializes chunks as newline delimited JSON so they can
+// be consumed by tooling outside of the engine.
+type NDJSONChunkWriter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONChunkWriter creates an NDJSONChunkWriter that writes to w.
+func NewNDJSONChunkWriter(w io.Writer) *NDJSONChunkWriter {
+	return &NDJSONChunkWriter{enc: json.NewEncoder(w)}
+}
+
+// Write serializes a single chunk as one line of JSON.
+func (w *NDJSONChunkWriter) Write(chunk *Chunk) error {
+	c := ndjsonChunk{
+		SourceName:   chunk.SourceName,
+		SourceID:     chunk.SourceID,
+		SourceType:   chunk.SourceType,
+		SourceUnitID: chunk.SourceUnitID,
+		Data:         chunk.Data,
+		Verify:       chunk.Verify,
+		DecoderType:  chunk.DecoderType,
+	}
+	if chunk.SourceMetadata != nil {
+		metadata, err := protojson.Marshal(chunk.SourceMetadata)
+		if err != nil {
+			return fmt.Errorf("unable to marshal source metadata: %w", err)
+		}
+		c.SourceMetadata = metadata
+	}
+	if err := w.enc.Encode(c); err != nil {
+		return fmt.Errorf("unable to encode chunk: %w", err)
+	}
+	return nil
+}
+
+// NDJSONChunkReader deserializes chunks written by an NDJSONChunkWriter.
+type NDJSONChunkReader struct {
+	dec *json.Decoder
+}
+
+// NewNDJSONChunkReader creates an NDJSONChunkReader that reads from r.
+func NewNDJSONChunkReader(r io.Reader) *NDJSONChunkReader {
+	return &NDJSONChunkReader{dec: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// Read returns the next chunk, or io.EOF when there are no more chunks.
+func (r *NDJSONChunkReader) Read() (*Chunk, error) {
+	var c ndjsonChunk
+	if err := r.dec.Decode(&c); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unable to decode chunk: %w", err)
+	}
+	chunk := &Chunk{
+		SourceName:   c.SourceName,
+		SourceID:     c.SourceID,
+		SourceType:   c.SourceType,
+		SourceUnitID: c.SourceUnitID,
+		Data:         c.Data,
+		Verify:       c.Verify,
+		DecoderType:  c.DecoderType,
+	}
+	if len(c.SourceMetadata) > 0 {
+		chunk.SourceMetadata = &source_metadatapb.MetaData{}
+		if err := protojson.Unmarshal(c.SourceMetadata, chunk.SourceMetadata); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal source metadata: %w", err)
+		}
+	}
+	return chunk, nil
+}
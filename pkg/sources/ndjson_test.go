@@ -0,0 +1,65 @@
+package sources
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+func TestNDJSONChunk_RoundTrip(t *testing.T) {
+	chunks := []*Chunk{
+		{
+			SourceName: "trufflehog - filesystem",
+			SourceID:   1,
+			SourceType: sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM,
+			SourceMetadata: &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Filesystem{
+					Filesystem: &source_metadatapb.Filesystem{File: "/tmp/secrets.txt"},
+				},
+			},
+			SourceUnitID: "/tmp/secrets.txt",
+			Data:         []byte("password=hunter2\n"),
+			Verify:       true,
+		},
+		{
+			SourceType:  sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM,
+			Data:        []byte{0x00, 0xff, 0x10, '\n'},
+			DecoderType: detectorspb.DecoderType_BASE64,
+		},
+	}
+
+	var buf bytes.Buffer
+	w := NewNDJSONChunkWriter(&buf)
+	for _, chunk := range chunks {
+		assert.NoError(t, w.Write(chunk))
+	}
+	assert.Equal(t, len(chunks), strings.Count(buf.String(), "\n"))
+
+	r := NewNDJSONChunkReader(&buf)
+	for _, want := range chunks {
+		got, err := r.Read()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, proto.Equal(want.SourceMetadata, got.SourceMetadata))
+		want.SourceMetadata, got.SourceMetadata = nil, nil
+		assert.Equal(t, want, got)
+	}
+	_, err := r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNDJSONChunkReader_Invalid(t *testing.T) {
+	r := NewNDJSONChunkReader(strings.NewReader("{not json}\n"))
+	_, err := r.Read()
+	assert.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
@@ -0,0 +1,45 @@
+package sources
+
+import (
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// ScanSource runs the Chunks method of the initialized src and calls handler
+// with each chunk it emits, in order, until it returns. It saves embedders
+// from wiring up a channel and goroutine themselves.
+//
+// If handler returns an error, the source is cancelled and ScanSource returns
+// that error once it has stopped. Otherwise, it returns the error of Chunks,
+// or that of ctx if it was cancelled.
+func ScanSource(ctx context.Context, src Source, handler func(*Chunk) error) error {
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunksChan := make(chan *Chunk, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(chunksChan)
+		errChan <- src.Chunks(scanCtx, chunksChan)
+	}()
+
+	var handlerErr error
+	for chunk := range chunksChan {
+		if handlerErr != nil {
+			// Drain the channel so the source can exit.
+			continue
+		}
+		if err := handler(chunk); err != nil {
+			handlerErr = err
+			cancel()
+		}
+	}
+
+	chunksErr := <-errChan
+	if handlerErr != nil {
+		return handlerErr
+	}
+	if chunksErr != nil {
+		return chunksErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,65 @@
+package sources_test
+
+import (
+	aCtx "context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/filesystem"
+)
+
+// The test is in an external package since the filesystem source imports
+// sources.
+
+func newFilesystemSource(t *testing.T, files int) *filesystem.Source {
+	t.Helper()
+	dir := t.TempDir()
+	for i := 0; i < files; i++ {
+		name := filepath.Join(dir, string(rune('a'+i))+".txt")
+		if err := os.WriteFile(name, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn, err := anypb.New(&sourcespb.Filesystem{Paths: []string{dir}})
+	assert.NoError(t, err)
+	s := &filesystem.Source{}
+	assert.NoError(t, s.Init(context.Background(), "test", 0, 0, false, conn, 1))
+	return s
+}
+
+func TestScanSource(t *testing.T) {
+	var count int
+	err := sources.ScanSource(context.Background(), newFilesystemSource(t, 3), func(chunk *sources.Chunk) error {
+		assert.Equal(t, "content", string(chunk.Data))
+		count++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestScanSource_HandlerError(t *testing.T) {
+	errStop := errors.New("stop")
+	var count int
+	err := sources.ScanSource(context.Background(), newFilesystemSource(t, 5), func(*sources.Chunk) error {
+		count++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, count)
+}
+
+func TestScanSource_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := sources.ScanSource(ctx, newFilesystemSource(t, 3), func(*sources.Chunk) error { return nil })
+	assert.ErrorIs(t, err, aCtx.Canceled)
+}
@@ -1,8 +1,11 @@
 package sources
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 )
 
 // Ensure CommonSourceUnit implements SourceUnit at compile time.
@@ -12,6 +15,9 @@ var _ SourceUnit = CommonSourceUnit{}
 // use instead of implementing their own types.
 type CommonSourceUnit struct {
 	ID string `json:"source_unit_id"`
+	// Metadata is optional context about the unit, such as its size, that
+	// sources can attach during enumeration.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // SourceUnitID implements the SourceUnit interface.
@@ -35,3 +41,41 @@ func (c CommonSourceUnitUnmarshaller) UnmarshalSourceUnit(data []byte) (SourceUn
 	}
 	return unit, nil
 }
+
+// WriteSourceUnit serializes unit as one line of JSON, so that units
+// enumerated on one machine can be chunked on another. Units are read back
+// with ReadSourceUnits.
+func WriteSourceUnit(w io.Writer, unit SourceUnit) error {
+	data, err := json.Marshal(unit)
+	if err != nil {
+		return fmt.Errorf("unable to marshal unit %q: %w", unit.SourceUnitID(), err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("unable to write unit %q: %w", unit.SourceUnitID(), err)
+	}
+	return nil
+}
+
+// ReadSourceUnits deserializes the units written to r by WriteSourceUnit
+// using unmarshaller, typically the source that will chunk them. Blank lines
+// are ignored.
+func ReadSourceUnits(r io.Reader, unmarshaller SourceUnitUnmarshaller) ([]SourceUnit, error) {
+	var units []SourceUnit
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		unit, err := unmarshaller.UnmarshalSourceUnit(data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read unit on line %d: %w", line, err)
+		}
+		units = append(units, unit)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read units: %w", err)
+	}
+	return units, nil
+}
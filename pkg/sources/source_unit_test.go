@@ -0,0 +1,64 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommonSourceUnit_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		unit CommonSourceUnit
+	}{
+		{
+			name: "id only",
+			unit: CommonSourceUnit{ID: "/tmp/dir"},
+		},
+		{
+			name: "with metadata",
+			unit: CommonSourceUnit{
+				ID:       "/tmp/file.txt",
+				Metadata: map[string]string{"kind": "file", "size": "42"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.unit)
+			assert.NoError(t, err)
+
+			got, err := CommonSourceUnitUnmarshaller{}.UnmarshalSourceUnit(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.unit, got)
+			assert.Equal(t, tt.unit.ID, got.SourceUnitID())
+		})
+	}
+}
+
+func TestCommonSourceUnitUnmarshaller_Invalid(t *testing.T) {
+	_, err := CommonSourceUnitUnmarshaller{}.UnmarshalSourceUnit([]byte(`{"metadata":{"kind":"file"}}`))
+	assert.Error(t, err)
+}
+
+func TestReadSourceUnits(t *testing.T) {
+	units := []SourceUnit{
+		CommonSourceUnit{ID: "/tmp/dir"},
+		CommonSourceUnit{ID: "/tmp/file.txt", Metadata: map[string]string{"size": "42"}},
+	}
+	var buf bytes.Buffer
+	for _, unit := range units {
+		assert.NoError(t, WriteSourceUnit(&buf, unit))
+	}
+	buf.WriteString("\n")
+
+	got, err := ReadSourceUnits(&buf, CommonSourceUnitUnmarshaller{})
+	assert.NoError(t, err)
+	assert.Equal(t, units, got)
+
+	_, err = ReadSourceUnits(strings.NewReader(`{"source_unit_id":"/tmp/dir"}`+"\n{}\n"), CommonSourceUnitUnmarshaller{})
+	assert.ErrorContains(t, err, "line 2")
+}
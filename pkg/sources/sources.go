@@ -2,11 +2,14 @@ package sources
 
 import (
 	"sync"
+	"time"
 
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 )
@@ -21,11 +24,48 @@ type Chunk struct {
 	SourceType sourcespb.SourceType
 	// SourceMetadata holds the context of where the Chunk was found.
 	SourceMetadata *source_metadatapb.MetaData
+	// SourceUnitID identifies the unit of the source the chunk came from,
+	// such as the path of a file, so that results can be grouped by it. It
+	// is empty for sources that don't set it.
+	SourceUnitID string
+	// ArchiveEntry is the path within the archive of the entry the chunk was
+	// extracted from, if any. It is sanitized to be relative to the root of
+	// the archive, so it can't be used to reach outside of it. Entries of
+	// nested archives are prefixed with the path of the containing entry.
+	ArchiveEntry string
+	// DetectorHints are the detectors the source expects to find secrets in
+	// the chunk, such as AWS for a .aws/credentials file. They're a hint to
+	// run those detectors first, not a restriction: all detectors still run.
+	// Sources may share the slice between chunks, so it must not be modified.
+	DetectorHints []detectorspb.DetectorType
 
 	// Data is the data to decode and scan.
 	Data []byte
 	// Verify specifies whether any secrets in the Chunk should be verified.
 	Verify bool
+	// DecoderType is set by sources that decode Data before emitting it, such
+	// as base64 content found within a file. When set, it is reported on
+	// results instead of the decoder the engine used to scan the chunk.
+	DecoderType detectorspb.DecoderType
+}
+
+// Clone returns a deep copy of the chunk. Chunks are shared by reference as
+// they move through the engine, so a chunk must be cloned before being handed
+// to a consumer that modifies its Data or SourceMetadata while others may
+// still be reading it.
+func (c *Chunk) Clone() *Chunk {
+	clone := *c
+	if c.Data != nil {
+		clone.Data = make([]byte, len(c.Data))
+		copy(clone.Data, c.Data)
+	}
+	if c.DetectorHints != nil {
+		clone.DetectorHints = append([]detectorspb.DetectorType(nil), c.DetectorHints...)
+	}
+	if c.SourceMetadata != nil {
+		clone.SourceMetadata = proto.Clone(c.SourceMetadata).(*source_metadatapb.MetaData)
+	}
+	return &clone
 }
 
 // Source defines the interface required to implement a source chunker.
@@ -39,6 +79,10 @@ type Source interface {
 	// Init initializes the source.
 	Init(aCtx context.Context, name string, jobId, sourceId int64, verify bool, connection *anypb.Any, concurrency int) error
 	// Chunks emits data over a channel that is decoded and scanned for secrets.
+	// Chunks must be sent as soon as they are produced rather than buffered
+	// until the scan is done, so that findings of long scans are reported as
+	// they are found. A send may block until the chunk is received, so the
+	// channel's capacity is what bounds how far a source runs ahead.
 	Chunks(ctx context.Context, chunksChan chan *Chunk) error
 	// GetProgress is the completion progress (percentage) for Scanned Source.
 	GetProgress() *Progress
@@ -62,6 +106,54 @@ type SourceUnitEnumerator interface {
 	Enumerate(ctx context.Context, units chan<- EnumerationResult) error
 }
 
+// SourceUnitChunker defines an optional interface a Source can implement to
+// support chunking a single SourceUnit.
+type SourceUnitChunker interface {
+	// ChunkUnit creates 0 or more chunks from a unit, outputting them or
+	// any errors to the provided channel. An error should only be
+	// returned from this method in the case of context cancellation. All
+	// other errors related to unit chunking are tracked in the
+	// ChunkResult.
+	ChunkUnit(ctx context.Context, unit SourceUnit, chunks chan<- ChunkResult) error
+}
+
+// SourceUnitBatchChunker defines an optional interface a SourceUnitChunker
+// can implement to output the results of a unit in batches, which reduces
+// contention on the channel when units produce many small chunks.
+type SourceUnitBatchChunker interface {
+	// ChunkUnitBatches is like ChunkUnit, but outputs the results in
+	// batches. Every result is sent exactly once, and a unit's last batch
+	// is sent when it's finished, so batches never span units.
+	ChunkUnitBatches(ctx context.Context, unit SourceUnit, batches chan<- []ChunkResult) error
+}
+
+// Pauser defines an optional interface a Source can implement to support
+// halting a running scan without cancelling it.
+type Pauser interface {
+	// Pause halts the scan at the next point it can resume from. Cancelling
+	// the scan's context still stops it while paused.
+	Pause()
+	// Resume continues a paused scan.
+	Resume()
+}
+
+// Resettable defines an optional interface a Source can implement to be
+// reused for another scan without being initialized again.
+type Resettable interface {
+	// Reset clears the progress and any other state kept from previous
+	// scans, while keeping resources that are expensive to set up, such as
+	// clients and caches. It must not be called while a scan is running.
+	Reset(ctx context.Context) error
+}
+
+// ChunkResult is the output unit of a ChunkUnit, containing the chunk and
+// error if any. Chunk and Error are mutually exclusive (only one will be
+// non-nil).
+type ChunkResult struct {
+	Chunk *Chunk
+	Error error
+}
+
 // EnumerationResult is the result of an enumeration, containing the unit and
 // error if any. Unit and Error are mutually exclusive (only one will be
 // non-nil).
@@ -116,7 +208,8 @@ type GitConfig struct {
 	MaxDepth int
 	// Filter is the filter to use to scan the source.
 	Filter *common.Filter
-	// ExcludeGlobs is a list of globs to exclude from the scan.
+	// ExcludeGlobs is a list of globs to exclude from the scan. See
+	// common.GlobFilter for the pattern syntax.
 	// This differs from the Filter exclusions as ExcludeGlobs is applied at the `git log -p` level
 	ExcludeGlobs []string
 }
@@ -163,6 +256,162 @@ type FilesystemConfig struct {
 	Paths []string
 	// Filter is the filter to use to scan the source.
 	Filter *common.Filter
+	// Filters are additional filters, such as an organization-wide filter
+	// and a project-specific one. A path is scanned if it passes every one
+	// of them, or any one of them if FiltersAny is set, as well as Filter.
+	Filters    []*common.Filter
+	FiltersAny bool
+	// WholeFileThreshold is the size in bytes below which a file is emitted as
+	// a single chunk containing its entire content. Zero disables this.
+	WholeFileThreshold int64
+	// DecodeBase64 enables emitting additional chunks for base64 encoded
+	// content found in files, including nested encodings.
+	DecodeBase64 bool
+	// EmitEmptyFiles emits a chunk without data for each empty file, so that
+	// every scanned file shows up in the output.
+	EmitEmptyFiles bool
+	// SkipDirs is a list of directory names that are never descended into.
+	// Directories are matched by their base name.
+	SkipDirs []string
+	// HiddenFiles selects the files scanned when walking directories based
+	// on whether their name, or that of a directory they're in, starts with
+	// a dot: "include" (the default) scans all files, "exclude" skips hidden
+	// ones and "only" scans nothing else.
+	HiddenFiles string
+	// NoDefaultSkipDirs disables skipping the default set of directories
+	// (such as .git and node_modules) when walking directories.
+	NoDefaultSkipDirs bool
+	// WarningReporter optionally receives structured warnings for paths that
+	// could not be scanned.
+	WarningReporter WarningReporter
+	// LifecycleHook optionally receives the lifecycle events of the source,
+	// such as its start and finish with timings.
+	LifecycleHook LifecycleHook
+	// CheckpointReporter optionally receives the ID of each file once it was
+	// scanned, for resuming the scan by setting CompletedUnits.
+	CheckpointReporter CheckpointReporter
+	// CompletedUnits are the IDs of the files a previous scan reported as
+	// completed to its CheckpointReporter, which are skipped.
+	CompletedUnits []string
+	// PathSanitizer optionally transforms the paths stored in chunk metadata,
+	// for example to mask user names in reports.
+	PathSanitizer func(path string) string
+	// SkipCachePath is the path of a file used to persist fingerprints of
+	// scanned files across runs. Unchanged files are skipped when set.
+	SkipCachePath string
+	// SkipCacheContentHash fingerprints files by a hash of their content
+	// instead of their size and modification time.
+	SkipCacheContentHash bool
+	// ForceRescan scans every file regardless of the skip cache.
+	ForceRescan bool
+	// MaxOpenFiles limits the number of files open at once while scanning.
+	// Zero means filesystem.DefaultMaxOpenFiles.
+	MaxOpenFiles int
+	// ChunkBatchSize groups the chunks of the units scanned from a units
+	// file into batches of this size before they're passed on. Zero
+	// disables batching.
+	ChunkBatchSize int
+	// ReadBytesPerSecond limits how fast file contents are read. Zero means
+	// unlimited.
+	ReadBytesPerSecond int64
+	// AnnotateGit records in the metadata of findings in files in git
+	// working trees the email of the author of the last commit to the file,
+	// or of the line for findings of a diff, normalized with .mailmap.
+	AnnotateGit bool
+	// SampleRate scans only this fraction of the files found in
+	// directories, selected by a hash of their path, for a quick triage of
+	// whether a large tree likely contains secrets. It is not a complete
+	// scan. Zero scans every file.
+	SampleRate float64
+	// ProgressInterval updates the progress message with the file being
+	// scanned and the counts so far at this interval, in addition to when
+	// each path is started. Zero only updates it per path.
+	ProgressInterval time.Duration
+	// ExcludeGlobs is a list of globs, matched against paths relative to
+	// each scanned directory, to exclude from the scan. They follow the same
+	// rules as GitConfig.ExcludeGlobs, see common.GlobFilter.
+	ExcludeGlobs []string
+	// ExcludeFalsePositivePaths skips the files in directories that are
+	// known to produce false positives, such as minified assets, lockfiles
+	// and test fixtures. See filesystem.DefaultFalsePositivePaths.
+	ExcludeFalsePositivePaths bool
+	// FalsePositivePaths are globs, like ExcludeGlobs, of further files
+	// skipped with ExcludeFalsePositivePaths.
+	FalsePositivePaths []string
+	// HighSignalOnly only scans the files in locations strongly associated
+	// with secrets, such as dotenv files and private keys, for a fast high
+	// signal pass. See filesystem.DefaultHighSignalPaths.
+	HighSignalOnly bool
+	// HighSignalPaths are globs, like ExcludeGlobs, of further files
+	// scanned with HighSignalOnly.
+	HighSignalPaths []string
+	// HeadBytes limits scanning to the first HeadBytes bytes of each file.
+	// Zero means whole file.
+	HeadBytes int64
+	// DiffPath is the path to a unified diff. When set, only the lines added
+	// by the diff are scanned, with the diff's file paths resolved relative
+	// to the single configured path.
+	DiffPath string
+	// ManifestPath is the path to a JSON manifest of further paths to scan,
+	// each with its own label, max file size and filter. See
+	// filesystem.LoadManifest.
+	ManifestPath string
+	// ArchiveIncludeGlobs restricts the entries scanned inside archives to
+	// those whose path within the archive matches one of the globs. All
+	// entries are scanned if it is empty. See common.GlobFilter.
+	ArchiveIncludeGlobs []string
+	// ArchiveExcludeGlobs is a list of globs for entries inside archives that
+	// are not scanned. See common.GlobFilter.
+	ArchiveExcludeGlobs []string
+	// ArchiveMaxRatio aborts extracting an archive once the extracted data
+	// exceeds this multiple of the archive's size. Zero means unlimited.
+	ArchiveMaxRatio int
+	// ArchiveMaxExtractedSize aborts extracting an archive once more than
+	// this many bytes were extracted from it. Zero means unlimited.
+	ArchiveMaxExtractedSize int64
+	// Labels maps path prefixes to a label attached to the metadata of
+	// findings in files under them, e.g. to group findings by environment.
+	Labels map[string]string
+	// ScanReferencedCredentials also scans the credential files read by the
+	// package manager of each lockfile found, such as ~/.npmrc for
+	// package-lock.json, even if they're outside of Paths.
+	ScanReferencedCredentials bool
+	// FollowFileSymlinks resolves the symlinks to files found while walking
+	// directories and scans each target once, skipping those under Paths,
+	// which are scanned anyway, and those outside of them unless
+	// AllowSymlinkEscape is set. Otherwise symlinks to files are scanned like
+	// the files themselves. Symlinks to directories are skipped regardless,
+	// to avoid loops.
+	FollowFileSymlinks bool
+	// AllowSymlinkEscape scans the targets of followed symlinks that are
+	// outside of Paths. They're skipped otherwise, so that a crafted symlink
+	// can't make the scan read arbitrary system files.
+	AllowSymlinkEscape bool
+	// DetectEncoding transcodes files detected as UTF-16 or Latin-1 to UTF-8
+	// before they're chunked, recording the original encoding in the
+	// metadata.
+	DetectEncoding bool
+	// ResolveGitLFS scans the object each Git LFS pointer file points to,
+	// read from the local LFS cache of its repository, in place of the
+	// pointer file. Pointers whose object isn't cached are skipped.
+	ResolveGitLFS bool
+	// ContentTypeAllow restricts the scan to files whose content type,
+	// detected from their first bytes regardless of their extension, matches
+	// one of the media types, such as "application/json", or wildcards, such
+	// as "text/*". All files are scanned if it is empty.
+	ContentTypeAllow []string
+	// ScanFileAttributes also scans the extended attributes of files on
+	// Linux and macOS, or their alternate data streams on Windows, where
+	// secrets can hide from tools that only read file contents.
+	ScanFileAttributes bool
+	// ScanImageTarballs scans the files of container images saved as
+	// tarballs, such as by `docker save`, layer by layer instead of the
+	// tarballs themselves, skipping files deleted or replaced by upper layers.
+	ScanImageTarballs bool
+	// SizePercentileCutoff skips files larger than this percentile of the
+	// sizes of the files to scan, e.g. 90 skips the largest 10%. The sizes
+	// are surveyed in a pre-pass over the paths. Zero disables the cutoff.
+	SizePercentileCutoff int
 }
 
 // S3Config defines the optional configuration for an S3 source.
@@ -206,6 +455,9 @@ type Progress struct {
 	EncodedResumeInfo string
 	SectionsCompleted int32
 	SectionsRemaining int32
+	// Indeterminate is set for sources that can't know their total scope,
+	// such as streams. PercentComplete is -1 while it is set.
+	Indeterminate bool
 }
 
 // Validator is an interface for validating a source. Sources can optionally implement this interface to validate
@@ -227,6 +479,7 @@ func (p *Progress) SetProgressComplete(i, scope int, message, encodedResumeInfo
 	p.EncodedResumeInfo = encodedResumeInfo
 	p.SectionsCompleted = int32(i)
 	p.SectionsRemaining = int32(scope)
+	p.Indeterminate = false
 
 	// If the iteration and scope are both 0, completion is 100%.
 	if i == 0 && scope == 0 {
@@ -237,6 +490,57 @@ func (p *Progress) SetProgressComplete(i, scope int, message, encodedResumeInfo
 	p.PercentComplete = int64((float64(i) / float64(scope)) * 100)
 }
 
+// SetIndeterminateProgress marks a running job's progress as indeterminate,
+// for sources that don't know their scope upfront, so that UIs can show
+// activity instead of a misleading percentage. PercentComplete is set to -1
+// until SetProgressComplete is called again.
+func (p *Progress) SetIndeterminateProgress(message string) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.Message = message
+	p.Indeterminate = true
+	p.PercentComplete = -1
+	p.SectionsRemaining = 0
+}
+
+// SetProgressMessage replaces the public facing message of a running job
+// without changing its completion, for sources that report what they're
+// working on more often than they complete sections.
+func (p *Progress) SetProgressMessage(message string) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.Message = message
+}
+
+// ProgressMessage returns the public facing message of the job. Unlike
+// reading Message, it is safe to call while the job runs.
+func (p *Progress) ProgressMessage() string {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	return p.Message
+}
+
+// PercentCompleteFloat returns the job completion percentage without
+// truncating it to a whole number, so progress bars can advance smoothly when
+// a source has many small sections. SectionsRemaining holds the total scope
+// as set by SetProgressComplete; an empty scope is reported as complete and
+// indeterminate progress as -1.
+func (p *Progress) PercentCompleteFloat() float64 {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if p.Indeterminate {
+		return -1
+	}
+	if p.SectionsRemaining == 0 {
+		return 100
+	}
+	return float64(p.SectionsCompleted) / float64(p.SectionsRemaining) * 100
+}
+
 // GetProgress gets job completion percentage for metrics reporting.
 func (p *Progress) GetProgress() *Progress {
 	p.mut.Lock()
@@ -244,6 +548,20 @@ func (p *Progress) GetProgress() *Progress {
 	return p
 }
 
+// ClearProgress resets job progress to its initial state, including any
+// resume information, for sources that are reused for another scan.
+func (p *Progress) ClearProgress() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.PercentComplete = 0
+	p.Message = ""
+	p.EncodedResumeInfo = ""
+	p.SectionsCompleted = 0
+	p.SectionsRemaining = 0
+	p.Indeterminate = false
+}
+
 // CommonEnumerationOk is a helper function to construct an EnumerationResult
 // using a CommonSourceUnit.
 func CommonEnumerationOk(id string) EnumerationResult {
@@ -251,8 +569,25 @@ func CommonEnumerationOk(id string) EnumerationResult {
 	return EnumerationResult{Unit: unit}
 }
 
+// CommonEnumerationOkWithMetadata is a helper function to construct an
+// EnumerationResult using a CommonSourceUnit with metadata.
+func CommonEnumerationOkWithMetadata(id string, metadata map[string]string) EnumerationResult {
+	unit := CommonSourceUnit{ID: id, Metadata: metadata}
+	return EnumerationResult{Unit: unit}
+}
+
 // EnumerationErr is a helper function to construct an EnumerationResult from
 // an error.
 func EnumerationErr(err error) EnumerationResult {
 	return EnumerationResult{Error: err}
 }
+
+// ChunkOk is a helper function to construct a ChunkResult from a chunk.
+func ChunkOk(chunk *Chunk) ChunkResult {
+	return ChunkResult{Chunk: chunk}
+}
+
+// ChunkErr is a helper function to construct a ChunkResult from an error.
+func ChunkErr(err error) ChunkResult {
+	return ChunkResult{Error: err}
+}
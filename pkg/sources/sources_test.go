@@ -0,0 +1,110 @@
+package sources
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+func testChunk() *Chunk {
+	return &Chunk{
+		SourceName: "test",
+		SourceID:   1,
+		SourceType: sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM,
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: "file.txt", Line: 1},
+			},
+		},
+		Data:   []byte("original data"),
+		Verify: true,
+	}
+}
+
+func TestChunk_Clone(t *testing.T) {
+	original := testChunk()
+	clone := original.Clone()
+
+	assert.Equal(t, original.Data, clone.Data)
+	assert.True(t, proto.Equal(original.SourceMetadata, clone.SourceMetadata))
+
+	clone.Data[0] = 'X'
+	clone.SourceMetadata.GetFilesystem().File = "other.txt"
+	assert.Equal(t, "original data", string(original.Data))
+	assert.Equal(t, "file.txt", original.SourceMetadata.GetFilesystem().GetFile())
+
+	empty := (&Chunk{}).Clone()
+	assert.Nil(t, empty.Data)
+	assert.Nil(t, empty.SourceMetadata)
+}
+
+// TestChunk_CloneConcurrent mutates clones from many goroutines. Run with
+// -race to verify the clones share no memory with the original.
+func TestChunk_CloneConcurrent(t *testing.T) {
+	original := testChunk()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		clone := original.Clone()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := range clone.Data {
+				clone.Data[j] = byte(i)
+			}
+			md := clone.SourceMetadata.GetFilesystem()
+			md.File = fmt.Sprintf("file-%d.txt", i)
+			md.Line = int64(i)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.True(t, proto.Equal(testChunk().SourceMetadata, original.SourceMetadata))
+	assert.Equal(t, testChunk().Data, original.Data)
+}
+
+func TestProgress_PercentCompleteFloat(t *testing.T) {
+	tests := []struct {
+		name        string
+		i, scope    int
+		wantFloat   float64
+		wantPercent int64
+	}{
+		{name: "empty scope", i: 0, scope: 0, wantFloat: 100, wantPercent: 100},
+		{name: "not started", i: 0, scope: 3000, wantFloat: 0, wantPercent: 0},
+		{name: "fraction of a percent", i: 1, scope: 3000, wantFloat: 100.0 / 3000, wantPercent: 0},
+		{name: "between whole percents", i: 1999, scope: 3000, wantFloat: 1999.0 / 3000 * 100, wantPercent: 66},
+		{name: "complete", i: 3000, scope: 3000, wantFloat: 100, wantPercent: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Progress
+			p.SetProgressComplete(tt.i, tt.scope, "", "")
+			assert.InDelta(t, tt.wantFloat, p.PercentCompleteFloat(), 1e-9)
+			assert.Equal(t, tt.wantPercent, p.PercentComplete)
+		})
+	}
+}
+
+func TestProgress_SetIndeterminateProgress(t *testing.T) {
+	var p Progress
+	p.SetProgressComplete(3, 10, "determinate", "")
+	p.SetIndeterminateProgress("listening on :514")
+
+	assert.True(t, p.Indeterminate)
+	assert.Equal(t, "listening on :514", p.Message)
+	assert.Equal(t, int64(-1), p.PercentComplete)
+	assert.Equal(t, -1.0, p.PercentCompleteFloat())
+
+	// Reporting a known scope returns to determinate progress.
+	p.SetProgressComplete(5, 10, "determinate", "")
+	assert.False(t, p.Indeterminate)
+	assert.Equal(t, int64(50), p.PercentComplete)
+	assert.Equal(t, 50.0, p.PercentCompleteFloat())
+}
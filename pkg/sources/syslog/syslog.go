@@ -183,6 +183,8 @@ func (s *Source) verifyConnectionConfig() error {
 
 // Chunks emits chunks of bytes over a channel.
 func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) error {
+	// A syslog stream has no known end, so there is no meaningful percentage.
+	s.SetIndeterminateProgress(fmt.Sprintf("Listening on %s", s.conn.ListenAddress))
 	switch {
 	case s.conn.TlsCert != nilString || s.conn.TlsKey != nilString:
 		cert, err := tls.X509KeyPair([]byte(s.conn.TlsCert), []byte(s.conn.TlsKey))
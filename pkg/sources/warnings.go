@@ -0,0 +1,76 @@
+package sources
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WarningCode identifies the kind of non-fatal problem a source encountered.
+type WarningCode string
+
+const (
+	// WarningUnableToStat indicates file information could not be read.
+	WarningUnableToStat WarningCode = "unable_to_stat"
+	// WarningNotRegularFile indicates a path was skipped because it is not a
+	// regular file.
+	WarningNotRegularFile WarningCode = "not_regular_file"
+	// WarningScanError indicates an error occurred while scanning a path.
+	WarningScanError WarningCode = "scan_error"
+	// WarningNoDiskBuffer indicates a file was scanned without buffering it
+	// in a temporary file, such as when the temporary directory is full or
+	// read-only, so archives in it weren't extracted.
+	WarningNoDiskBuffer WarningCode = "no_disk_buffer"
+)
+
+// SourceWarning is a structured, non-fatal problem encountered by a source
+// that can be surfaced to users outside of the logs.
+type SourceWarning struct {
+	Code    WarningCode
+	Message string
+	Path    string
+}
+
+func (w SourceWarning) String() string {
+	return fmt.Sprintf("%s: %s (%s)", w.Code, w.Message, w.Path)
+}
+
+// WarningReporter receives structured warnings emitted by sources.
+// Implementations must be safe for concurrent use.
+type WarningReporter interface {
+	ReportWarning(warning SourceWarning)
+}
+
+// SourceWarnings is a WarningReporter that collects warnings in a thread-safe
+// manner.
+type SourceWarnings struct {
+	mu       sync.RWMutex
+	warnings []SourceWarning
+}
+
+// NewSourceWarnings creates a new thread safe warning collector.
+func NewSourceWarnings() *SourceWarnings {
+	return &SourceWarnings{warnings: make([]SourceWarning, 0)}
+}
+
+// ReportWarning implements the WarningReporter interface.
+func (s *SourceWarnings) ReportWarning(warning SourceWarning) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.warnings = append(s.warnings, warning)
+}
+
+// Warnings returns a copy of the warnings collected.
+func (s *SourceWarnings) Warnings() []SourceWarning {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	warnings := make([]SourceWarning, len(s.warnings))
+	copy(warnings, s.warnings)
+	return warnings
+}
+
+// Count returns the number of warnings collected.
+func (s *SourceWarnings) Count() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return uint64(len(s.warnings))
+}
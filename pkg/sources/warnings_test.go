@@ -0,0 +1,27 @@
+package sources
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceWarnings(t *testing.T) {
+	warnings := NewSourceWarnings()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			warnings.ReportWarning(SourceWarning{Code: WarningScanError, Message: "oops", Path: "/tmp"})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(50), warnings.Count())
+	for _, w := range warnings.Warnings() {
+		assert.Equal(t, WarningScanError, w.Code)
+	}
+}